@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
 	"github.com/kasbench/globeco-allocation-service/internal/config"
@@ -58,7 +57,11 @@ func main() {
 	}
 
 	// Initialize business metrics (legacy Prometheus)
-	businessMetrics := observability.NewBusinessMetrics(logger)
+	businessMetrics := observability.NewBusinessMetrics(logger, observability.HistogramBuckets{
+		ExecutionProcessing: cfg.Observability.MetricsExecutionProcessingBuckets,
+		BatchProcessing:     cfg.Observability.MetricsBatchProcessingBuckets,
+		TradeServiceLatency: cfg.Observability.MetricsTradeServiceLatencyBuckets,
+	})
 
 	// Initialize OpenTelemetry metrics manager
 	otelMetrics, err := observability.NewOTELMetricsManager(logger)
@@ -66,6 +69,16 @@ func main() {
 		logger.Fatal("Failed to initialize OpenTelemetry metrics", zap.Error(err))
 	}
 
+	// Service and repository metrics are recorded once, on the configured backend(s)
+	metricsRecorder, err := observability.NewMetricsRecorder(cfg.Observability.MetricsBackend, businessMetrics, otelMetrics)
+	if err != nil {
+		logger.Fatal("Failed to initialize metrics recorder", zap.Error(err))
+	}
+	if cfg.Observability.MetricsBackend != observability.MetricsBackendPrometheus && !cfg.Observability.OTELEnabled {
+		logger.Warn("Metrics backend records to OpenTelemetry but OpenTelemetry is disabled; those metrics are not exported",
+			zap.String("metrics_backend", cfg.Observability.MetricsBackend))
+	}
+
 	// Initialize database connection
 	db, err := repository.NewPostgresDB(cfg.Database)
 	if err != nil {
@@ -77,25 +90,75 @@ func main() {
 		}
 	}()
 
+	db.SetMetrics(metricsRecorder)
+
 	// Initialize repositories
 	executionRepo := repository.NewExecutionRepository(db, logger)
 	batchHistoryRepo := repository.NewBatchHistoryRepository(db, logger)
+	auditLogRepo := repository.NewAuditLogRepository(db, logger)
+	skippedExecutionRepo := repository.NewSkippedExecutionRepository(db, logger)
+	rejectedExecutionRepo := repository.NewRejectedExecutionRepository(db, logger)
 
 	// Initialize services with metrics integration
 	tradeClient := service.NewTradeServiceClient(cfg.TradeServiceURL, logger)
-	tradeClient.SetRetryConfig(cfg.RetryMaxAttempts, time.Duration(cfg.RetryBaseDelay)*time.Millisecond)
+	tradeClient.SetRetryConfig(cfg.RetryMaxAttempts, cfg.RetryBaseDelay)
+	tradeClient.SetRetryJitter(cfg.RetryJitter)
+	tradeClient.SetMaxRetryDuration(cfg.RetryMaxDuration)
+	tradeClient.SetDebugLogging(cfg.TradeServiceDebugLogging)
+	tradeClient.SetCorrelationHeader(cfg.Observability.LogCorrelationHeader)
+	tradeClient.SetPortfolioCache(
+		cfg.PortfolioCacheSize,
+		cfg.PortfolioCacheTTL,
+		cfg.PortfolioCacheNegativeTTL,
+	)
+	tradeClient.SetMetrics(metricsRecorder)
 
-	executionService := service.NewExecutionService(
+	executionService, err := service.NewExecutionService(
 		executionRepo,
 		batchHistoryRepo,
 		tradeClient,
 		logger,
 		cfg,
 	)
+	if err != nil {
+		logger.Fatal("Failed to initialize execution service", zap.Error(err))
+	}
+	executionService.SetAuditRepository(auditLogRepo)
+	executionService.SetSkippedExecutionRepository(skippedExecutionRepo)
+	executionService.SetRejectedExecutionRepository(rejectedExecutionRepo)
+	executionService.SetMetrics(metricsRecorder)
+	executionBroker := service.NewExecutionBroker(cfg.ExecutionStreamMaxSubscribers, logger)
+	executionService.SetExecutionBroker(executionBroker)
+	if err := executionService.SeedLastSuccessfulSend(context.Background()); err != nil {
+		logger.Warn("Failed to seed last successful send metric", zap.Error(err))
+	}
+	if cfg.TradeDateCheckSampleSize > 0 {
+		if _, err := executionService.CheckTradeDates(context.Background(), cfg.TradeDateCheckSampleSize); err != nil {
+			logger.Warn("Failed to check stored trade dates", zap.Error(err))
+		}
+	}
+	var completionWebhook *service.WebhookNotifier
+	if cfg.SendCompletionWebhookURL != "" {
+		completionWebhook = service.NewWebhookNotifier(
+			cfg.SendCompletionWebhookURL,
+			cfg.SendCompletionWebhookTimeout,
+			logger,
+		)
+		executionService.SetCompletionWebhook(completionWebhook)
+	}
 
 	// Initialize handlers with structured logging
 	executionHandler := handler.NewExecutionHandler(executionService, logger)
+	executionHandler.SetDecimalStrings(cfg.JSONDecimalStrings)
 	healthHandler := handler.NewHealthHandler(db, logger)
+	if cfg.OutputDirCheck {
+		healthHandler.SetOutputDirCheck(cfg.OutputDir)
+	}
+	healthHandler.SetPoolWaitThreshold(cfg.Database.PoolWaitThreshold)
+	healthHandler.SetWarmup(cfg.ReadinessWarmupDelay, cfg.ReadinessWarmupMinChecks)
+	if cfg.CLIHealthCheckEnabled {
+		healthHandler.SetCLICheck(executionService)
+	}
 
 	// Setup router with observability middleware
 	r := setupRouterWithObservability(cfg, structuredLogger, businessMetrics, otelMetrics, executionHandler, healthHandler)
@@ -147,6 +210,29 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Shutdown waits for open connections, so end execution streams when it starts
+	srv.RegisterOnShutdown(executionBroker.Close)
+
+	// Start the in-process Send schedule, if configured
+	var sendScheduler *service.SendScheduler
+	if cfg.SendSchedule != "" {
+		sendScheduler, err = service.NewSendScheduler(cfg.SendSchedule, executionService, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize send scheduler", zap.Error(err))
+		}
+		sendScheduler.Start()
+	}
+
+	// Start archiving sent executions past their retention, if enabled
+	var executionArchiver *service.ExecutionArchiver
+	if cfg.ExecutionRetention > 0 {
+		executionArchiver, err = service.NewExecutionArchiver(cfg, executionRepo, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize execution archiver", zap.Error(err))
+		}
+		executionArchiver.SetMetrics(metricsRecorder)
+		executionArchiver.Start()
+	}
 
 	// Start server in a goroutine
 	go func() {
@@ -156,6 +242,17 @@ func main() {
 		}
 	}()
 
+	// Serve metrics on their own address, if configured
+	metricsSrv := newMetricsServer(cfg, logger)
+	if metricsSrv != nil {
+		go func() {
+			logger.Info("Metrics server starting", zap.String("addr", metricsSrv.Addr))
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -163,10 +260,32 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Fail readiness first and keep serving for the pre-stop delay, so the load balancer
+	// stops sending traffic before connections start closing
+	healthHandler.StartShutdown()
+	if cfg.ShutdownPreStopDelay > 0 {
+		logger.Info("Waiting before shutdown for the load balancer to drain", zap.Duration("delay", cfg.ShutdownPreStopDelay))
+		time.Sleep(cfg.ShutdownPreStopDelay)
+	}
+
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Stop scheduled Sends, letting a running one finish
+	if sendScheduler != nil {
+		if err := sendScheduler.Stop(ctx); err != nil {
+			logger.Error("Failed to stop send scheduler", zap.Error(err))
+		}
+	}
+
+	// Stop archival; a running pass ends after its current batch
+	if executionArchiver != nil {
+		if err := executionArchiver.Stop(ctx); err != nil {
+			logger.Error("Failed to stop execution archiver", zap.Error(err))
+		}
+	}
+
 	// Shutdown OpenTelemetry
 	if otelManager != nil {
 		if err := otelManager.Shutdown(ctx); err != nil {
@@ -179,6 +298,20 @@ func main() {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Deliver the completion webhooks of Sends that finished before the server stopped
+	if completionWebhook != nil {
+		if err := completionWebhook.Stop(ctx); err != nil {
+			logger.Error("Failed to deliver pending send completion webhooks", zap.Error(err))
+		}
+	}
+
+	// Metrics stop last so the final scrapes during shutdown still succeed
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("Metrics server forced to shutdown", zap.Error(err))
+		}
+	}
+
 	logger.Info("Server exited")
 }
 
@@ -191,6 +324,7 @@ func initStructuredLogger(cfg *config.Config) (*observability.StructuredLogger,
 		Development:         cfg.Observability.LogDevelopment,
 		DisableSampling:     cfg.Observability.LogDisableSampling,
 		CorrelationIDHeader: cfg.Observability.LogCorrelationHeader,
+		RequestIDHeader:     cfg.Observability.LogRequestIDHeader,
 		InitialFields: map[string]interface{}{
 			"service":     "globeco-allocation-service",
 			"version":     "1.0.0",
@@ -212,7 +346,7 @@ func setupRouterWithObservability(
 	r := chi.NewRouter()
 
 	// Core middleware
-	r.Use(middleware.RequestID)
+	r.Use(structuredLogger.RequestIDMiddleware())
 	r.Use(structuredLogger.CorrelationIDMiddleware())
 	
 	// OpenTelemetry tracing middleware (before logging for proper trace context)
@@ -220,8 +354,14 @@ func setupRouterWithObservability(
 		r.Use(internalMiddleware.OTELTracing(cfg.Observability.OTELServiceName, structuredLogger.Logger()))
 	}
 	
-	r.Use(internalMiddleware.Logger(structuredLogger.Logger()))
-	r.Use(middleware.Recoverer)
+	var probePaths []string
+	if cfg.Observability.LogSuppressProbes {
+		probePaths = []string{"/healthz", "/readyz", metricsPath(cfg)}
+	}
+	trustedProxies, _ := cfg.TrustedProxyNets() // validated when the config was loaded
+	clientIP := internalMiddleware.NewClientIPResolver(trustedProxies)
+	r.Use(internalMiddleware.Logger(structuredLogger.Logger(), clientIP, probePaths...))
+	r.Use(internalMiddleware.Recoverer(structuredLogger.Logger()))
 	r.Use(internalMiddleware.CORS())
 
 	// Metrics middleware
@@ -234,28 +374,25 @@ func setupRouterWithObservability(
 		r.Use(internalMiddleware.OTELMetrics(otelMetrics))
 	}
 
-	// Health check endpoints
-	r.Get("/healthz", healthHandler.Liveness)
-	r.Get("/readyz", healthHandler.Readiness)
-
-	// Metrics endpoint
-	if cfg.Observability.MetricsEnabled {
-		metricsPath := cfg.Observability.MetricsPath
-		if metricsPath == "" {
-			metricsPath = "/metrics"
-		}
-		r.Handle(metricsPath, internalMiddleware.MetricsHandler())
+	// Metrics endpoint, unless it has its own server
+	if cfg.Observability.MetricsEnabled && cfg.Observability.MetricsListenAddress == "" {
+		r.Handle(metricsPath(cfg), metricsHandler(cfg, structuredLogger.Logger()))
 	}
 
-	// API routes
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Route("/executions", func(r chi.Router) {
-			r.Get("/", executionHandler.GetExecutions)
-			r.Post("/", executionHandler.CreateExecutions)
-			r.Get("/{id}", executionHandler.GetExecution)
-			r.Post("/send", executionHandler.SendExecutions)
-		})
+	registerRoutes(r, structuredLogger, executionHandler, healthHandler, routeOptions{
+		APITimeout:   cfg.RequestTimeout,
+		SendTimeout:  cfg.SendRequestTimeout,
+		GzipEnabled:  cfg.GzipEnabled,
+		GzipMinBytes: cfg.GzipMinBytes,
 	})
 
 	return r
 }
+
+// metricsPath returns the configured Prometheus scrape path
+func metricsPath(cfg *config.Config) string {
+	if cfg.Observability.MetricsPath == "" {
+		return "/metrics"
+	}
+	return cfg.Observability.MetricsPath
+}
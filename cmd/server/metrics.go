@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	internalMiddleware "github.com/kasbench/globeco-allocation-service/internal/middleware"
+)
+
+// metricsHandler returns the Prometheus scrape handler, requiring the configured bearer
+// token when there is one
+func metricsHandler(cfg *config.Config, logger *zap.Logger) http.Handler {
+	handler := internalMiddleware.MetricsHandler()
+	if cfg.Observability.MetricsAuthToken != "" {
+		handler = internalMiddleware.BearerAuth(cfg.Observability.MetricsAuthToken, logger)(handler)
+	}
+	return handler
+}
+
+// newMetricsServer returns a server for metrics alone on the configured metrics listen
+// address, or nil when metrics are disabled or served on the main router
+func newMetricsServer(cfg *config.Config, logger *zap.Logger) *http.Server {
+	if !cfg.Observability.MetricsEnabled || cfg.Observability.MetricsListenAddress == "" {
+		return nil
+	}
+
+	r := chi.NewRouter()
+	r.Handle(metricsPath(cfg), metricsHandler(cfg, logger))
+	return &http.Server{
+		Addr:         cfg.Observability.MetricsListenAddress,
+		Handler:      r,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
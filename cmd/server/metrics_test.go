@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+)
+
+func TestNewMetricsServer(t *testing.T) {
+	cfg := &config.Config{Observability: config.ObservabilityConfig{MetricsEnabled: true, MetricsPath: "/metrics"}}
+
+	// Without a listen address metrics stay on the main router
+	assert.Nil(t, newMetricsServer(cfg, zap.NewNop()))
+
+	cfg.Observability.MetricsListenAddress = ":9090"
+	srv := newMetricsServer(cfg, zap.NewNop())
+	require.NotNil(t, srv)
+	assert.Equal(t, ":9090", srv.Addr)
+
+	w := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	cfg.Observability.MetricsEnabled = false
+	assert.Nil(t, newMetricsServer(cfg, zap.NewNop()))
+}
+
+func TestMetricsHandler_AuthToken(t *testing.T) {
+	cfg := &config.Config{Observability: config.ObservabilityConfig{MetricsAuthToken: "s3cret"}}
+	handler := metricsHandler(cfg, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
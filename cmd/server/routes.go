@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kasbench/globeco-allocation-service/internal/handler"
+	internalMiddleware "github.com/kasbench/globeco-allocation-service/internal/middleware"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// routeOptions configures the middleware applied to the API route groups
+type routeOptions struct {
+	// Request timeouts for the API routes and for Send and batch retry; zero disables one
+	APITimeout  time.Duration
+	SendTimeout time.Duration
+
+	// GzipEnabled compresses API responses of at least GzipMinBytes for clients that accept it
+	GzipEnabled  bool
+	GzipMinBytes int
+}
+
+// registerRoutes registers the documented endpoints. Every route added here must have a
+// matching path and method in openapi.yaml; TestRoutesMatchOpenAPISpec enforces this.
+func registerRoutes(
+	r chi.Router,
+	structuredLogger *observability.StructuredLogger,
+	executionHandler *handler.ExecutionHandler,
+	healthHandler *handler.HealthHandler,
+	options routeOptions,
+) {
+	apiTimeout := internalMiddleware.RequestTimeout(options.APITimeout, structuredLogger.Logger())
+	sendTimeout := internalMiddleware.RequestTimeout(options.SendTimeout, structuredLogger.Logger())
+	requireJSON := internalMiddleware.RequireJSON(structuredLogger.Logger())
+	compress := func(next http.Handler) http.Handler { return next }
+	if options.GzipEnabled {
+		compress = internalMiddleware.Gzip(options.GzipMinBytes)
+	}
+
+	// Health check endpoints
+	r.Get("/healthz", healthHandler.Liveness)
+	r.Get("/readyz", healthHandler.Readiness)
+
+	// Admin endpoints
+	r.Method(http.MethodGet, "/admin/log-level", structuredLogger.LevelHandler())
+	r.Method(http.MethodPut, "/admin/log-level", structuredLogger.LevelHandler())
+
+	// API routes. Send and batch retry run the CLI, and bulk load a long COPY, so they get
+	// their own, longer timeout; the stream is long-lived and gets neither a timeout nor
+	// compression, which buffer. Routes that decode a JSON body answer any other
+	// Content-Type with 415.
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Route("/executions", func(r chi.Router) {
+			r.Get("/stream", executionHandler.StreamExecutions)
+			r.With(compress, sendTimeout).Post("/send", executionHandler.SendExecutions)
+			r.With(compress, sendTimeout, requireJSON).Post("/bulk-load", executionHandler.BulkLoadExecutions)
+
+			r.Group(func(r chi.Router) {
+				r.Use(compress, apiTimeout)
+				r.Get("/", executionHandler.GetExecutions)
+				r.With(requireJSON).Post("/", executionHandler.CreateExecutions)
+				r.Delete("/", executionHandler.DeleteExecutions)
+				r.Get("/stats", executionHandler.GetExecutionStats)
+				r.Get("/count", executionHandler.GetExecutionCount)
+				r.Get("/rejected", executionHandler.GetRejectedExecutions)
+				r.Get("/{id}", executionHandler.GetExecution)
+				r.With(requireJSON).Post("/reprocess", executionHandler.ReprocessExecutions)
+				r.With(requireJSON).Post("/validate", executionHandler.ValidateExecutions)
+			})
+		})
+		r.Route("/batches", func(r chi.Router) {
+			r.With(compress, apiTimeout).Get("/{id}", executionHandler.GetBatch)
+			r.With(compress, sendTimeout).Post("/{id}/retry", executionHandler.RetryBatch)
+		})
+		r.With(compress, apiTimeout).Get("/audit", executionHandler.GetAuditLogs)
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kasbench/globeco-allocation-service/internal/handler"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// registeredRoutes walks the router and returns "METHOD /path" for every route
+func registeredRoutes(t *testing.T) []string {
+	t.Helper()
+
+	structuredLogger, err := observability.NewStructuredLogger(observability.LoggingConfig{Level: "info", Format: "json"})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	registerRoutes(r, structuredLogger, handler.NewExecutionHandler(nil, zap.NewNop()), handler.NewHealthHandler(nil, zap.NewNop()), routeOptions{GzipEnabled: true})
+
+	var routes []string
+	err = chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// chi reports sub-router index routes with a trailing slash
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	sort.Strings(routes)
+	return routes
+}
+
+// documentedRoutes parses openapi.yaml and returns "METHOD /path" for every operation
+func documentedRoutes(t *testing.T) []string {
+	t.Helper()
+
+	content, err := os.ReadFile("../../openapi.yaml")
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &spec))
+
+	var routes []string
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				routes = append(routes, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	sort.Strings(routes)
+	return routes
+}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	registered := registeredRoutes(t)
+	documented := documentedRoutes(t)
+
+	require.NotEmpty(t, registered)
+	for _, route := range registered {
+		assert.Contains(t, documented, route, "route is registered but missing from openapi.yaml")
+	}
+	for _, route := range documented {
+		assert.Contains(t, registered, route, "openapi.yaml documents a route that is not registered")
+	}
+}
@@ -0,0 +1,50 @@
+// Package apperrors defines sentinel errors shared by the repository, service and
+// handler layers. Callers wrap them with context via fmt.Errorf("...: %w", ...) and
+// check them with errors.Is rather than matching on error strings.
+package apperrors
+
+import "errors"
+
+var (
+	// ErrExecutionNotFound is returned when an execution does not exist or is deleted
+	ErrExecutionNotFound = errors.New("execution not found")
+
+	// ErrBatchNotFound is returned when a batch history record does not exist
+	ErrBatchNotFound = errors.New("batch not found")
+
+	// ErrVersionConflict is returned when an optimistic-locking update matches no row
+	ErrVersionConflict = errors.New("version conflict")
+
+	// ErrDuplicateBatch is returned when another Send has already claimed the batch window
+	ErrDuplicateBatch = errors.New("duplicate batch process already started")
+
+	// ErrBatchKeyInUse is returned when a Send repeats the key of a batch that did not complete
+	ErrBatchKeyInUse = errors.New("batch key already used by a batch that did not complete")
+
+	// ErrBatchNotFailed is returned when retrying a batch that is not in failed status
+	ErrBatchNotFailed = errors.New("batch is not in failed status")
+
+	// ErrReconciliationFailed is returned when a batch is aborted because executions'
+	// quantity * average price diverges from their total amount
+	ErrReconciliationFailed = errors.New("total amount reconciliation failed")
+
+	// ErrSendWindowTooLarge is returned when a Send window spans longer than the configured
+	// maximum and the refuse policy requires the Send to be forced
+	ErrSendWindowTooLarge = errors.New("send window exceeds the maximum duration")
+
+	// ErrSendQueueTimeout is returned when a queued Send waits too long for the one in progress
+	ErrSendQueueTimeout = errors.New("timed out waiting for the send in progress")
+
+	// ErrQueryTimeout is returned when a database query exceeds the configured query timeout
+	ErrQueryTimeout = errors.New("database query timed out")
+
+	// ErrBulkLoadDisabled is returned by BulkLoad when the COPY fast path is not enabled
+	ErrBulkLoadDisabled = errors.New("bulk load is disabled")
+
+	// ErrExecutionsAlreadySent is returned when a delete by filter matches executions
+	// inside a batch window, which have reached or are reaching Portfolio Accounting
+	ErrExecutionsAlreadySent = errors.New("executions already sent in a batch")
+
+	// ErrTooManySubscribers is returned when the execution stream's subscriber cap is reached
+	ErrTooManySubscribers = errors.New("too many execution stream subscribers")
+)
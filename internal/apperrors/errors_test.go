@@ -0,0 +1,23 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentinelsSurviveWrapping(t *testing.T) {
+	sentinels := []error{ErrExecutionNotFound, ErrBatchNotFound, ErrVersionConflict, ErrDuplicateBatch, ErrBatchKeyInUse, ErrBatchNotFailed, ErrReconciliationFailed, ErrQueryTimeout, ErrBulkLoadDisabled, ErrSendQueueTimeout, ErrSendWindowTooLarge}
+
+	for _, sentinel := range sentinels {
+		wrapped := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", sentinel))
+		assert.ErrorIs(t, wrapped, sentinel)
+		for _, other := range sentinels {
+			if other != sentinel {
+				assert.False(t, errors.Is(wrapped, other), "%v should not match %v", wrapped, other)
+			}
+		}
+	}
+}
@@ -1,25 +1,153 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	// Embed the IANA time zone database so trade_date_timezone resolves in
+	// container images that ship without the system tzdata package
+	_ "time/tzdata"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Port               int      `mapstructure:"port"`
-	LogLevel           string   `mapstructure:"log_level"`
-	MetricsEnabled     bool     `mapstructure:"metrics_enabled"`
-	TracingEnabled     bool     `mapstructure:"tracing_enabled"`
-	Database           Database `mapstructure:"database"`
-	TradeServiceURL    string   `mapstructure:"trade_service_url"`
-	OutputDir          string   `mapstructure:"output_dir"`
-	CLICommand         string   `mapstructure:"cli_command"`
-	RetryMaxAttempts   int      `mapstructure:"retry_max_attempts"`
-	RetryBaseDelay     int      `mapstructure:"retry_base_delay_ms"`
-	FileCleanupEnabled bool     `mapstructure:"file_cleanup_enabled"`
+	Port               int               `mapstructure:"port"`
+	LogLevel           string            `mapstructure:"log_level"`
+	MetricsEnabled     bool              `mapstructure:"metrics_enabled"`
+	TracingEnabled     bool              `mapstructure:"tracing_enabled"`
+	Database           Database          `mapstructure:"database"`
+	TradeServiceURL    string            `mapstructure:"trade_service_url"`
+	OutputDir          string            `mapstructure:"output_dir"`
+	OutputDirCheck     bool              `mapstructure:"output_dir_check_enabled"`
+	OutputFormat       string            `mapstructure:"output_format"`
+	CSVLineEnding      string            `mapstructure:"csv_line_ending"`
+	CSVWriteBOM        bool              `mapstructure:"csv_write_bom"`
+	TradeTypeMapping   map[string]string `mapstructure:"trade_type_mapping"`
+	CLICommand         []string          `mapstructure:"cli_command"` // pipeline steps run in order
+	CLIWorkingDir      string            `mapstructure:"cli_working_dir"`
+	CLIEnv             map[string]string `mapstructure:"cli_env"`
+	CLITimeoutGrace    time.Duration     `mapstructure:"cli_timeout_grace_ms"`
+	RetryMaxAttempts   int               `mapstructure:"retry_max_attempts"`
+	RetryBaseDelay     time.Duration     `mapstructure:"retry_base_delay_ms"`
+	RetryJitter        bool              `mapstructure:"retry_jitter"`
+	RetryMaxDuration   time.Duration     `mapstructure:"retry_max_duration_ms"`
+	FileCleanupEnabled bool              `mapstructure:"file_cleanup_enabled"`
+	BatchConcurrency   int               `mapstructure:"batch_concurrency"`
+	TradeDateTimezone  string            `mapstructure:"trade_date_timezone"`
+	SendWindowLag      time.Duration     `mapstructure:"send_window_lag_ms"`
+	MaxSendBatchSize   int               `mapstructure:"max_send_batch_size"`
+	SendSchedule       string            `mapstructure:"send_schedule"`
+
+	// A Send whose executions span longer than max_send_window_duration is split, sending
+	// only the oldest part, or refused unless forced; zero disables the check
+	MaxSendWindowDuration    time.Duration `mapstructure:"max_send_window_duration"`
+	SendWindowOverflowPolicy string        `mapstructure:"send_window_overflow_policy"`
+
+	// Write a <filename>.sha256 sidecar next to each generated file
+	WriteChecksumSidecar bool `mapstructure:"write_checksum_sidecar"`
+
+	// Parse each generated CSV file before invoking the CLI, failing the Send if it is malformed
+	ValidateGeneratedFile bool `mapstructure:"validate_generated_file"`
+
+	// Octal permissions for generated files and the output directory when it is created,
+	// e.g. "0640"; empty keeps 0666 and 0755 less the umask
+	OutputFileMode string `mapstructure:"output_file_mode"`
+	OutputDirMode  string `mapstructure:"output_dir_mode"`
+
+	// Write a separate file per portfolio, each handed to the CLI in turn
+	SplitOutputByPortfolio bool `mapstructure:"split_output_by_portfolio"`
+
+	// Execution statuses a posted execution may carry; anything else is rejected
+	AllowedExecutionStatuses []string `mapstructure:"allowed_execution_statuses"`
+
+	// Recent executions sampled at startup for trade dates that don't match their sent
+	// timestamp in trade_date_timezone; zero skips the check
+	TradeDateCheckSampleSize int `mapstructure:"trade_date_check_sample_size"`
+
+	// Readiness check that runs the CLI with a cheap invocation such as --version
+	CLIHealthCheckEnabled bool          `mapstructure:"cli_health_check_enabled"`
+	CLIHealthCheckCommand string        `mapstructure:"cli_health_check_command"`
+	CLIHealthCheckTimeout time.Duration `mapstructure:"cli_health_check_timeout_ms"`
+
+	// List endpoint page sizes: the limit used when none is given, and the largest accepted
+	DefaultPageSize int `mapstructure:"default_page_size"`
+	MaxPageSize     int `mapstructure:"max_page_size"`
+
+	// Per-request timeouts for the API routes and, separately, for Send and batch retry,
+	// which run the CLI; zero disables a timeout
+	RequestTimeout     time.Duration `mapstructure:"request_timeout_ms"`
+	SendRequestTimeout time.Duration `mapstructure:"send_request_timeout_ms"`
+
+	// Gzip API responses of at least gzip_min_bytes for clients that accept it
+	GzipEnabled  bool `mapstructure:"gzip_enabled"`
+	GzipMinBytes int  `mapstructure:"gzip_min_bytes"`
+
+	// Proxies, as CIDRs or single IPs, whose X-Forwarded-For and X-Real-IP headers are
+	// trusted to name the client in access logs; empty trusts none
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	// Readiness warm-up: /readyz reports 503 until both the delay has passed since start and
+	// this many probes have succeeded
+	ReadinessWarmupDelay     time.Duration `mapstructure:"readiness_warmup_delay_ms"`
+	ReadinessWarmupMinChecks int           `mapstructure:"readiness_warmup_min_checks"`
+
+	// How long to keep serving after SIGTERM with readiness failing, so the load balancer
+	// stops routing here before connections are closed
+	ShutdownPreStopDelay time.Duration `mapstructure:"shutdown_pre_stop_delay_ms"`
+
+	// Most clients GET /api/v1/executions/stream accepts at once
+	ExecutionStreamMaxSubscribers int `mapstructure:"execution_stream_max_subscribers"`
+
+	// What to do with an execution whose portfolio ID lookup fails: "error" or "skip"
+	PortfolioLookupFailurePolicy string `mapstructure:"portfolio_lookup_failure_policy"`
+
+	// What to do with a fill whose quantity filled is zero: "reject", "skip" or "pass"
+	ZeroQuantityFilledPolicy string `mapstructure:"zero_quantity_filled_policy"`
+
+	// Record every skipped or failed create in rejected_execution for later triage
+	RejectedExecutionsEnabled bool `mapstructure:"rejected_executions_enabled"`
+
+	// Encode execution quantities, prices and amounts as decimal strings in responses
+	JSONDecimalStrings bool `mapstructure:"json_decimal_strings"`
+
+	// Pre-Send check that quantity * average price matches total amount
+	ReconciliationEnabled   bool    `mapstructure:"reconciliation_enabled"`
+	ReconciliationTolerance float64 `mapstructure:"reconciliation_tolerance"`
+	ReconciliationPolicy    string  `mapstructure:"reconciliation_policy"`
+
+	// How a created full fill's total amount is set: "trust" the supplied value, "compute"
+	// it as quantity * average price, or "reconcile" the two within ReconciliationTolerance
+	TotalAmountPolicy string `mapstructure:"total_amount_policy"`
+
+	// Queue concurrent Sends in-process instead of rejecting them with a conflict
+	SendQueueEnabled bool          `mapstructure:"send_queue_enabled"`
+	SendQueueMaxWait time.Duration `mapstructure:"send_queue_max_wait_ms"`
+
+	// Callback posted when a Send batch completes; empty disables it
+	SendCompletionWebhookURL     string        `mapstructure:"send_completion_webhook_url"`
+	SendCompletionWebhookTimeout time.Duration `mapstructure:"send_completion_webhook_timeout_ms"`
+
+	// Trade Service portfolio cache
+	PortfolioCacheSize        int           `mapstructure:"portfolio_cache_size"`
+	PortfolioCacheTTL         time.Duration `mapstructure:"portfolio_cache_ttl_ms"`
+	PortfolioCacheNegativeTTL time.Duration `mapstructure:"portfolio_cache_negative_ttl_ms"`
+
+	// Log every Trade Service request and raw response at debug level, with credentials redacted
+	TradeServiceDebugLogging bool `mapstructure:"trade_service_debug_logging"`
+
+	// Move sent executions older than this, such as "2160h", to execution_archive on
+	// archive_schedule, archive_batch_size rows per transaction; zero disables archival
+	ExecutionRetention time.Duration `mapstructure:"execution_retention"`
+	ArchiveSchedule    string        `mapstructure:"archive_schedule"`
+	ArchiveBatchSize   int           `mapstructure:"archive_batch_size"`
 
 	// Observability configuration
 	Observability ObservabilityConfig `mapstructure:"observability"`
@@ -33,6 +161,26 @@ type Database struct {
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	SSLMode  string `mapstructure:"ssl_mode"`
+
+	// PoolWaitThreshold fails readiness when more than this many connection waits
+	// occur between probes; zero only reports pool usage
+	PoolWaitThreshold int64 `mapstructure:"pool_wait_threshold"`
+
+	// Connection pool sizing
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime_ms"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time_ms"`
+
+	// ReplicaDSN is a PostgreSQL connection string for a read replica that serves
+	// reporting queries; empty sends all queries to the primary
+	ReplicaDSN string `mapstructure:"replica_dsn"`
+
+	// QueryTimeout bounds each repository query; zero leaves deadlines to the caller
+	QueryTimeout time.Duration `mapstructure:"query_timeout_ms"`
+
+	// BulkLoadEnabled allows ExecutionRepository.BulkLoad to load executions with COPY
+	BulkLoadEnabled bool `mapstructure:"bulk_load_enabled"`
 }
 
 // ObservabilityConfig holds observability configuration
@@ -57,11 +205,134 @@ type ObservabilityConfig struct {
 	LogDevelopment       bool   `mapstructure:"log_development"`
 	LogDisableSampling   bool   `mapstructure:"log_disable_sampling"`
 	LogCorrelationHeader string `mapstructure:"log_correlation_header"`
+	LogRequestIDHeader   string `mapstructure:"log_request_id_header"`
+	// LogSuppressProbes drops access logs for successful health and metrics probes
+	LogSuppressProbes bool `mapstructure:"log_suppress_probes"`
 
 	// Metrics configuration
 	MetricsEnabled       bool   `mapstructure:"metrics_enabled"`
 	MetricsPath          string `mapstructure:"metrics_path"`
 	MetricsListenAddress string `mapstructure:"metrics_listen_address"`
+	// MetricsAuthToken, when set, is required as a bearer token to scrape metrics
+	MetricsAuthToken string `mapstructure:"metrics_auth_token"`
+	// Histogram bucket overrides in seconds, comma-separated; empty keeps the built-in buckets
+	MetricsExecutionProcessingBuckets []float64 `mapstructure:"metrics_execution_processing_buckets"`
+	MetricsBatchProcessingBuckets     []float64 `mapstructure:"metrics_batch_processing_buckets"`
+	MetricsTradeServiceLatencyBuckets []float64 `mapstructure:"metrics_trade_service_latency_buckets"`
+
+	// MetricsBackend selects where service and repository metrics are recorded:
+	// "prometheus", "otel" or "both"
+	MetricsBackend string `mapstructure:"metrics_backend"`
+}
+
+// validateMetricsListenAddress checks that a separate metrics address is a host:port
+// that doesn't collide with the API port, which would only fail at startup
+func (c *Config) validateMetricsListenAddress() error {
+	address := c.Observability.MetricsListenAddress
+	if address == "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid observability.metrics_listen_address %q: %w", address, err)
+	}
+	if port == strconv.Itoa(c.Port) {
+		return fmt.Errorf("observability.metrics_listen_address %q must not use the API port %d", address, c.Port)
+	}
+	return nil
+}
+
+// OutputModes returns the configured permissions for generated files and the output
+// directory; zero means the mode was not set
+func (c *Config) OutputModes() (fileMode, dirMode os.FileMode, err error) {
+	if fileMode, err = parseFileMode(c.OutputFileMode); err != nil {
+		return 0, 0, fmt.Errorf("invalid output_file_mode: %w", err)
+	}
+	if dirMode, err = parseFileMode(c.OutputDirMode); err != nil {
+		return 0, 0, fmt.Errorf("invalid output_dir_mode: %w", err)
+	}
+	return fileMode, dirMode, nil
+}
+
+// TrustedProxyNets parses trusted_proxies; a single IP becomes a one-address network
+func (c *Config) TrustedProxyNets() ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range c.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted_proxies entry %q: not an IP address or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted_proxies entry %q: %w", proxy, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// parseFileMode parses an octal permission such as "0640"; empty parses as zero
+func parseFileMode(value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("%q is not an octal permission between 0001 and 0777", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Built-in list page sizes, used when default_page_size and max_page_size are unset
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// PageSizeLimits returns the default and maximum list page sizes, falling back to the
+// built-in values for fields left unset, as in a Config built directly rather than by Load
+func (c *Config) PageSizeLimits() (int, int) {
+	defaultSize, maxSize := c.DefaultPageSize, c.MaxPageSize
+	if defaultSize <= 0 {
+		defaultSize = defaultPageSize
+	}
+	if maxSize <= 0 {
+		maxSize = maxPageSize
+	}
+	return defaultSize, maxSize
+}
+
+// DefaultExecutionStatuses are the execution statuses accepted when
+// allowed_execution_statuses is unset
+var DefaultExecutionStatuses = []string{
+	"NEW", "SENT", "WORK", "PART", "PARTIAL", "PARTIALLY_FILLED", "FULL", "FILLED", "CANCELLED",
+}
+
+// ExecutionStatuses returns the allowed execution statuses, falling back to
+// DefaultExecutionStatuses when none are configured
+func (c *Config) ExecutionStatuses() []string {
+	var statuses []string
+	for _, status := range c.AllowedExecutionStatuses {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return DefaultExecutionStatuses
+	}
+	return statuses
 }
 
 // Load loads configuration from environment variables
@@ -74,15 +345,244 @@ func Load() (*Config, error) {
 	// Read from environment variables
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	legacyMilliseconds(v)
+	if err := cliCommandList(v); err != nil {
+		return nil, err
+	}
 
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		durationHook(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToStringMapHook(),
+		stringToFloatSliceHook(),
+	))
+	if err := v.Unmarshal(&cfg, decodeHook); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// validate checks configuration values that would otherwise fail at first use
+func (c *Config) validate() error {
+	if _, err := time.LoadLocation(c.TradeDateTimezone); err != nil {
+		return fmt.Errorf("invalid trade_date_timezone %q: %w", c.TradeDateTimezone, err)
+	}
+
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		return fmt.Errorf("database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)",
+			c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	if c.DefaultPageSize < 1 || c.MaxPageSize < 1 {
+		return fmt.Errorf("default_page_size (%d) and max_page_size (%d) must be positive", c.DefaultPageSize, c.MaxPageSize)
+	}
+	if c.DefaultPageSize > c.MaxPageSize {
+		return fmt.Errorf("default_page_size (%d) must not exceed max_page_size (%d)", c.DefaultPageSize, c.MaxPageSize)
+	}
+
+	if c.RequestTimeout < 0 || c.SendRequestTimeout < 0 {
+		return fmt.Errorf("request_timeout_ms (%s) and send_request_timeout_ms (%s) must not be negative", c.RequestTimeout, c.SendRequestTimeout)
+	}
+
+	if err := c.validateMetricsListenAddress(); err != nil {
+		return err
+	}
+
+	if c.ReadinessWarmupDelay < 0 || c.ReadinessWarmupMinChecks < 0 {
+		return fmt.Errorf("readiness_warmup_delay_ms (%s) and readiness_warmup_min_checks (%d) must not be negative",
+			c.ReadinessWarmupDelay, c.ReadinessWarmupMinChecks)
+	}
+
+	if c.TradeDateCheckSampleSize < 0 {
+		return fmt.Errorf("trade_date_check_sample_size must not be negative, got %d", c.TradeDateCheckSampleSize)
+	}
+
+	if c.ShutdownPreStopDelay < 0 {
+		return fmt.Errorf("shutdown_pre_stop_delay_ms must not be negative, got %s", c.ShutdownPreStopDelay)
+	}
+
+	if c.GzipMinBytes < 0 {
+		return fmt.Errorf("gzip_min_bytes must not be negative, got %d", c.GzipMinBytes)
+	}
+
+	if _, err := c.TrustedProxyNets(); err != nil {
+		return err
+	}
+
+	if c.ExecutionStreamMaxSubscribers < 1 {
+		return fmt.Errorf("execution_stream_max_subscribers must be positive, got %d", c.ExecutionStreamMaxSubscribers)
+	}
+
+	if c.RetryMaxDuration < 0 {
+		return fmt.Errorf("retry_max_duration_ms must not be negative, got %s", c.RetryMaxDuration)
+	}
+
+	if c.MaxSendWindowDuration < 0 {
+		return fmt.Errorf("max_send_window_duration must not be negative, got %s", c.MaxSendWindowDuration)
+	}
+
+	if c.CLITimeoutGrace < 0 {
+		return fmt.Errorf("cli_timeout_grace_ms must not be negative, got %s", c.CLITimeoutGrace)
+	}
+
+	if c.ExecutionRetention < 0 {
+		return fmt.Errorf("execution_retention must not be negative, got %s", c.ExecutionRetention)
+	}
+	if c.ExecutionRetention > 0 && c.ArchiveBatchSize < 1 {
+		return fmt.Errorf("archive_batch_size must be positive, got %d", c.ArchiveBatchSize)
+	}
+
+	if _, _, err := c.OutputModes(); err != nil {
+		return err
+	}
+
+	if c.CLIHealthCheckEnabled {
+		if strings.TrimSpace(c.CLIHealthCheckCommand) == "" {
+			return fmt.Errorf("cli_health_check_command must be set when cli_health_check_enabled is true")
+		}
+		if c.CLIHealthCheckTimeout <= 0 {
+			return fmt.Errorf("cli_health_check_timeout_ms must be positive, got %s", c.CLIHealthCheckTimeout)
+		}
+	}
+
+	histogramBuckets := []struct {
+		key     string
+		buckets []float64
+	}{
+		{"observability.metrics_execution_processing_buckets", c.Observability.MetricsExecutionProcessingBuckets},
+		{"observability.metrics_batch_processing_buckets", c.Observability.MetricsBatchProcessingBuckets},
+		{"observability.metrics_trade_service_latency_buckets", c.Observability.MetricsTradeServiceLatencyBuckets},
+	}
+	for _, h := range histogramBuckets {
+		for i := 1; i < len(h.buckets); i++ {
+			if h.buckets[i] <= h.buckets[i-1] {
+				return fmt.Errorf("%s must be in strictly ascending order, got %v", h.key, h.buckets)
+			}
+		}
+	}
+
+	return nil
+}
+
+// legacyMilliseconds turns bare integers in the *_ms settings into durations, so those
+// settings keep accepting the millisecond values they always have. Every other duration
+// setting must carry a unit.
+func legacyMilliseconds(v *viper.Viper) {
+	for _, key := range v.AllKeys() {
+		if !strings.HasSuffix(key, "_ms") {
+			continue
+		}
+		switch value := v.Get(key).(type) {
+		case int:
+			v.Set(key, time.Duration(value)*time.Millisecond)
+		case string:
+			if ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				v.Set(key, time.Duration(ms)*time.Millisecond)
+			}
+		}
+	}
+}
+
+// cliCommandList turns a cli_command string into the pipeline it names: a JSON array of
+// commands, such as ["validate {filename}", "load {filename}"], or else one command.
+// Commas and newlines are common inside commands, so neither separates steps.
+func cliCommandList(v *viper.Viper) error {
+	value, ok := v.Get("cli_command").(string)
+	if !ok {
+		return nil
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		v.Set("cli_command", []string{value})
+		return nil
+	}
+	var commands []string
+	if err := json.Unmarshal([]byte(value), &commands); err != nil {
+		return fmt.Errorf("invalid cli_command, expected a command or a JSON array of commands: %w", err)
+	}
+	v.Set("cli_command", commands)
+	return nil
+}
+
+// durationHook decodes durations from Go duration strings such as "1s" or "500ms". A
+// bare number is rejected, since its unit would be a guess; legacyMilliseconds has
+// already converted the ones in *_ms settings.
+func durationHook() mapstructure.DecodeHookFuncType {
+	durationType := reflect.TypeOf(time.Duration(0))
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != durationType || from == durationType {
+			return data, nil
+		}
+
+		switch from.Kind() {
+		case reflect.String:
+			value := strings.TrimSpace(data.(string))
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q, expected a Go duration such as \"1s\"", value)
+			}
+			return d, nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return nil, fmt.Errorf("invalid duration %v, expected a Go duration such as \"1s\"", data)
+		default:
+			return data, nil
+		}
+	}
+}
+
+// stringToStringMapHook decodes "KEY=value,KEY2=value2" environment values into maps
+func stringToStringMapHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]string{}) {
+			return data, nil
+		}
+
+		result := map[string]string{}
+		for _, pair := range strings.Split(data.(string), ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid map entry %q, expected KEY=value", pair)
+			}
+			result[strings.TrimSpace(key)] = value
+		}
+		return result, nil
+	}
+}
+
+// stringToFloatSliceHook decodes "0.1,0.5,1" environment values into float slices
+func stringToFloatSliceHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf([]float64{}) {
+			return data, nil
+		}
+
+		result := []float64{}
+		for _, field := range strings.Split(data.(string), ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q: %w", field, err)
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	}
+}
+
 func setDefaults(v *viper.Viper) {
 	// Server defaults
 	v.SetDefault("port", 8089)
@@ -97,22 +597,123 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.user", "postgres")
 	v.SetDefault("database.password", "")
 	v.SetDefault("database.ssl_mode", "disable")
+	v.SetDefault("database.pool_wait_threshold", 0)
+	v.SetDefault("database.max_open_conns", 25)
+	v.SetDefault("database.max_idle_conns", 5)
+	v.SetDefault("database.conn_max_lifetime_ms", 300000)
+	v.SetDefault("database.conn_max_idle_time_ms", 120000)
+	v.SetDefault("database.replica_dsn", "")
+	v.SetDefault("database.query_timeout_ms", 10000)
+	v.SetDefault("database.bulk_load_enabled", false)
 
 	// External service defaults
 	v.SetDefault("trade_service_url", "http://globeco-trade-service:8082")
 	v.SetDefault("output_dir", "/data")
+	v.SetDefault("output_dir_check_enabled", true)
+	// Portfolio Accounting file format: "csv" or "jsonl"
+	v.SetDefault("output_format", "csv")
+	// CSV line ending, "lf" or "crlf", and whether CSV files start with a UTF-8 BOM
+	v.SetDefault("csv_line_ending", "lf")
+	v.SetDefault("csv_write_bom", false)
+	// Write a <filename>.sha256 sidecar next to each generated file
+	v.SetDefault("write_checksum_sidecar", false)
+	// Parse each generated CSV file before invoking the CLI
+	v.SetDefault("validate_generated_file", false)
+	// Octal permissions for generated files and a created output directory; empty keeps the umask defaults
+	v.SetDefault("output_file_mode", "")
+	v.SetDefault("output_dir_mode", "")
+	// One Portfolio Accounting file per portfolio instead of one per batch
+	v.SetDefault("split_output_by_portfolio", false)
+	// Output transaction_type codes as TRADE_TYPE=code pairs, e.g. "BUY=B,SELL=S"; empty writes trade types as-is
+	v.SetDefault("trade_type_mapping", map[string]string{})
 	// Use {home} as a placeholder for the user's home directory; replace at runtime.
+	// A JSON array of commands runs them in order as a pipeline that stops at the first failure.
 	v.SetDefault("cli_command", "docker run --rm -v {home}/docker_data:/data --network my-network kasbench/globeco-portfolio-accounting-service-cli:latest process --file /data/{filename} --output-dir /data")
 
 	// "$HOME/docker_data:/data"
+	v.SetDefault("cli_working_dir", "")
+	v.SetDefault("cli_env", map[string]string{})
+	// Time a timed-out CLI gets after SIGTERM to flush its output before SIGKILL; zero kills at once
+	v.SetDefault("cli_timeout_grace_ms", 0)
+	// Off by default: the check starts a process (or container) on every readiness probe
+	v.SetDefault("cli_health_check_enabled", false)
+	v.SetDefault("cli_health_check_command", "docker run --rm kasbench/globeco-portfolio-accounting-service-cli:latest --version")
+	v.SetDefault("cli_health_check_timeout_ms", 5000)
+
+	// List endpoint page size defaults
+	v.SetDefault("default_page_size", defaultPageSize)
+	v.SetDefault("max_page_size", maxPageSize)
+
+	// Request timeouts: the API default answers before the server's 15s write timeout;
+	// Send and batch retry allow for the CLI's own 5 minute timeout
+	v.SetDefault("request_timeout_ms", 10000)
+	v.SetDefault("send_request_timeout_ms", 600000)
+
+	// No readiness warm-up by default
+	v.SetDefault("readiness_warmup_delay_ms", 0)
+	v.SetDefault("readiness_warmup_min_checks", 0)
+
+	// Shut down immediately on SIGTERM unless a pre-stop delay is configured; it should be
+	// at least the load balancer's readiness probe interval
+	v.SetDefault("shutdown_pre_stop_delay_ms", 0)
+
+	// Below about 1KB the gzip overhead outweighs the saving
+	v.SetDefault("gzip_enabled", true)
+	v.SetDefault("gzip_min_bytes", 1024)
+	v.SetDefault("trusted_proxies", []string{})
+
+	// Each stream subscriber holds a connection and a goroutine open
+	v.SetDefault("execution_stream_max_subscribers", 10)
 
 	// Retry configuration defaults
 	v.SetDefault("retry_max_attempts", 3)
 	v.SetDefault("retry_base_delay_ms", 1000)
+	v.SetDefault("retry_jitter", false)
+	// Two full-length attempts under the HTTP client's 30 second timeout
+	v.SetDefault("retry_max_duration_ms", 60000)
+
+	v.SetDefault("trade_service_debug_logging", false)
+
+	// Portfolio cache defaults
+	v.SetDefault("portfolio_cache_size", 10000)
+	v.SetDefault("portfolio_cache_ttl_ms", 300000)
+	v.SetDefault("portfolio_cache_negative_ttl_ms", 30000)
 
 	// File management defaults
 	v.SetDefault("file_cleanup_enabled", false)
 
+	// Batch processing defaults
+	v.SetDefault("batch_concurrency", 1)
+	v.SetDefault("trade_date_timezone", "America/New_York")
+	v.SetDefault("trade_date_check_sample_size", 1000)
+	v.SetDefault("allowed_execution_statuses", DefaultExecutionStatuses)
+	v.SetDefault("execution_retention", "0s")
+	v.SetDefault("archive_schedule", "@hourly")
+	v.SetDefault("archive_batch_size", 1000)
+	// Executions stamped within this lag of a Send are left for the next batch
+	v.SetDefault("send_window_lag_ms", 1000)
+	// Zero sends the whole window; otherwise a Send takes only the oldest N executions
+	v.SetDefault("max_send_batch_size", 0)
+	// A Go duration such as "24h"; over it a Send is split ("split") or refused without force ("refuse")
+	v.SetDefault("max_send_window_duration", "0s")
+	v.SetDefault("send_window_overflow_policy", "split")
+	// Five-field cron expression for running Send in-process, e.g. "0 18 * * 1-5"; empty disables it
+	v.SetDefault("send_schedule", "")
+	// Executions outside the tolerance are excluded from the file, or abort the whole batch
+	v.SetDefault("reconciliation_enabled", false)
+	v.SetDefault("reconciliation_tolerance", 0.01)
+	v.SetDefault("reconciliation_policy", "exclude")
+	v.SetDefault("total_amount_policy", "trust")
+	v.SetDefault("portfolio_lookup_failure_policy", "error")
+	v.SetDefault("zero_quantity_filled_policy", "reject")
+	v.SetDefault("rejected_executions_enabled", false)
+	v.SetDefault("json_decimal_strings", false)
+	// A queued Send waits this long for the one in progress before giving up
+	v.SetDefault("send_queue_enabled", false)
+	v.SetDefault("send_queue_max_wait_ms", 30000)
+	v.SetDefault("send_completion_webhook_url", "")
+	v.SetDefault("send_completion_webhook_timeout_ms", 5000)
+
 	// OpenTelemetry defaults (GlobeCo standards)
 	v.SetDefault("observability.otel_enabled", true)
 	v.SetDefault("observability.otel_endpoint", "otel-collector-collector.monitoring.svc.cluster.local:4317")
@@ -132,10 +733,18 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("observability.log_development", false)
 	v.SetDefault("observability.log_disable_sampling", false)
 	v.SetDefault("observability.log_correlation_header", "X-Correlation-ID")
+	v.SetDefault("observability.log_request_id_header", "X-Request-Id")
+	v.SetDefault("observability.log_suppress_probes", true)
 
 	v.SetDefault("observability.metrics_enabled", true)
 	v.SetDefault("observability.metrics_path", "/metrics")
+	// A separate address such as ":9090" serves metrics on their own server, off the API port
 	v.SetDefault("observability.metrics_listen_address", "")
+	v.SetDefault("observability.metrics_auth_token", "")
+	v.SetDefault("observability.metrics_execution_processing_buckets", []float64{})
+	v.SetDefault("observability.metrics_batch_processing_buckets", []float64{})
+	v.SetDefault("observability.metrics_trade_service_latency_buckets", []float64{})
+	v.SetDefault("observability.metrics_backend", "prometheus")
 }
 
 // DatabaseConnectionString returns the PostgreSQL connection string
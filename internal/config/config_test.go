@@ -0,0 +1,416 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_CLIEnvFromEnvironment(t *testing.T) {
+	t.Setenv("CLI_WORKING_DIR", "/opt/portfolio-accounting")
+	t.Setenv("CLI_ENV", "PA_CONFIG=/etc/pa/config.yaml,PA_TOKEN=s3cr=t")
+
+	cfg, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/portfolio-accounting", cfg.CLIWorkingDir)
+	assert.Equal(t, map[string]string{
+		"PA_CONFIG": "/etc/pa/config.yaml",
+		"PA_TOKEN":  "s3cr=t",
+	}, cfg.CLIEnv)
+}
+
+func TestLoad_InvalidCLIEnv(t *testing.T) {
+	t.Setenv("CLI_ENV", "NOT_A_PAIR")
+
+	_, err := Load()
+
+	assert.Error(t, err)
+}
+
+func TestLoad_TradeDateTimezone(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", cfg.TradeDateTimezone)
+
+	t.Setenv("TRADE_DATE_TIMEZONE", "Mars/Olympus_Mons")
+	_, err = Load()
+	assert.ErrorContains(t, err, "invalid trade_date_timezone")
+}
+
+func TestLoad_TradeTypeMapping(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.TradeTypeMapping)
+
+	t.Setenv("TRADE_TYPE_MAPPING", "BUY=B,SELL=S")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"BUY": "B", "SELL": "S"}, cfg.TradeTypeMapping)
+}
+
+func TestLoad_DatabasePool(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 25, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+	assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, 2*time.Minute, cfg.Database.ConnMaxIdleTime)
+
+	t.Setenv("DATABASE_MAX_OPEN_CONNS", "50")
+	t.Setenv("DATABASE_MAX_IDLE_CONNS", "10")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+
+	t.Setenv("DATABASE_MAX_OPEN_CONNS", "4")
+	_, err = Load()
+	assert.ErrorContains(t, err, "database.max_idle_conns (10) must not exceed database.max_open_conns (4)")
+}
+
+func TestLoad_MetricsHistogramBuckets(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Observability.MetricsExecutionProcessingBuckets)
+	assert.Empty(t, cfg.Observability.MetricsBatchProcessingBuckets)
+	assert.Empty(t, cfg.Observability.MetricsTradeServiceLatencyBuckets)
+
+	t.Setenv("OBSERVABILITY_METRICS_EXECUTION_PROCESSING_BUCKETS", "0.005,0.01,0.05")
+	t.Setenv("OBSERVABILITY_METRICS_BATCH_PROCESSING_BUCKETS", "1,5,15")
+	t.Setenv("OBSERVABILITY_METRICS_TRADE_SERVICE_LATENCY_BUCKETS", "0.02,0.04")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.005, 0.01, 0.05}, cfg.Observability.MetricsExecutionProcessingBuckets)
+	assert.Equal(t, []float64{1, 5, 15}, cfg.Observability.MetricsBatchProcessingBuckets)
+	assert.Equal(t, []float64{0.02, 0.04}, cfg.Observability.MetricsTradeServiceLatencyBuckets)
+
+	t.Setenv("OBSERVABILITY_METRICS_BATCH_PROCESSING_BUCKETS", "1,15,5")
+	_, err = Load()
+	assert.ErrorContains(t, err, "observability.metrics_batch_processing_buckets must be in strictly ascending order")
+
+	t.Setenv("OBSERVABILITY_METRICS_BATCH_PROCESSING_BUCKETS", "1,1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "strictly ascending")
+}
+
+func TestLoad_CLICommand(t *testing.T) {
+	// A single command, commas and all, is a one-step pipeline
+	t.Setenv("CLI_COMMAND", `docker run --mount type=bind,src=/data,dst=/data cli process --file /data/{filename}`)
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker run --mount type=bind,src=/data,dst=/data cli process --file /data/{filename}"}, cfg.CLICommand)
+
+	t.Setenv("CLI_COMMAND", `["cli validate {filename}", "cli load {filename}"]`)
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cli validate {filename}", "cli load {filename}"}, cfg.CLICommand)
+
+	t.Setenv("CLI_COMMAND", `["cli validate {filename}",`)
+	_, err = Load()
+	assert.ErrorContains(t, err, "invalid cli_command")
+}
+
+func TestLoad_CLIHealthCheck(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.CLIHealthCheckEnabled)
+	assert.Contains(t, cfg.CLIHealthCheckCommand, "--version")
+	assert.Equal(t, 5*time.Second, cfg.CLIHealthCheckTimeout)
+
+	t.Setenv("CLI_HEALTH_CHECK_ENABLED", "true")
+	t.Setenv("CLI_HEALTH_CHECK_COMMAND", "globeco-portfolio-cli --help")
+	t.Setenv("CLI_HEALTH_CHECK_TIMEOUT_MS", "2000")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.CLIHealthCheckEnabled)
+	assert.Equal(t, "globeco-portfolio-cli --help", cfg.CLIHealthCheckCommand)
+	assert.Equal(t, 2*time.Second, cfg.CLIHealthCheckTimeout)
+
+	t.Setenv("CLI_HEALTH_CHECK_TIMEOUT_MS", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "cli_health_check_timeout_ms must be positive")
+}
+
+func TestLoad_CLITimeoutGrace(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.CLITimeoutGrace)
+
+	t.Setenv("CLI_TIMEOUT_GRACE_MS", "3000")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.CLITimeoutGrace)
+
+	t.Setenv("CLI_TIMEOUT_GRACE_MS", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "cli_timeout_grace_ms must not be negative")
+}
+
+func TestLoad_RequestTimeouts(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.RequestTimeout)
+	assert.Equal(t, 10*time.Minute, cfg.SendRequestTimeout)
+
+	t.Setenv("REQUEST_TIMEOUT_MS", "0")
+	t.Setenv("SEND_REQUEST_TIMEOUT_MS", "900000")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.RequestTimeout)
+	assert.Equal(t, 15*time.Minute, cfg.SendRequestTimeout)
+
+	t.Setenv("SEND_REQUEST_TIMEOUT_MS", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestLoad_MetricsListenAddress(t *testing.T) {
+	t.Setenv("OBSERVABILITY_METRICS_LISTEN_ADDRESS", ":9090")
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Observability.MetricsListenAddress)
+
+	t.Setenv("OBSERVABILITY_METRICS_LISTEN_ADDRESS", "9090")
+	_, err = Load()
+	assert.ErrorContains(t, err, "invalid observability.metrics_listen_address")
+
+	t.Setenv("OBSERVABILITY_METRICS_LISTEN_ADDRESS", "0.0.0.0:8089")
+	_, err = Load()
+	assert.ErrorContains(t, err, "must not use the API port 8089")
+}
+
+func TestLoad_ReadinessWarmup(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ReadinessWarmupDelay)
+	assert.Equal(t, 0, cfg.ReadinessWarmupMinChecks)
+
+	t.Setenv("READINESS_WARMUP_DELAY_MS", "5000")
+	t.Setenv("READINESS_WARMUP_MIN_CHECKS", "3")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.ReadinessWarmupDelay)
+	assert.Equal(t, 3, cfg.ReadinessWarmupMinChecks)
+
+	t.Setenv("READINESS_WARMUP_MIN_CHECKS", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestLoad_TradeDateCheckSampleSize(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 1000, cfg.TradeDateCheckSampleSize)
+
+	t.Setenv("TRADE_DATE_CHECK_SAMPLE_SIZE", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "trade_date_check_sample_size must not be negative")
+}
+
+func TestLoad_ShutdownPreStopDelay(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ShutdownPreStopDelay)
+
+	t.Setenv("SHUTDOWN_PRE_STOP_DELAY_MS", "-5")
+	_, err = Load()
+	assert.ErrorContains(t, err, "shutdown_pre_stop_delay_ms must not be negative")
+}
+
+func TestLoad_AllowedExecutionStatuses(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultExecutionStatuses, cfg.ExecutionStatuses())
+
+	t.Setenv("ALLOWED_EXECUTION_STATUSES", "FILLED, DONE")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FILLED", "DONE"}, cfg.ExecutionStatuses())
+
+	assert.Equal(t, DefaultExecutionStatuses, (&Config{}).ExecutionStatuses())
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	nets, err := cfg.TrustedProxyNets()
+	require.NoError(t, err)
+	assert.Empty(t, nets)
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5")
+	cfg, err = Load()
+	require.NoError(t, err)
+	nets, err = cfg.TrustedProxyNets()
+	require.NoError(t, err)
+	require.Len(t, nets, 2)
+	assert.Equal(t, "10.0.0.0/8", nets[0].String())
+	assert.Equal(t, "192.168.1.5/32", nets[1].String())
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+	_, err = Load()
+	assert.ErrorContains(t, err, `invalid trusted_proxies entry "10.0.0.0/33"`)
+}
+
+func TestLoad_Durations(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.RetryBaseDelay)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"250", 250 * time.Millisecond}, // bare integers stay milliseconds
+		{"0", 0},
+		{"500ms", 500 * time.Millisecond},
+		{"1.5s", 1500 * time.Millisecond},
+		{" 2m ", 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("RETRY_BASE_DELAY_MS", tt.value)
+			t.Setenv("SEND_QUEUE_MAX_WAIT_MS", tt.value)
+
+			cfg, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg.RetryBaseDelay)
+			assert.Equal(t, tt.expected, cfg.SendQueueMaxWait)
+		})
+	}
+
+	t.Setenv("RETRY_BASE_DELAY_MS", "1 second")
+	_, err = Load()
+	assert.ErrorContains(t, err, `invalid duration "1 second"`)
+}
+
+func TestLoad_DurationsNeedUnitsOutsideMsSettings(t *testing.T) {
+	// Only the legacy *_ms settings read a bare number as milliseconds
+	t.Setenv("MAX_SEND_WINDOW_DURATION", "3600")
+	_, err := Load()
+	assert.ErrorContains(t, err, `invalid duration "3600", expected a Go duration such as "1s"`)
+
+	t.Setenv("MAX_SEND_WINDOW_DURATION", "1h")
+	t.Setenv("EXECUTION_RETENTION", "90")
+	_, err = Load()
+	assert.ErrorContains(t, err, `invalid duration "90"`)
+}
+
+func TestLoad_RetryMaxDuration(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, cfg.RetryMaxDuration)
+
+	t.Setenv("RETRY_MAX_DURATION_MS", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "retry_max_duration_ms must not be negative")
+}
+
+func TestLoad_MaxSendWindowDuration(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.MaxSendWindowDuration, "the window limit is disabled by default")
+	assert.Equal(t, "split", cfg.SendWindowOverflowPolicy)
+
+	t.Setenv("MAX_SEND_WINDOW_DURATION", "36h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, cfg.MaxSendWindowDuration)
+
+	t.Setenv("MAX_SEND_WINDOW_DURATION", "-1h")
+	_, err = Load()
+	assert.ErrorContains(t, err, "max_send_window_duration must not be negative")
+}
+
+func TestLoad_ExecutionArchival(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ExecutionRetention, "archival is disabled by default")
+	assert.Equal(t, "@hourly", cfg.ArchiveSchedule)
+	assert.Equal(t, 1000, cfg.ArchiveBatchSize)
+
+	t.Setenv("EXECUTION_RETENTION", "-24h")
+	_, err = Load()
+	assert.ErrorContains(t, err, "execution_retention must not be negative")
+
+	t.Setenv("EXECUTION_RETENTION", "2160h")
+	t.Setenv("ARCHIVE_BATCH_SIZE", "500")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 90*24*time.Hour, cfg.ExecutionRetention)
+
+	t.Setenv("ARCHIVE_BATCH_SIZE", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "archive_batch_size must be positive")
+}
+
+func TestLoad_OutputModes(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	fileMode, dirMode, err := cfg.OutputModes()
+	require.NoError(t, err)
+	assert.Zero(t, fileMode, "unset by default")
+	assert.Zero(t, dirMode, "unset by default")
+
+	t.Setenv("OUTPUT_FILE_MODE", "0640")
+	t.Setenv("OUTPUT_DIR_MODE", "750")
+	cfg, err = Load()
+	require.NoError(t, err)
+	fileMode, dirMode, err = cfg.OutputModes()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), fileMode)
+	assert.Equal(t, os.FileMode(0750), dirMode)
+
+	for _, invalid := range []string{"0980", "rw-r-----", "01777", "0"} {
+		t.Setenv("OUTPUT_FILE_MODE", invalid)
+		_, err = Load()
+		assert.ErrorContains(t, err, "invalid output_file_mode", invalid)
+	}
+}
+
+func TestLoad_Gzip(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.GzipEnabled)
+	assert.Equal(t, 1024, cfg.GzipMinBytes)
+
+	t.Setenv("GZIP_MIN_BYTES", "-1")
+	_, err = Load()
+	assert.ErrorContains(t, err, "gzip_min_bytes must not be negative")
+}
+
+func TestLoad_ExecutionStreamMaxSubscribers(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.ExecutionStreamMaxSubscribers)
+
+	t.Setenv("EXECUTION_STREAM_MAX_SUBSCRIBERS", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "execution_stream_max_subscribers must be positive")
+}
+
+func TestLoad_PageSizes(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 50, cfg.DefaultPageSize)
+	assert.Equal(t, 1000, cfg.MaxPageSize)
+
+	t.Setenv("DEFAULT_PAGE_SIZE", "25")
+	t.Setenv("MAX_PAGE_SIZE", "500")
+	cfg, err = Load()
+	require.NoError(t, err)
+	defaultSize, maxSize := cfg.PageSizeLimits()
+	assert.Equal(t, 25, defaultSize)
+	assert.Equal(t, 500, maxSize)
+
+	t.Setenv("DEFAULT_PAGE_SIZE", "600")
+	_, err = Load()
+	assert.ErrorContains(t, err, "default_page_size (600) must not exceed max_page_size (500)")
+
+	t.Setenv("DEFAULT_PAGE_SIZE", "0")
+	_, err = Load()
+	assert.ErrorContains(t, err, "must be positive")
+}
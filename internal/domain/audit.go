@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Audit actions
+const (
+	AuditActionSend       = "send"
+	AuditActionBatchRetry = "batch_retry"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeError   = "error"
+)
+
+// AuditLog is an immutable record of a Send or batch retry invocation
+type AuditLog struct {
+	ID             int       `json:"id" db:"id"`
+	Action         string    `json:"action" db:"action"`
+	BatchID        *int      `json:"batchId,omitempty" db:"batch_id"`
+	CorrelationID  *string   `json:"correlationId,omitempty" db:"correlation_id"`
+	Actor          *string   `json:"actor,omitempty" db:"actor"` // API key identity, once authentication exists
+	ProcessedCount int       `json:"processedCount" db:"processed_count"`
+	FileName       *string   `json:"fileName,omitempty" db:"file_name"`
+	Outcome        string    `json:"outcome" db:"outcome"`
+	Message        *string   `json:"message,omitempty" db:"message"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// AuditLogListResponse represents the paginated response for listing audit logs
+type AuditLogListResponse struct {
+	AuditLogs  []AuditLog     `json:"auditLogs"`
+	Pagination PaginationInfo `json:"pagination"`
+}
@@ -0,0 +1,311 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decimalPattern matches the decimal strings accepted for quantities, prices and
+// amounts: at most 10 integer and 8 fractional digits, like the DECIMAL(18,8) columns
+var decimalPattern = regexp.MustCompile(`^-?\d{1,10}(\.\d{1,8})?$`)
+
+// decimalScale is the number of fractional digits kept, the scale of the DECIMAL(18,8) columns
+const decimalScale = 8
+
+// Decimal is an exact quantity, price or amount. It holds the canonical decimal string
+// rather than a float, so a value passes from the request to the DECIMAL(18,8) columns
+// and back out unchanged. The zero value is 0; it encodes as a plain JSON number.
+type Decimal struct {
+	value string
+}
+
+// ParseDecimal parses a decimal string such as "1234.5678". It rejects values that do
+// not fit DECIMAL(18,8) rather than silently storing a different number.
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: expected up to 10 integer and 8 fractional digits", s)
+	}
+	return Decimal{value: canonicalDecimal(s)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a valid decimal
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat returns f rounded to 8 fractional digits
+func DecimalFromFloat(f float64) Decimal {
+	return Decimal{value: canonicalDecimal(strconv.FormatFloat(f, 'f', decimalScale, 64))}
+}
+
+// decimalFromRat returns r rounded half away from zero to 8 fractional digits, as
+// PostgreSQL rounds on insert into a DECIMAL(18,8) column
+func decimalFromRat(r *big.Rat) Decimal {
+	return Decimal{value: canonicalDecimal(r.FloatString(decimalScale))}
+}
+
+// parseDecimalText parses any plain decimal or exponent notation, rounding to 8
+// fractional digits. It is used for JSON numbers and database values, which are not
+// held to the strict ParseDecimal format.
+func parseDecimalText(s string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return decimalFromRat(r), nil
+}
+
+// canonicalDecimal strips redundant zeros and signs from a plain decimal string
+func canonicalDecimal(s string) string {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" || strings.HasPrefix(s, ".") {
+		s = "0" + s
+	}
+	if negative && s != "0" {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns d in plain decimal notation with no redundant zeros, such as "150.25"
+func (d Decimal) String() string {
+	if d.value == "" {
+		return "0"
+	}
+	return d.value
+}
+
+// StringFixed returns d with exactly places fractional digits, rounding half away from zero
+func (d Decimal) StringFixed(places int) string {
+	return d.rat().FloatString(places)
+}
+
+// AppendFixed appends d with exactly places fractional digits to buf, as StringFixed
+// returns it, without allocating when no rounding is needed
+func (d Decimal) AppendFixed(buf []byte, places int) []byte {
+	s := d.String()
+	fraction := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		fraction = len(s) - i - 1
+	}
+	if fraction > places {
+		return append(buf, d.StringFixed(places)...)
+	}
+
+	buf = append(buf, s...)
+	if fraction == 0 && places > 0 {
+		buf = append(buf, '.')
+	}
+	for ; fraction < places; fraction++ {
+		buf = append(buf, '0')
+	}
+	return buf
+}
+
+// Float64 returns the nearest float64 to d, for metrics and tolerance comparisons
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// IsZero reports whether d is zero
+func (d Decimal) IsZero() bool {
+	return d.String() == "0"
+}
+
+// Sign returns -1, 0 or +1 as d is negative, zero or positive
+func (d Decimal) Sign() int {
+	switch {
+	case d.IsZero():
+		return 0
+	case strings.HasPrefix(d.value, "-"):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Cmp compares d and other, returning -1, 0 or +1
+func (d Decimal) Cmp(other Decimal) int {
+	return d.rat().Cmp(other.rat())
+}
+
+// Mul returns d * other, rounded to 8 fractional digits
+func (d Decimal) Mul(other Decimal) Decimal {
+	return decimalFromRat(new(big.Rat).Mul(d.rat(), other.rat()))
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	return decimalFromRat(new(big.Rat).Sub(d.rat(), other.rat()))
+}
+
+// rat returns d as an exact rational
+func (d Decimal) rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// MarshalJSON encodes d as a JSON number in plain decimal notation
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or a decimal string. Strings are checked
+// strictly with ParseDecimal; numbers are rounded to 8 fractional digits.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseDecimal(s)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	parsed, err := parseDecimalText(n.String())
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		return d.scanText(string(v))
+	case string:
+		return d.scanText(v)
+	case float64:
+		*d = DecimalFromFloat(v)
+		return nil
+	case int64:
+		*d = Decimal{value: strconv.FormatInt(v, 10)}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+}
+
+func (d *Decimal) scanText(s string) error {
+	parsed, err := parseDecimalText(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, passing d to the database as its decimal string
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// DecimalValidationValue lets validator tags such as gt=0 compare a Decimal field.
+// Register it with validator.RegisterCustomTypeFunc for Decimal{}.
+func DecimalValidationValue(field reflect.Value) interface{} {
+	if d, ok := field.Interface().(Decimal); ok {
+		return d.Float64()
+	}
+	return nil
+}
+
+// decimalString is a Decimal that encodes as a JSON string, such as "15075000.25"
+type decimalString Decimal
+
+// MarshalJSON implements json.Marshaler
+func (d decimalString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Decimal(d).String())
+}
+
+// DecimalStringExecutionDTO is an ExecutionDTO that encodes its quantities, prices and
+// amounts as decimal strings instead of JSON numbers
+type DecimalStringExecutionDTO ExecutionDTO
+
+// MarshalJSON implements json.Marshaler
+func (d DecimalStringExecutionDTO) MarshalJSON() ([]byte, error) {
+	type plain ExecutionDTO
+	return json.Marshal(struct {
+		plain
+		Quantity       decimalString  `json:"quantity"`
+		LimitPrice     *decimalString `json:"limitPrice"`
+		QuantityFilled decimalString  `json:"quantityFilled"`
+		TotalAmount    decimalString  `json:"totalAmount"`
+		AveragePrice   decimalString  `json:"averagePrice"`
+	}{
+		plain:          plain(d),
+		Quantity:       decimalString(d.Quantity),
+		LimitPrice:     (*decimalString)(d.LimitPrice),
+		QuantityFilled: decimalString(d.QuantityFilled),
+		TotalAmount:    decimalString(d.TotalAmount),
+		AveragePrice:   decimalString(d.AveragePrice),
+	})
+}
+
+// DecimalStringExecutionListResponse is an ExecutionListResponse whose executions
+// encode their quantities, prices and amounts as decimal strings
+type DecimalStringExecutionListResponse struct {
+	Executions []DecimalStringExecutionDTO `json:"executions"`
+	Pagination PaginationInfo              `json:"pagination"`
+}
+
+// DecimalStringBatchDetailResponse is a BatchDetailResponse whose executions encode
+// their quantities, prices and amounts as decimal strings
+type DecimalStringBatchDetailResponse struct {
+	Batch      BatchHistory                        `json:"batch"`
+	Executions *DecimalStringExecutionListResponse `json:"executions,omitempty"`
+}
+
+// WithDecimalStrings returns the response with quantities, prices and amounts encoded as decimal strings
+func (r *BatchDetailResponse) WithDecimalStrings() *DecimalStringBatchDetailResponse {
+	response := &DecimalStringBatchDetailResponse{Batch: r.Batch}
+	if r.Executions != nil {
+		response.Executions = r.Executions.WithDecimalStrings()
+	}
+	return response
+}
+
+// WithDecimalStrings returns the response with quantities, prices and amounts encoded as decimal strings
+func (r *ExecutionListResponse) WithDecimalStrings() *DecimalStringExecutionListResponse {
+	executions := make([]DecimalStringExecutionDTO, len(r.Executions))
+	for i, execution := range r.Executions {
+		executions[i] = DecimalStringExecutionDTO(execution)
+	}
+	return &DecimalStringExecutionListResponse{
+		Executions: executions,
+		Pagination: r.Pagination,
+	}
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "15075000.25", want: "15075000.25"},
+		{input: "15075000.12345678", want: "15075000.12345678"},
+		{input: "1234567890.12345678", want: "1234567890.12345678"},
+		{input: "0.10000000", want: "0.1"},
+		{input: "007", want: "7"},
+		{input: "-0", want: "0"},
+		{input: "-12.5", want: "-12.5"},
+		{input: "12345678901", wantErr: true},
+		{input: "1.123456789", wantErr: true},
+		{input: "1e5", wantErr: true},
+		{input: ".5", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	assert.Equal(t, "0.2", MustParseDecimal("0.3").Sub(MustParseDecimal("0.1")).String())
+	assert.Equal(t, "15025", MustParseDecimal("100").Mul(MustParseDecimal("150.25")).String())
+	// Products round half away from zero at the eighth place, as PostgreSQL does on insert
+	assert.Equal(t, "0.00000001", MustParseDecimal("0.00000001").Mul(MustParseDecimal("0.5")).String())
+	assert.Equal(t, "-0.00000001", MustParseDecimal("-0.00000001").Mul(MustParseDecimal("0.5")).String())
+	assert.Equal(t, "150.25000000", MustParseDecimal("150.25").StringFixed(8))
+	assert.Equal(t, "x7.00000000", string(MustParseDecimal("7").AppendFixed([]byte("x"), 8)))
+	assert.Equal(t, "0.13", string(MustParseDecimal("0.125").AppendFixed(nil, 2)))
+	assert.Equal(t, "-1.5", string(MustParseDecimal("-1.5").AppendFixed(nil, 1)))
+	assert.True(t, Decimal{}.IsZero())
+	assert.Equal(t, -1, MustParseDecimal("-1").Sign())
+	assert.Equal(t, 1, MustParseDecimal("2").Cmp(MustParseDecimal("1.99999999")))
+}
+
+// TestDecimal_RoundTrip passes values using every digit of DECIMAL(18,8) through a
+// request, the database and a response; a float64 holds only about 16 of those 18 digits
+func TestDecimal_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "decimal string", body: `{"totalAmount": "1234567890.12345678"}`, want: "1234567890.12345678"},
+		{name: "number", body: `{"totalAmount": 1234567890.12345678}`, want: "1234567890.12345678"},
+		{name: "largest negative", body: `{"totalAmount": "-9999999999.99999999"}`, want: "-9999999999.99999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dto ExecutionPostDTO
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &dto))
+
+			value, err := dto.TotalAmount.Value()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+
+			// PostgreSQL returns NUMERIC columns padded to the column scale
+			var scanned Decimal
+			require.NoError(t, scanned.Scan([]byte(dto.TotalAmount.StringFixed(8))))
+			assert.Equal(t, dto.TotalAmount, scanned)
+
+			data, err := json.Marshal(ExecutionDTO{TotalAmount: scanned})
+			require.NoError(t, err)
+			var fields map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(data, &fields))
+			assert.Equal(t, tt.want, string(fields["totalAmount"]))
+
+			data, err = json.Marshal(DecimalStringExecutionDTO{TotalAmount: scanned})
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(data, &fields))
+			assert.Equal(t, `"`+tt.want+`"`, string(fields["totalAmount"]))
+		})
+	}
+}
+
+func TestDecimal_Scan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{src: []byte("15075000.12345678"), want: "15075000.12345678"},
+		{src: "100.50000000", want: "100.5"},
+		{src: 149.25, want: "149.25"},
+		{src: int64(7), want: "7"},
+		{src: nil, want: "0"},
+	}
+
+	for _, tt := range tests {
+		d := MustParseDecimal("1")
+		require.NoError(t, d.Scan(tt.src))
+		assert.Equal(t, tt.want, d.String())
+	}
+
+	var d Decimal
+	assert.Error(t, d.Scan(true))
+	assert.Error(t, d.Scan("not a number"))
+}
+
+func TestExecutionPostDTO_UnmarshalJSON(t *testing.T) {
+	t.Run("decimal strings", func(t *testing.T) {
+		var dto ExecutionPostDTO
+		err := json.Unmarshal([]byte(`{
+			"executionServiceId": 1,
+			"ticker": "AAPL",
+			"quantity": "15075000.12345678",
+			"limitPrice": "150.25",
+			"quantityFilled": "15075000",
+			"totalAmount": "2265043125.5",
+			"averagePrice": "150.25"
+		}`), &dto)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, dto.ExecutionServiceID)
+		assert.Equal(t, "AAPL", dto.Ticker)
+		assert.Equal(t, "15075000.12345678", dto.Quantity.String())
+		require.NotNil(t, dto.LimitPrice)
+		assert.Equal(t, "150.25", dto.LimitPrice.String())
+		assert.Equal(t, "15075000", dto.QuantityFilled.String())
+		assert.Equal(t, "2265043125.5", dto.TotalAmount.String())
+		assert.Equal(t, "150.25", dto.AveragePrice.String())
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		var dto ExecutionPostDTO
+		err := json.Unmarshal([]byte(`{"quantity": 100, "limitPrice": null, "averagePrice": 1.5e2, "totalAmount": 0.123456789}`), &dto)
+		require.NoError(t, err)
+
+		assert.Equal(t, "100", dto.Quantity.String())
+		assert.Nil(t, dto.LimitPrice)
+		assert.Equal(t, "150", dto.AveragePrice.String())
+		assert.Equal(t, "0.12345679", dto.TotalAmount.String())
+	})
+
+	t.Run("decimal string out of range", func(t *testing.T) {
+		var dto ExecutionPostDTO
+		err := json.Unmarshal([]byte(`{"totalAmount": "1.123456789"}`), &dto)
+		assert.ErrorContains(t, err, "expected up to 10 integer and 8 fractional digits")
+	})
+}
+
+func TestDecimalStringExecutionDTO_MarshalJSON(t *testing.T) {
+	limitPrice := DecimalFromFloat(150.25)
+	dto := ExecutionDTO{
+		ID:             1,
+		Ticker:         "AAPL",
+		Quantity:       DecimalFromFloat(15075000.12345678),
+		LimitPrice:     &limitPrice,
+		QuantityFilled: DecimalFromFloat(1e9),
+		TotalAmount:    DecimalFromFloat(2265043125.5),
+		AveragePrice:   DecimalFromFloat(150.25),
+	}
+
+	data, err := json.Marshal(DecimalStringExecutionDTO(dto))
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "AAPL", fields["ticker"])
+	assert.Equal(t, "15075000.12345678", fields["quantity"])
+	assert.Equal(t, "150.25", fields["limitPrice"])
+	assert.Equal(t, "1000000000", fields["quantityFilled"])
+	assert.Equal(t, "2265043125.5", fields["totalAmount"])
+	assert.Equal(t, "150.25", fields["averagePrice"])
+
+	list := (&ExecutionListResponse{Executions: []ExecutionDTO{dto}}).WithDecimalStrings()
+	require.Len(t, list.Executions, 1)
+	assert.Equal(t, DecimalStringExecutionDTO(dto), list.Executions[0])
+}
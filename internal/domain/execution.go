@@ -2,6 +2,8 @@ package domain
 
 import (
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // Execution represents a trade execution record
@@ -16,24 +18,77 @@ type Execution struct {
 	SecurityID           string     `json:"securityId" db:"security_id"`
 	Ticker               string     `json:"ticker" db:"ticker"`
 	PortfolioID          *string    `json:"portfolioId" db:"portfolio_id"`
-	Quantity             float64    `json:"quantity" db:"quantity"`
-	LimitPrice           *float64   `json:"limitPrice" db:"limit_price"`
+	Quantity             Decimal    `json:"quantity" db:"quantity"`
+	LimitPrice           *Decimal   `json:"limitPrice" db:"limit_price"`
 	ReceivedTimestamp    time.Time  `json:"receivedTimestamp" db:"received_timestamp"`
 	SentTimestamp        time.Time  `json:"sentTimestamp" db:"sent_timestamp"`
 	LastFillTimestamp    *time.Time `json:"lastFillTimestamp" db:"last_fill_timestamp"`
-	QuantityFilled       float64    `json:"quantityFilled" db:"quantity_filled"`
-	TotalAmount          float64    `json:"totalAmount" db:"total_amount"`
-	AveragePrice         float64    `json:"averagePrice" db:"average_price"`
+	QuantityFilled       Decimal    `json:"quantityFilled" db:"quantity_filled"`
+	TotalAmount          Decimal    `json:"totalAmount" db:"total_amount"`
+	AveragePrice         Decimal    `json:"averagePrice" db:"average_price"`
 	ReadyToSendTimestamp time.Time  `json:"readyToSendTimestamp" db:"ready_to_send_timestamp"`
 	Version              int        `json:"version" db:"version"`
+	DeletedAt            *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	CreateTraceID        *string    `json:"-" db:"create_trace_id"`
+	CreateSpanID         *string    `json:"-" db:"create_span_id"`
+}
+
+// ExecutionFilter narrows execution queries. The zero value matches all live
+// (non-deleted) executions.
+type ExecutionFilter struct {
+	IncludeDeleted bool
+	TradeDateFrom  *time.Time // inclusive
+	TradeDateTo    *time.Time // inclusive
+	TickerPrefix   string     // case-insensitive ticker prefix; empty matches all
+	Destination    string     // exact destination; empty matches all
+}
+
+// HasCriteria reports whether the filter narrows by anything other than deleted status
+func (f ExecutionFilter) HasCriteria() bool {
+	return f.TradeDateFrom != nil || f.TradeDateTo != nil || f.TickerPrefix != "" || f.Destination != ""
 }
 
+// MaxTickerPrefixLength is the longest ticker prefix accepted, matching the ticker column
+const MaxTickerPrefixLength = 20
+
+// Fields the executions list can be sorted by, as named in the API
+const (
+	SortByID                = "id"
+	SortByTradeDate         = "tradeDate"
+	SortByReceivedTimestamp = "receivedTimestamp"
+	SortByQuantity          = "quantity"
+)
+
+// ExecutionSortFields lists the accepted sortBy values
+var ExecutionSortFields = []string{SortByID, SortByTradeDate, SortByReceivedTimestamp, SortByQuantity}
+
+// ExecutionSort orders execution lists. The zero value sorts by id, newest first.
+type ExecutionSort struct {
+	Field     string // one of ExecutionSortFields; empty means SortByID
+	Ascending bool
+}
+
+// Batch statuses recorded on batch_history
+const (
+	BatchStatusInProgress = "in_progress"
+	BatchStatusCompleted  = "completed"
+	BatchStatusFailed     = "failed"
+)
+
 // BatchHistory represents a batch processing history record
 type BatchHistory struct {
 	ID                int       `json:"id" db:"id"`
 	StartTime         time.Time `json:"startTime" db:"start_time"`
 	PreviousStartTime time.Time `json:"previousStartTime" db:"previous_start_time"`
+	Status            string    `json:"status" db:"status"`
 	Version           int       `json:"version" db:"version"`
+	BatchKey          *string   `json:"batchKey,omitempty" db:"batch_key"`
+	// SendResponse is the JSON SendResponse of a completed keyed batch, replayed when
+	// a Send repeats its key
+	SendResponse []byte `json:"-" db:"send_response"`
+	// LoadedPortfolios are the portfolios of a batch split by portfolio whose file the
+	// CLI has loaded; a retry of the batch leaves them out
+	LoadedPortfolios pq.StringArray `json:"-" db:"loaded_portfolios"`
 }
 
 // ExecutionDTO represents the response DTO for execution
@@ -47,15 +102,16 @@ type ExecutionDTO struct {
 	SecurityID         string     `json:"securityId"`
 	PortfolioID        *string    `json:"portfolioId"`
 	Ticker             string     `json:"ticker"`
-	Quantity           float64    `json:"quantity"`
-	LimitPrice         *float64   `json:"limitPrice"`
+	Quantity           Decimal    `json:"quantity"`
+	LimitPrice         *Decimal   `json:"limitPrice"`
 	ReceivedTimestamp  time.Time  `json:"receivedTimestamp"`
 	SentTimestamp      time.Time  `json:"sentTimestamp"`
 	LastFillTimestamp  *time.Time `json:"lastFillTimestamp"`
-	QuantityFilled     float64    `json:"quantityFilled"`
-	TotalAmount        float64    `json:"totalAmount"`
-	AveragePrice       float64    `json:"averagePrice"`
+	QuantityFilled     Decimal    `json:"quantityFilled"`
+	TotalAmount        Decimal    `json:"totalAmount"`
+	AveragePrice       Decimal    `json:"averagePrice"`
 	Version            int        `json:"version"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty"`
 }
 
 // ExecutionPostDTO represents the request DTO for creating executions
@@ -67,14 +123,17 @@ type ExecutionPostDTO struct {
 	Destination        string     `json:"destination" validate:"required"`
 	SecurityID         string     `json:"securityId" validate:"required"`
 	Ticker             string     `json:"ticker" validate:"required"`
-	Quantity           float64    `json:"quantity" validate:"required,gt=0"`
-	LimitPrice         *float64   `json:"limitPrice"`
+	Quantity           Decimal    `json:"quantity" validate:"required,gt=0"`
+	LimitPrice         *Decimal   `json:"limitPrice"`
 	ReceivedTimestamp  time.Time  `json:"receivedTimestamp" validate:"required"`
 	SentTimestamp      time.Time  `json:"sentTimestamp" validate:"required"`
 	LastFillTimestamp  *time.Time `json:"lastFillTimestamp"`
-	QuantityFilled     float64    `json:"quantityFilled" validate:"gte=0"`
-	TotalAmount        float64    `json:"totalAmount" validate:"gte=0"`
-	AveragePrice       float64    `json:"averagePrice" validate:"gt=0"`
+	QuantityFilled     Decimal    `json:"quantityFilled" validate:"gte=0"`
+	TotalAmount        Decimal    `json:"totalAmount" validate:"gte=0"`
+	AveragePrice       Decimal    `json:"averagePrice" validate:"gt=0"`
+	// PortfolioID, when supplied by a caller that already knows it, skips the Trade Service
+	// lookup. An empty value is treated as absent; anything else must be 24 alphanumerics.
+	PortfolioID *string `json:"portfolioId,omitempty" validate:"omitempty,len=0|len=24,len=0|alphanum"`
 }
 
 // ToDTO converts an Execution domain model to ExecutionDTO
@@ -98,16 +157,30 @@ func (e *Execution) ToDTO() ExecutionDTO {
 		TotalAmount:        e.TotalAmount,
 		AveragePrice:       e.AveragePrice,
 		Version:            e.Version,
+		DeletedAt:          e.DeletedAt,
 	}
 }
 
-// ToExecution converts an ExecutionPostDTO to Execution domain model
-func (dto *ExecutionPostDTO) ToExecution() Execution {
+// ComputeTotalAmount returns the total amount of a full fill, quantity * average price
+func (e *Execution) ComputeTotalAmount() Decimal {
+	return e.Quantity.Mul(e.AveragePrice)
+}
+
+// CalculateTradeDate returns the trade date for an execution sent at sentTimestamp,
+// evaluated in the given trading location. The result is local midnight of the
+// calendar date in loc; time.Truncate is deliberately avoided because it rounds on
+// absolute time since the zero instant (effectively UTC), not local midnight.
+func CalculateTradeDate(sentTimestamp time.Time, loc *time.Location) time.Time {
+	year, month, day := sentTimestamp.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// ToExecution converts an ExecutionPostDTO to Execution domain model, computing the
+// trade date in the given trading location
+func (dto *ExecutionPostDTO) ToExecution(loc *time.Location) Execution {
 	now := time.Now()
 
-	// Calculate trade date in US Eastern Time
-	loc, _ := time.LoadLocation("America/New_York")
-	tradeDate := dto.SentTimestamp.In(loc).Truncate(24 * time.Hour)
+	tradeDate := CalculateTradeDate(dto.SentTimestamp, loc)
 
 	return Execution{
 		ExecutionServiceID:   dto.ExecutionServiceID,
@@ -118,7 +191,7 @@ func (dto *ExecutionPostDTO) ToExecution() Execution {
 		TradeDate:            tradeDate,
 		SecurityID:           dto.SecurityID,
 		Ticker:               dto.Ticker,
-		PortfolioID:          nil, // Will be set by business logic
+		PortfolioID:          dto.PortfolioID, // Looked up by business logic when not supplied
 		Quantity:             dto.Quantity,
 		LimitPrice:           dto.LimitPrice,
 		ReceivedTimestamp:    dto.ReceivedTimestamp,
@@ -6,10 +6,15 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecutionPostDTO_Validation(t *testing.T) {
 	validator := validator.New()
+	validator.RegisterCustomTypeFunc(DecimalValidationValue, Decimal{})
+	portfolioID := "PORTFOLIO123456789012345"
+	emptyPortfolioID := ""
+	malformedPortfolioID := "PORTFOLIO-123456789-0123"
 
 	tests := []struct {
 		name    string
@@ -27,13 +32,13 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:        "NYSE",
 				SecurityID:         "12345678901234567890ABCD",
 				Ticker:             "AAPL",
-				Quantity:           100.5,
+				Quantity:           DecimalFromFloat(100.5),
 				LimitPrice:         nil,
 				ReceivedTimestamp:  time.Now(),
 				SentTimestamp:      time.Now(),
-				QuantityFilled:     100.5,
-				TotalAmount:        15000.0,
-				AveragePrice:       149.25,
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
 			},
 			wantErr: false,
 		},
@@ -45,12 +50,12 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:       "NYSE",
 				SecurityID:        "12345678901234567890ABCD",
 				Ticker:            "AAPL",
-				Quantity:          100.5,
+				Quantity:          DecimalFromFloat(100.5),
 				ReceivedTimestamp: time.Now(),
 				SentTimestamp:     time.Now(),
-				QuantityFilled:    100.5,
-				TotalAmount:       15000.0,
-				AveragePrice:      149.25,
+				QuantityFilled:    DecimalFromFloat(100.5),
+				TotalAmount:       DecimalFromFloat(15000.0),
+				AveragePrice:      DecimalFromFloat(149.25),
 			},
 			wantErr: true,
 			errMsg:  "ExecutionServiceID",
@@ -64,12 +69,12 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:        "NYSE",
 				SecurityID:         "12345678901234567890ABCD",
 				Ticker:             "AAPL",
-				Quantity:           100.5,
+				Quantity:           DecimalFromFloat(100.5),
 				ReceivedTimestamp:  time.Now(),
 				SentTimestamp:      time.Now(),
-				QuantityFilled:     100.5,
-				TotalAmount:        15000.0,
-				AveragePrice:       149.25,
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
 			},
 			wantErr: true,
 			errMsg:  "TradeType",
@@ -83,12 +88,12 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:        "NYSE",
 				SecurityID:         "12345678901234567890ABCD",
 				Ticker:             "AAPL",
-				Quantity:           0,
+				Quantity:           DecimalFromFloat(0),
 				ReceivedTimestamp:  time.Now(),
 				SentTimestamp:      time.Now(),
-				QuantityFilled:     0,
-				TotalAmount:        0,
-				AveragePrice:       149.25,
+				QuantityFilled:     DecimalFromFloat(0),
+				TotalAmount:        DecimalFromFloat(0),
+				AveragePrice:       DecimalFromFloat(149.25),
 			},
 			wantErr: true,
 			errMsg:  "Quantity",
@@ -102,12 +107,12 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:        "NYSE",
 				SecurityID:         "12345678901234567890ABCD",
 				Ticker:             "AAPL",
-				Quantity:           100.5,
+				Quantity:           DecimalFromFloat(100.5),
 				ReceivedTimestamp:  time.Now(),
 				SentTimestamp:      time.Now(),
-				QuantityFilled:     100.5,
-				TotalAmount:        15000.0,
-				AveragePrice:       -149.25,
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(-149.25),
 			},
 			wantErr: true,
 			errMsg:  "AveragePrice",
@@ -121,16 +126,74 @@ func TestExecutionPostDTO_Validation(t *testing.T) {
 				Destination:        "NYSE",
 				SecurityID:         "12345678901234567890ABCD",
 				Ticker:             "AAPL",
-				Quantity:           100.5,
+				Quantity:           DecimalFromFloat(100.5),
 				ReceivedTimestamp:  time.Now(),
 				SentTimestamp:      time.Now(),
-				QuantityFilled:     -100.5,
-				TotalAmount:        15000.0,
-				AveragePrice:       149.25,
+				QuantityFilled:     DecimalFromFloat(-100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
 			},
 			wantErr: true,
 			errMsg:  "QuantityFilled",
 		},
+		{
+			name: "valid supplied PortfolioID",
+			dto: ExecutionPostDTO{
+				ExecutionServiceID: 123,
+				ExecutionStatus:    "FILLED",
+				TradeType:          "BUY",
+				Destination:        "NYSE",
+				SecurityID:         "12345678901234567890ABCD",
+				Ticker:             "AAPL",
+				Quantity:           DecimalFromFloat(100.5),
+				ReceivedTimestamp:  time.Now(),
+				SentTimestamp:      time.Now(),
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
+				PortfolioID:        &portfolioID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty PortfolioID falls back to lookup",
+			dto: ExecutionPostDTO{
+				ExecutionServiceID: 123,
+				ExecutionStatus:    "FILLED",
+				TradeType:          "BUY",
+				Destination:        "NYSE",
+				SecurityID:         "12345678901234567890ABCD",
+				Ticker:             "AAPL",
+				Quantity:           DecimalFromFloat(100.5),
+				ReceivedTimestamp:  time.Now(),
+				SentTimestamp:      time.Now(),
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
+				PortfolioID:        &emptyPortfolioID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed PortfolioID",
+			dto: ExecutionPostDTO{
+				ExecutionServiceID: 123,
+				ExecutionStatus:    "FILLED",
+				TradeType:          "BUY",
+				Destination:        "NYSE",
+				SecurityID:         "12345678901234567890ABCD",
+				Ticker:             "AAPL",
+				Quantity:           DecimalFromFloat(100.5),
+				ReceivedTimestamp:  time.Now(),
+				SentTimestamp:      time.Now(),
+				QuantityFilled:     DecimalFromFloat(100.5),
+				TotalAmount:        DecimalFromFloat(15000.0),
+				AveragePrice:       DecimalFromFloat(149.25),
+				PortfolioID:        &malformedPortfolioID,
+			},
+			wantErr: true,
+			errMsg:  "PortfolioID",
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,7 +213,7 @@ func TestExecution_ToDTO(t *testing.T) {
 	now := time.Now()
 	fillTime := now.Add(1 * time.Hour)
 	portfolioID := "PORTFOLIO123456789012"
-	limitPrice := 150.0
+	limitPrice := DecimalFromFloat(150.0)
 
 	execution := Execution{
 		ID:                   1,
@@ -163,14 +226,14 @@ func TestExecution_ToDTO(t *testing.T) {
 		SecurityID:           "12345678901234567890ABCD",
 		Ticker:               "AAPL",
 		PortfolioID:          &portfolioID,
-		Quantity:             100.5,
+		Quantity:             DecimalFromFloat(100.5),
 		LimitPrice:           &limitPrice,
 		ReceivedTimestamp:    now,
 		SentTimestamp:        now.Add(30 * time.Second),
 		LastFillTimestamp:    &fillTime,
-		QuantityFilled:       100.5,
-		TotalAmount:          15000.0,
-		AveragePrice:         149.25,
+		QuantityFilled:       DecimalFromFloat(100.5),
+		TotalAmount:          DecimalFromFloat(15000.0),
+		AveragePrice:         DecimalFromFloat(149.25),
 		ReadyToSendTimestamp: now,
 		Version:              1,
 	}
@@ -200,7 +263,7 @@ func TestExecution_ToDTO(t *testing.T) {
 func TestExecutionPostDTO_ToExecution(t *testing.T) {
 	now := time.Now()
 	fillTime := now.Add(1 * time.Hour)
-	limitPrice := 150.0
+	limitPrice := DecimalFromFloat(150.0)
 
 	dto := ExecutionPostDTO{
 		ExecutionServiceID: 123,
@@ -210,17 +273,20 @@ func TestExecutionPostDTO_ToExecution(t *testing.T) {
 		Destination:        "NYSE",
 		SecurityID:         "12345678901234567890ABCD",
 		Ticker:             "AAPL",
-		Quantity:           100.5,
+		Quantity:           DecimalFromFloat(100.5),
 		LimitPrice:         &limitPrice,
 		ReceivedTimestamp:  now,
 		SentTimestamp:      now.Add(30 * time.Second),
 		LastFillTimestamp:  &fillTime,
-		QuantityFilled:     100.5,
-		TotalAmount:        15000.0,
-		AveragePrice:       149.25,
+		QuantityFilled:     DecimalFromFloat(100.5),
+		TotalAmount:        DecimalFromFloat(15000.0),
+		AveragePrice:       DecimalFromFloat(149.25),
 	}
 
-	execution := dto.ToExecution()
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	execution := dto.ToExecution(loc)
 
 	assert.Equal(t, dto.ExecutionServiceID, execution.ExecutionServiceID)
 	assert.Equal(t, dto.IsOpen, execution.IsOpen)
@@ -339,3 +405,67 @@ func TestPaginationInfo_CalculatePages(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateTradeDate(t *testing.T) {
+	eastern, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		sent     time.Time
+		expected string
+	}{
+		{
+			name:     "winter afternoon",
+			sent:     time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC), // 16:00 EST
+			expected: "2024-01-15",
+		},
+		{
+			name:     "late evening rolls past UTC midnight",
+			sent:     time.Date(2024, 7, 16, 3, 30, 0, 0, time.UTC), // 23:30 EDT Jul 15
+			expected: "2024-07-15",
+		},
+		{
+			name:     "just after local midnight",
+			sent:     time.Date(2024, 1, 16, 5, 1, 0, 0, time.UTC), // 00:01 EST
+			expected: "2024-01-16",
+		},
+		{
+			name:     "spring forward day afternoon",
+			sent:     time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC), // 16:00 EDT
+			expected: "2024-03-10",
+		},
+		{
+			name:     "spring forward day late evening",
+			sent:     time.Date(2024, 3, 11, 3, 59, 0, 0, time.UTC), // 23:59 EDT Mar 10
+			expected: "2024-03-10",
+		},
+		{
+			name:     "fall back day repeated hour",
+			sent:     time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), // 01:30 EST (second pass)
+			expected: "2024-11-03",
+		},
+		{
+			name:     "fall back day late evening",
+			sent:     time.Date(2024, 11, 4, 4, 59, 0, 0, time.UTC), // 23:59 EST Nov 3
+			expected: "2024-11-03",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tradeDate := CalculateTradeDate(tt.sent, eastern)
+
+			assert.Equal(t, tt.expected, tradeDate.Format("2006-01-02"))
+			assert.Equal(t, eastern, tradeDate.Location())
+			hour, minute, second := tradeDate.Clock()
+			assert.Zero(t, hour+minute+second, "trade date must be local midnight")
+		})
+	}
+}
+
+func TestExecution_ComputeTotalAmount(t *testing.T) {
+	execution := Execution{Quantity: DecimalFromFloat(100), QuantityFilled: DecimalFromFloat(40), AveragePrice: DecimalFromFloat(10.05), TotalAmount: DecimalFromFloat(1)}
+
+	assert.Equal(t, "1005", execution.ComputeTotalAmount().String())
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RejectedExecution records an execution that was skipped or failed during batch
+// create, with the payload exactly as it was submitted
+type RejectedExecution struct {
+	ID                 int             `json:"id" db:"id"`
+	ExecutionServiceID int             `json:"executionServiceId" db:"execution_service_id"`
+	Status             string          `json:"status" db:"status"` // "skipped" or "error"
+	Reason             *string         `json:"reason,omitempty" db:"reason"`
+	Error              *string         `json:"error,omitempty" db:"error"`
+	Payload            json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt          time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// RejectedExecutionListResponse represents the paginated response for listing rejected executions
+type RejectedExecutionListResponse struct {
+	RejectedExecutions []RejectedExecution `json:"rejectedExecutions"`
+	Pagination         PaginationInfo      `json:"pagination"`
+}
@@ -8,6 +8,13 @@ type ExecutionListResponse struct {
 	Pagination PaginationInfo `json:"pagination"`
 }
 
+// BatchDetailResponse is a batch history record with, when requested, a page of the
+// executions in its [previousStartTime, startTime) window
+type BatchDetailResponse struct {
+	Batch      BatchHistory           `json:"batch"`
+	Executions *ExecutionListResponse `json:"executions,omitempty"`
+}
+
 // PaginationInfo represents pagination metadata
 type PaginationInfo struct {
 	TotalElements int  `json:"totalElements"`
@@ -18,6 +25,18 @@ type PaginationInfo struct {
 	HasPrevious   bool `json:"hasPrevious"`
 }
 
+// NewPaginationInfo builds pagination metadata for an offset/limit page of totalCount items
+func NewPaginationInfo(totalCount, limit, offset int) PaginationInfo {
+	return PaginationInfo{
+		TotalElements: totalCount,
+		TotalPages:    (totalCount + limit - 1) / limit,
+		CurrentPage:   offset / limit,
+		PageSize:      limit,
+		HasNext:       offset+limit < totalCount,
+		HasPrevious:   offset > 0,
+	}
+}
+
 // BatchCreateResponse represents the response for batch creation
 type BatchCreateResponse struct {
 	ProcessedCount int               `json:"processedCount"`
@@ -44,20 +63,107 @@ func (r *BatchCreateResponse) CalculateTotals() {
 	}
 }
 
+// Skip reasons reported in ExecutionResult.Reason
+const (
+	SkipReasonOpen             = "execution_open"
+	SkipReasonAlreadyExists    = "already_exists"
+	SkipReasonDuplicateInBatch = "duplicate_in_batch"
+	SkipReasonDeleted          = "deleted"
+	// SkipReasonPortfolioLookupFailed marks an execution whose portfolio could not be
+	// resolved; it was not stored, so it can be resubmitted in a later batch
+	SkipReasonPortfolioLookupFailed = "portfolio_lookup_failed"
+	// SkipReasonZeroQuantityFilled marks a fill whose quantity filled is zero, skipped
+	// under the skip zero quantity filled policy
+	SkipReasonZeroQuantityFilled = "zero_quantity_filled"
+	// SkipReasonTotalAmountMismatch marks a stored execution left out of a Send because
+	// it failed total amount reconciliation under the exclude policy
+	SkipReasonTotalAmountMismatch = "total_amount_mismatch"
+)
+
 // ExecutionResult represents the result of processing a single execution
 type ExecutionResult struct {
-	ExecutionServiceID int    `json:"executionServiceId"`
-	Status             string `json:"status"` // "created", "skipped", "error"
-	Error              string `json:"error,omitempty"`
-	ExecutionID        *int   `json:"executionId,omitempty"`
+	ExecutionServiceID int     `json:"executionServiceId"`
+	Status             string  `json:"status"`           // "created", "skipped", "error"
+	Reason             string  `json:"reason,omitempty"` // machine-readable skip reason
+	Error              string  `json:"error,omitempty"`
+	ExecutionID        *int    `json:"executionId,omitempty"`
+	PortfolioID        *string `json:"portfolioId,omitempty"` // set for created and already-existing executions
+}
+
+// FieldError describes one validation rule an execution payload field failed
+type FieldError struct {
+	Field   string `json:"field"`           // JSON field name, such as "quantity"
+	Rule    string `json:"rule"`            // validation rule, such as "required" or "gt"
+	Param   string `json:"param,omitempty"` // rule parameter, such as "0" for gt=0
+	Message string `json:"message"`
+}
+
+// ExecutionValidationResult reports whether one posted execution passed validation
+type ExecutionValidationResult struct {
+	Index              int  `json:"index"` // position in the posted array
+	ExecutionServiceID int  `json:"executionServiceId"`
+	Valid              bool `json:"valid"`
+	// SkipReason is set on a valid execution that CreateBatch would skip, such as a
+	// duplicate in the batch or a fill the zero quantity filled policy skips
+	SkipReason string       `json:"skipReason,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+}
+
+// ValidateExecutionsResponse reports per-execution validation results without creating anything
+type ValidateExecutionsResponse struct {
+	ValidCount   int                         `json:"validCount"`
+	InvalidCount int                         `json:"invalidCount"`
+	Results      []ExecutionValidationResult `json:"results"`
+}
+
+// BulkLoadResponse reports a bulk load. Nothing is loaded unless every execution can be,
+// so Results lists only the executions that held the load back.
+type BulkLoadResponse struct {
+	LoadedCount int                         `json:"loadedCount"`
+	Results     []ExecutionValidationResult `json:"results,omitempty"`
+}
+
+// ExecutionStats represents aggregate counts over executions
+type ExecutionStats struct {
+	TotalExecutions int            `json:"totalExecutions"`
+	ByTradeType     map[string]int `json:"byTradeType"`
+	ByDestination   map[string]int `json:"byDestination"`
+	SentCount       int            `json:"sentCount"`
+	UnsentCount     int            `json:"unsentCount"`
+}
+
+// ExecutionCountResponse reports how many executions match a filter
+type ExecutionCountResponse struct {
+	Count int `json:"count"`
+}
+
+// BulkDeleteResponse reports how many executions a delete by filter removed
+type BulkDeleteResponse struct {
+	DeletedCount int `json:"deletedCount"`
 }
 
 // SendResponse represents the response for sending executions to Portfolio Accounting
 type SendResponse struct {
-	ProcessedCount int    `json:"processedCount"`
-	FileName       string `json:"fileName"`
-	Status         string `json:"status"`
-	Message        string `json:"message"`
+	BatchID                int      `json:"batchId,omitempty"` // batch_history ID; absent when no batch was recorded
+	ProcessedCount         int      `json:"processedCount"`
+	FileName               string   `json:"fileName"`            // the file, the first of several, or the one the CLI failed on
+	FileNames              []string `json:"fileNames,omitempty"` // every file, when output or the window is split
+	Status                 string   `json:"status"`
+	Message                string   `json:"message"`
+	ExitCode               *int     `json:"exitCode,omitempty"`               // CLI exit code when the CLI step failed
+	MismatchedExecutionIDs []int    `json:"mismatchedExecutionIds,omitempty"` // executions failing total amount reconciliation
+	CLIStdout              string   `json:"cliStdout,omitempty"`              // output of a CLI stopped on timeout or cancellation
+	CLIStderr              string   `json:"cliStderr,omitempty"`
+}
+
+// SendCompletionEvent is posted to the completion webhook when a Send or batch retry finishes
+type SendCompletionEvent struct {
+	BatchID        int      `json:"batchId"`
+	Status         string   `json:"status"` // "success" or "error"
+	ProcessedCount int      `json:"processedCount"`
+	FileName       string   `json:"fileName,omitempty"`
+	FileNames      []string `json:"fileNames,omitempty"` // every file, when output is split by portfolio
+	Error          string   `json:"error,omitempty"`
 }
 
 // HealthResponse represents the health check response
@@ -135,10 +241,11 @@ type TradeServiceDestination struct {
 
 // ErrorResponse represents a standardized API error response
 type ErrorResponse struct {
-	Message   string `json:"message"`
-	Status    int    `json:"status"`
-	Timestamp string `json:"timestamp"`
-	Details   string `json:"details,omitempty"`
+	Message       string `json:"message"`
+	Status        int    `json:"status"`
+	Timestamp     string `json:"timestamp"`
+	Details       string `json:"details,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
 }
 
 // GetCurrentTimestamp returns the current timestamp in RFC3339 format
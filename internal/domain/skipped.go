@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+)
+
+// MaxReprocessBatchSize caps how many skipped executions one reprocess request handles,
+// matching the create batch limit
+const MaxReprocessBatchSize = 100
+
+// SkippedExecution is a stored skip that can be reprocessed later, holding the payload
+// exactly as it was submitted
+type SkippedExecution struct {
+	ExecutionServiceID int       `db:"execution_service_id"`
+	Reason             string    `db:"reason"`
+	Payload            []byte    `db:"payload"` // JSON-encoded ExecutionPostDTO, or ExecutionDTO for a record-only skip
+	SkippedAt          time.Time `db:"skipped_at"`
+}
+
+// IsReprocessableSkipReason reports whether executions skipped for reason are stored
+// for reprocessing; other skips, such as duplicates, are final. Total amount mismatches
+// are stored for the record only, since the execution itself is already stored.
+func IsReprocessableSkipReason(reason string) bool {
+	return reason == SkipReasonOpen || reason == SkipReasonPortfolioLookupFailed
+}
+
+// ReprocessRequest selects stored skipped executions to reprocess, by executionServiceId,
+// by skip reason, or by giving updated payloads for them
+type ReprocessRequest struct {
+	ExecutionServiceIDs []int  `json:"executionServiceIds,omitempty"`
+	Reason              string `json:"reason,omitempty"`
+	// Executions replace the stored payloads, for executions whose state changed after
+	// they were skipped, such as one skipped while open that has since closed
+	Executions []ExecutionPostDTO `json:"executions,omitempty"`
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// Response formats for list endpoints
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// executionCSVHeader names the CSV columns after the ExecutionDTO JSON fields
+var executionCSVHeader = []string{
+	"id", "executionServiceId", "isOpen", "executionStatus", "tradeType", "destination",
+	"securityId", "portfolioId", "ticker", "quantity", "limitPrice", "receivedTimestamp",
+	"sentTimestamp", "lastFillTimestamp", "quantityFilled", "totalAmount", "averagePrice",
+	"version", "deletedAt",
+}
+
+// negotiateFormat picks JSON or CSV from the format query parameter, which wins, or
+// else from the Accept header: the text/csv or application/json (or wildcard) entry with
+// the highest q-value, the first on a tie. Entries with q=0 are never chosen, and JSON
+// is the default when nothing acceptable is listed.
+func negotiateFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "":
+	case formatJSON, formatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format parameter, expected json or csv")
+	}
+
+	format, bestQ := formatJSON, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		var candidate string
+		switch mediaType {
+		case "text/csv":
+			candidate = formatCSV
+		case "application/json", "application/*", "*/*":
+			candidate = formatJSON
+		default:
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			format, bestQ = candidate, q
+		}
+	}
+	return format, nil
+}
+
+// writeExecutionsCSV writes executions as CSV with a header row. Pagination totals,
+// which have no place in the CSV body, are carried in the X-Total-Count header.
+func writeExecutionsCSV(w http.ResponseWriter, response *domain.ExecutionListResponse) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("X-Total-Count", strconv.Itoa(response.Pagination.TotalElements))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(executionCSVHeader); err != nil {
+		return err
+	}
+	for _, execution := range response.Executions {
+		if err := cw.Write(executionCSVRecord(execution)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// executionCSVRecord renders an execution in executionCSVHeader column order; nil values are empty
+func executionCSVRecord(e domain.ExecutionDTO) []string {
+	return []string{
+		strconv.Itoa(e.ID),
+		strconv.Itoa(e.ExecutionServiceID),
+		strconv.FormatBool(e.IsOpen),
+		e.ExecutionStatus,
+		e.TradeType,
+		e.Destination,
+		e.SecurityID,
+		optionalString(e.PortfolioID),
+		e.Ticker,
+		e.Quantity.String(),
+		optionalDecimal(e.LimitPrice),
+		e.ReceivedTimestamp.Format(time.RFC3339Nano),
+		e.SentTimestamp.Format(time.RFC3339Nano),
+		optionalTime(e.LastFillTimestamp),
+		e.QuantityFilled.String(),
+		e.TotalAmount.String(),
+		e.AveragePrice.String(),
+		strconv.Itoa(e.Version),
+		optionalTime(e.DeletedAt),
+	}
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optionalDecimal(d *domain.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		accept        string
+		expected      string
+		expectedError bool
+	}{
+		{name: "no preference", expected: formatJSON},
+		{name: "accept csv", accept: "text/csv", expected: formatCSV},
+		{name: "accept csv with parameters", accept: "text/csv; charset=utf-8", expected: formatCSV},
+		{name: "accept json", accept: "application/json", expected: formatJSON},
+		{name: "json preferred over csv", accept: "application/json, text/csv", expected: formatJSON},
+		{name: "csv preferred over wildcard", accept: "text/csv, */*;q=0.8", expected: formatCSV},
+		{name: "csv refused", accept: "text/csv;q=0, application/json", expected: formatJSON},
+		{name: "csv refused alone", accept: "text/csv;q=0", expected: formatJSON},
+		{name: "higher q wins", accept: "application/json;q=0.5, text/csv;q=0.9", expected: formatCSV},
+		{name: "wildcard preferred by q", accept: "text/csv;q=0.2, */*", expected: formatJSON},
+		{name: "browser accept", accept: "text/html,application/xhtml+xml,*/*;q=0.8", expected: formatJSON},
+		{name: "query overrides accept", query: "format=csv", accept: "application/json", expected: formatCSV},
+		{name: "query json", query: "format=json", accept: "text/csv", expected: formatJSON},
+		{name: "invalid query format", query: "format=xml", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/executions?"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			format, err := negotiateFormat(req)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+}
+
+func TestWriteExecutionsCSV(t *testing.T) {
+	portfolioID := "PORTFOLIO123456789012"
+	limitPrice := domain.DecimalFromFloat(50.5)
+	received := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	response := &domain.ExecutionListResponse{
+		Executions: []domain.ExecutionDTO{
+			{
+				ID:                 1,
+				ExecutionServiceID: 100,
+				ExecutionStatus:    "FULL",
+				TradeType:          "BUY",
+				Destination:        "NYSE",
+				SecurityID:         "SEC1",
+				PortfolioID:        &portfolioID,
+				Ticker:             "ACME, Inc.",
+				Quantity:           domain.DecimalFromFloat(100),
+				LimitPrice:         &limitPrice,
+				ReceivedTimestamp:  received,
+				SentTimestamp:      received,
+				QuantityFilled:     domain.DecimalFromFloat(100),
+				TotalAmount:        domain.DecimalFromFloat(5025),
+				AveragePrice:       domain.DecimalFromFloat(50.25),
+				Version:            2,
+			},
+			{ID: 2, ExecutionServiceID: 101, TradeType: "SELL", ReceivedTimestamp: received, SentTimestamp: received},
+		},
+		Pagination: domain.NewPaginationInfo(12, 2, 0),
+	}
+
+	w := httptest.NewRecorder()
+	require.NoError(t, writeExecutionsCSV(w, response))
+
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, executionCSVHeader, records[0])
+	assert.Equal(t, []string{
+		"1", "100", "false", "FULL", "BUY", "NYSE", "SEC1", "PORTFOLIO123456789012", "ACME, Inc.",
+		"100", "50.5", "2024-01-15T10:00:00Z", "2024-01-15T10:00:00Z", "", "100", "5025", "50.25", "2", "",
+	}, records[1])
+	assert.Equal(t, "", records[2][7], "missing portfolio ID is an empty cell")
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// executionETag builds a weak entity tag from an execution's ID and version. Every
+// update bumps the version, so the tag changes whenever the representation can.
+func executionETag(id, version int) string {
+	return fmt.Sprintf(`W/"%d-%d"`, id, version)
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag using
+// weak comparison, as RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionETag(t *testing.T) {
+	assert.Equal(t, `W/"42-3"`, executionETag(42, 3))
+	assert.NotEqual(t, executionETag(42, 3), executionETag(42, 4))
+	assert.NotEqual(t, executionETag(4, 23), executionETag(42, 3))
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := executionETag(42, 3)
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{name: "no header", ifNoneMatch: "", expected: false},
+		{name: "exact match", ifNoneMatch: `W/"42-3"`, expected: true},
+		{name: "strong form matches weakly", ifNoneMatch: `"42-3"`, expected: true},
+		{name: "older version", ifNoneMatch: `W/"42-2"`, expected: false},
+		{name: "match in list", ifNoneMatch: `W/"42-2", W/"42-3"`, expected: true},
+		{name: "wildcard", ifNoneMatch: "*", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, etagMatches(tt.ifNoneMatch, etag))
+		})
+	}
+}
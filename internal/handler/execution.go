@@ -2,21 +2,36 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
 	"github.com/kasbench/globeco-allocation-service/internal/service"
 )
 
+// tradeDateLayout is the query parameter format for trade dates
+const tradeDateLayout = "2006-01-02"
+
+// maxCreateBatchSize is the most executions one create or validate request may hold
+const maxCreateBatchSize = 100
+
+// maxBulkLoadSize is the most executions one bulk load request may hold
+const maxBulkLoadSize = 50000
+
 // ExecutionHandler handles HTTP requests for executions
 type ExecutionHandler struct {
 	executionService *service.ExecutionService
 	logger           *zap.Logger
+	decimalStrings   bool
 }
 
 // NewExecutionHandler creates a new execution handler
@@ -27,64 +42,219 @@ func NewExecutionHandler(executionService *service.ExecutionService, logger *zap
 	}
 }
 
+// SetDecimalStrings makes execution responses encode quantities, prices and amounts
+// as decimal strings, such as "15075000.25", instead of JSON numbers
+func (h *ExecutionHandler) SetDecimalStrings(enabled bool) {
+	h.decimalStrings = enabled
+}
+
 // GetExecutions handles GET /api/v1/executions
 func (h *ExecutionHandler) GetExecutions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	// Set defaults
-	limit := 50
-	offset := 0
-
-	// Parse limit
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err != nil {
-			h.writeErrorResponse(w, http.StatusBadRequest, "invalid limit parameter", err)
-			return
-		} else {
-			limit = parsedLimit
-		}
+	limit, offset, err := h.parsePagination(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
-	// Parse offset
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err != nil {
-			h.writeErrorResponse(w, http.StatusBadRequest, "invalid offset parameter", err)
-			return
-		} else {
-			offset = parsedOffset
-		}
+	filter, err := parseExecutionFilter(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
-	// Validate parameters
-	if limit < 1 || limit > 1000 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 1000", nil)
+	sort, err := parseExecutionSort(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	if offset < 0 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "offset must be non-negative", nil)
+	format, err := negotiateFormat(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
 	h.logger.Info("Fetching executions",
 		zap.Int("limit", limit),
-		zap.Int("offset", offset))
+		zap.Int("offset", offset),
+		zap.Bool("include_deleted", filter.IncludeDeleted),
+		zap.String("sort_by", sort.Field),
+		zap.Bool("ascending", sort.Ascending))
 
 	// Call service
-	response, err := h.executionService.List(ctx, limit, offset)
+	response, err := h.executionService.List(ctx, filter, sort, limit, offset)
 	if err != nil {
 		h.logger.Error("Failed to list executions", zap.Error(err))
 		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve executions", err)
 		return
 	}
 
+	w.Header().Set("Link", paginationLinks(r.URL, response.Pagination.PageSize, offset, response.Pagination.TotalElements))
+
+	if format == formatCSV {
+		if err := writeExecutionsCSV(w, response); err != nil {
+			h.logger.Error("Failed to write CSV response", zap.Error(err))
+		}
+		return
+	}
+
+	if h.decimalStrings {
+		h.writeJSONResponse(w, http.StatusOK, response.WithDecimalStrings())
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetAuditLogs handles GET /api/v1/audit
+func (h *ExecutionHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := h.parsePagination(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	response, err := h.executionService.ListAuditLogs(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list audit logs", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve audit logs", err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetRejectedExecutions handles GET /api/v1/executions/rejected
+func (h *ExecutionHandler) GetRejectedExecutions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := h.parsePagination(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	response, err := h.executionService.ListRejectedExecutions(ctx, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list rejected executions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve rejected executions", err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetExecutionCount handles GET /api/v1/executions/count
+func (h *ExecutionHandler) GetExecutionCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseExecutionFilter(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	response, err := h.executionService.Count(ctx, filter)
+	if err != nil {
+		h.logger.Error("Failed to count executions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to count executions", err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetExecutionStats handles GET /api/v1/executions/stats
+func (h *ExecutionHandler) GetExecutionStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseExecutionFilter(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	stats, err := h.executionService.GetStats(ctx, filter)
+	if err != nil {
+		h.logger.Error("Failed to get execution stats", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve execution stats", err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, stats)
+}
+
+// DeleteExecutions handles DELETE /api/v1/executions, soft-deleting every execution
+// matching the filter
+func (h *ExecutionHandler) DeleteExecutions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := parseBulkDeleteFilter(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	h.logger.Info("Deleting executions by filter", zap.String("query", r.URL.RawQuery))
+
+	response, err := h.executionService.DeleteByFilter(ctx, filter)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrExecutionsAlreadySent) {
+			h.writeErrorResponse(w, http.StatusConflict, "matching executions were already sent in a batch; nothing was deleted", err)
+			return
+		}
+		h.logger.Error("Failed to delete executions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to delete executions", err)
+		return
+	}
+
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// StreamExecutions handles GET /api/v1/executions/stream, sending each execution as it
+// is created as a server-sent event until the client disconnects
+func (h *ExecutionHandler) StreamExecutions(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe, err := h.executionService.SubscribeExecutions()
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTooManySubscribers) {
+			w.Header().Set("Retry-After", "30")
+			h.writeErrorResponse(w, http.StatusServiceUnavailable, "too many execution stream subscribers", err)
+			return
+		}
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "execution stream is unavailable", err)
+		return
+	}
+	defer unsubscribe()
+
+	// The stream stays open well past the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("Failed to clear write deadline for execution stream", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("Execution stream requires a flushable response", zap.Error(err))
+		return
+	}
+
+	h.logger.Info("Execution stream subscriber connected")
+	encode := func(execution domain.ExecutionDTO) interface{} { return execution }
+	if h.decimalStrings {
+		encode = func(execution domain.ExecutionDTO) interface{} { return domain.DecimalStringExecutionDTO(execution) }
+	}
+	if err := streamExecutions(r.Context(), w, events, streamKeepAliveInterval, encode); err != nil {
+		h.logger.Info("Execution stream write failed", zap.Error(err))
+	}
+	h.logger.Info("Execution stream subscriber disconnected")
+}
+
 // GetExecution handles GET /api/v1/executions/{id}
 func (h *ExecutionHandler) GetExecution(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -107,7 +277,7 @@ func (h *ExecutionHandler) GetExecution(w http.ResponseWriter, r *http.Request)
 	// Call service
 	execution, err := h.executionService.GetByID(ctx, id)
 	if err != nil {
-		if strings.Contains(err.Error(), "execution not found") {
+		if errors.Is(err, apperrors.ErrExecutionNotFound) {
 			h.writeErrorResponse(w, http.StatusNotFound, "execution not found", err)
 			return
 		}
@@ -116,6 +286,17 @@ func (h *ExecutionHandler) GetExecution(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	etag := executionETag(execution.ID, execution.Version)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if h.decimalStrings {
+		h.writeJSONResponse(w, http.StatusOK, domain.DecimalStringExecutionDTO(*execution))
+		return
+	}
 	h.writeJSONResponse(w, http.StatusOK, execution)
 }
 
@@ -132,13 +313,8 @@ func (h *ExecutionHandler) CreateExecutions(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Validate request
-	if len(executions) == 0 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "no executions provided", nil)
-		return
-	}
-
-	if len(executions) > 100 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "batch size exceeds maximum of 100 executions", nil)
+	if err := validateBatchSize(executions); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
@@ -152,46 +328,454 @@ func (h *ExecutionHandler) CreateExecutions(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Determine response status based on results
-	statusCode := http.StatusCreated
+	h.writeJSONResponse(w, batchCreateStatusCode(response), response)
+}
+
+// ValidateExecutions handles POST /api/v1/executions/validate, checking a batch the way
+// CreateExecutions does without creating anything
+func (h *ExecutionHandler) ValidateExecutions(w http.ResponseWriter, r *http.Request) {
+	var executions []domain.ExecutionPostDTO
+	if err := json.NewDecoder(r.Body).Decode(&executions); err != nil {
+		h.logger.Error("Failed to decode request body", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if err := validateBatchSize(executions); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, h.executionService.ValidateBatch(executions))
+}
+
+// BulkLoadExecutions handles POST /api/v1/executions/bulk-load, loading a backfill whose
+// portfolio IDs are already resolved in one COPY
+func (h *ExecutionHandler) BulkLoadExecutions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var executions []domain.ExecutionPostDTO
+	if err := json.NewDecoder(r.Body).Decode(&executions); err != nil {
+		h.logger.Error("Failed to decode request body", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if len(executions) == 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "no executions provided", nil)
+		return
+	}
+	if len(executions) > maxBulkLoadSize {
+		h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("bulk load size exceeds maximum of %d executions", maxBulkLoadSize), nil)
+		return
+	}
+
+	h.logger.Info("Bulk loading executions", zap.Int("count", len(executions)))
+
+	response, err := h.executionService.BulkLoad(ctx, executions)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrBulkLoadDisabled) {
+			h.writeErrorResponse(w, http.StatusNotFound, "bulk load is disabled", err)
+			return
+		}
+		h.logger.Error("Failed to bulk load executions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to bulk load executions", err)
+		return
+	}
+	if len(response.Results) > 0 {
+		h.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// validateBatchSize checks that a create or validate request holds 1 to 100 executions
+func validateBatchSize(executions []domain.ExecutionPostDTO) error {
+	if len(executions) == 0 {
+		return errors.New("no executions provided")
+	}
+	if len(executions) > maxCreateBatchSize {
+		return fmt.Errorf("batch size exceeds maximum of %d executions", maxCreateBatchSize)
+	}
+	return nil
+}
+
+// ReprocessExecutions handles POST /api/v1/executions/reprocess
+func (h *ExecutionHandler) ReprocessExecutions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request domain.ReprocessRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.logger.Error("Failed to decode request body", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if err := validateReprocessRequest(request); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	response, err := h.executionService.Reprocess(ctx, request)
+	if err != nil {
+		h.logger.Error("Failed to reprocess executions", zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to reprocess executions", err)
+		return
+	}
+
+	h.writeJSONResponse(w, batchCreateStatusCode(response), response)
+}
+
+// validateReprocessRequest checks that a reprocess request selects skipped executions
+// by exactly one of executionServiceIds, a reprocessable skip reason or executions
+func validateReprocessRequest(request domain.ReprocessRequest) error {
+	selectors := 0
+	for _, given := range []bool{len(request.ExecutionServiceIDs) > 0, request.Reason != "", len(request.Executions) > 0} {
+		if given {
+			selectors++
+		}
+	}
+
+	switch {
+	case selectors > 1:
+		return fmt.Errorf("specify only one of executionServiceIds, reason or executions")
+	case len(request.ExecutionServiceIDs) > domain.MaxReprocessBatchSize, len(request.Executions) > domain.MaxReprocessBatchSize:
+		return fmt.Errorf("reprocess size exceeds maximum of %d executions", domain.MaxReprocessBatchSize)
+	case len(request.ExecutionServiceIDs) > 0, len(request.Executions) > 0:
+		return nil
+	case request.Reason == "":
+		return fmt.Errorf("executionServiceIds, reason or executions is required")
+	case !domain.IsReprocessableSkipReason(request.Reason):
+		return fmt.Errorf("reason must be %q or %q", domain.SkipReasonOpen, domain.SkipReasonPortfolioLookupFailed)
+	}
+	return nil
+}
+
+// batchCreateStatusCode is 201 when every execution was created or skipped, 207 for
+// mixed results and 400 when nothing was created and at least one execution failed
+func batchCreateStatusCode(response *domain.BatchCreateResponse) int {
 	if response.ErrorCount > 0 && response.ProcessedCount == 0 {
 		// All requests failed
-		statusCode = http.StatusBadRequest
+		return http.StatusBadRequest
 	} else if response.ErrorCount > 0 {
 		// Mixed results
-		statusCode = http.StatusMultiStatus
+		return http.StatusMultiStatus
 	}
-
-	h.writeJSONResponse(w, statusCode, response)
+	return http.StatusCreated
 }
 
 // SendExecutions handles POST /api/v1/executions/send
 func (h *ExecutionHandler) SendExecutions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	h.logger.Info("Sending executions to Portfolio Accounting")
+	batchKey, err := parseBatchKey(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		force, err = strconv.ParseBool(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid force parameter", err)
+			return
+		}
+	}
+
+	h.logger.Info("Sending executions to Portfolio Accounting", zap.String("batch_key", batchKey), zap.Bool("force", force))
 
 	// Call service
-	response, err := h.executionService.Send(ctx)
+	response, err := h.executionService.SendWithOptions(ctx, service.SendOptions{BatchKey: batchKey, Force: force})
 	if err != nil {
 		// Check for specific error types
-		if err.Error() == "duplicate batch process already started" {
+		if errors.Is(err, apperrors.ErrDuplicateBatch) {
 			h.writeErrorResponse(w, http.StatusConflict, "batch process already in progress", err)
 			return
 		}
+		if errors.Is(err, apperrors.ErrBatchKeyInUse) {
+			h.writeErrorResponse(w, http.StatusConflict, "batch key belongs to a batch that did not complete", err)
+			return
+		}
+		if errors.Is(err, apperrors.ErrSendQueueTimeout) {
+			h.writeSendQueueTimeout(w, err)
+			return
+		}
+		if errors.Is(err, apperrors.ErrSendWindowTooLarge) {
+			h.writeErrorResponse(w, http.StatusUnprocessableEntity, "send window exceeds max_send_window_duration, send with force=true to process it", err)
+			return
+		}
 
 		h.logger.Error("Failed to send executions", zap.Error(err))
-		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to process executions", err)
+		// CLI and reconciliation failures still return a response with the details
+		if response == nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "failed to process executions", err)
+			return
+		}
+	}
+
+	h.writeJSONResponse(w, sendStatusCode(response, err), response)
+}
+
+// writeSendQueueTimeout responds 503 to a Send or retry that gave up waiting in the
+// Send queue, suggesting a retry once another full wait has passed
+func (h *ExecutionHandler) writeSendQueueTimeout(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(h.executionService.SendQueueMaxWait())))
+	h.writeErrorResponse(w, http.StatusServiceUnavailable, "send queue is full, retry later", err)
+}
+
+// retryAfterSeconds rounds d up to whole seconds for a Retry-After header, minimum 1
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// sendStatusCode maps a Send or retry response to its HTTP status
+func sendStatusCode(response *domain.SendResponse, err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrReconciliationFailed):
+		return http.StatusUnprocessableEntity
+	case response.Status == "error":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusOK
+	}
+}
+
+// RetryBatch handles POST /api/v1/batches/{id}/retry
+func (h *ExecutionHandler) RetryBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid batch ID", err)
+		return
+	}
+
+	h.logger.Info("Retrying batch", zap.Int("batch_id", id))
+
+	response, err := h.executionService.RetryBatch(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrBatchNotFound) {
+			h.writeErrorResponse(w, http.StatusNotFound, "batch not found", err)
+			return
+		}
+		if errors.Is(err, apperrors.ErrBatchNotFailed) {
+			h.writeErrorResponse(w, http.StatusConflict, "only failed batches can be retried", err)
+			return
+		}
+		if errors.Is(err, apperrors.ErrDuplicateBatch) {
+			h.writeErrorResponse(w, http.StatusConflict, "batch process already in progress", err)
+			return
+		}
+		if errors.Is(err, apperrors.ErrSendQueueTimeout) {
+			h.writeSendQueueTimeout(w, err)
+			return
+		}
+		if response == nil {
+			h.logger.Error("Failed to retry batch", zap.Int("batch_id", id), zap.Error(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retry batch", err)
+			return
+		}
+	}
+
+	h.writeJSONResponse(w, sendStatusCode(response, err), response)
+}
+
+// GetBatch handles GET /api/v1/batches/{id}; includeExecutions=true adds a page of the
+// batch's executions, paginated like the list endpoints
+func (h *ExecutionHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "invalid batch ID", err)
+		return
+	}
+
+	includeExecutions := false
+	if value := r.URL.Query().Get("includeExecutions"); value != "" {
+		includeExecutions, err = strconv.ParseBool(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "invalid includeExecutions parameter", err)
+			return
+		}
+	}
+
+	limit, offset, err := h.parsePagination(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	// Determine status code based on response
-	statusCode := http.StatusOK
-	if response.Status == "error" {
-		statusCode = http.StatusInternalServerError
+	response, err := h.executionService.GetBatch(ctx, id, includeExecutions, limit, offset)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrBatchNotFound) {
+			h.writeErrorResponse(w, http.StatusNotFound, "batch not found", err)
+			return
+		}
+		h.logger.Error("Failed to get batch", zap.Int("batch_id", id), zap.Error(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve batch", err)
+		return
 	}
 
-	h.writeJSONResponse(w, statusCode, response)
+	if h.decimalStrings {
+		h.writeJSONResponse(w, http.StatusOK, response.WithDecimalStrings())
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// parsePagination reads the pagination query parameters within the service's
+// configured page size limits
+func (h *ExecutionHandler) parsePagination(r *http.Request) (int, int, error) {
+	defaultSize, maxSize := h.executionService.PageSizeLimits()
+	return parsePagination(r, defaultSize, maxSize)
+}
+
+// parsePagination reads the limit (default defaultSize, 1 to maxSize) and offset
+// (default 0) query parameters shared by the list endpoints
+func parsePagination(r *http.Request, defaultSize, maxSize int) (int, int, error) {
+	limit := defaultSize
+	offset := 0
+	query := r.URL.Query()
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid limit parameter")
+		}
+		limit = parsedLimit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsedOffset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid offset parameter")
+		}
+		offset = parsedOffset
+	}
+
+	if limit < 1 || limit > maxSize {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxSize)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset must be non-negative")
+	}
+
+	return limit, offset, nil
+}
+
+// parseExecutionFilter builds an ExecutionFilter from the shared list/stats/count query
+// parameters: includeDeleted, tradeDateFrom and tradeDateTo (YYYY-MM-DD, inclusive),
+// tickerPrefix and destination
+func parseExecutionFilter(r *http.Request) (domain.ExecutionFilter, error) {
+	var filter domain.ExecutionFilter
+	query := r.URL.Query()
+
+	if includeDeletedStr := query.Get("includeDeleted"); includeDeletedStr != "" {
+		includeDeleted, err := strconv.ParseBool(includeDeletedStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid includeDeleted parameter")
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+
+	if fromStr := query.Get("tradeDateFrom"); fromStr != "" {
+		from, err := time.Parse(tradeDateLayout, fromStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid tradeDateFrom parameter, expected YYYY-MM-DD")
+		}
+		filter.TradeDateFrom = &from
+	}
+
+	if toStr := query.Get("tradeDateTo"); toStr != "" {
+		to, err := time.Parse(tradeDateLayout, toStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid tradeDateTo parameter, expected YYYY-MM-DD")
+		}
+		filter.TradeDateTo = &to
+	}
+
+	if filter.TradeDateFrom != nil && filter.TradeDateTo != nil && filter.TradeDateTo.Before(*filter.TradeDateFrom) {
+		return filter, fmt.Errorf("tradeDateTo must not be before tradeDateFrom")
+	}
+
+	if query.Has("tickerPrefix") {
+		prefix := strings.TrimSpace(query.Get("tickerPrefix"))
+		if prefix == "" || len(prefix) > domain.MaxTickerPrefixLength {
+			return filter, fmt.Errorf("tickerPrefix must be 1 to %d characters", domain.MaxTickerPrefixLength)
+		}
+		filter.TickerPrefix = prefix
+	}
+
+	if query.Has("destination") {
+		destination := strings.TrimSpace(query.Get("destination"))
+		if destination == "" {
+			return filter, fmt.Errorf("destination must not be empty")
+		}
+		filter.Destination = destination
+	}
+
+	return filter, nil
+}
+
+// parseBulkDeleteFilter reads the filter for a delete by filter. It requires
+// confirm=true and at least one criterion so a bare DELETE can't remove everything.
+func parseBulkDeleteFilter(r *http.Request) (domain.ExecutionFilter, error) {
+	query := r.URL.Query()
+	if confirm, err := strconv.ParseBool(query.Get("confirm")); err != nil || !confirm {
+		return domain.ExecutionFilter{}, fmt.Errorf("confirm=true is required to delete executions by filter")
+	}
+	if query.Has("includeDeleted") {
+		return domain.ExecutionFilter{}, fmt.Errorf("includeDeleted is not supported when deleting executions")
+	}
+
+	filter, err := parseExecutionFilter(r)
+	if err != nil {
+		return filter, err
+	}
+	if !filter.HasCriteria() {
+		return filter, fmt.Errorf("at least one of tradeDateFrom, tradeDateTo, tickerPrefix or destination is required")
+	}
+	return filter, nil
+}
+
+// parseExecutionSort reads the sortBy (one of domain.ExecutionSortFields, default id)
+// and sortOrder (asc or desc, default desc) query parameters
+func parseExecutionSort(r *http.Request) (domain.ExecutionSort, error) {
+	var sort domain.ExecutionSort
+	query := r.URL.Query()
+
+	if sortBy := query.Get("sortBy"); sortBy != "" {
+		if !slices.Contains(domain.ExecutionSortFields, sortBy) {
+			return sort, fmt.Errorf("invalid sortBy parameter, expected one of: %s", strings.Join(domain.ExecutionSortFields, ", "))
+		}
+		sort.Field = sortBy
+	}
+
+	switch strings.ToLower(query.Get("sortOrder")) {
+	case "", "desc":
+	case "asc":
+		sort.Ascending = true
+	default:
+		return sort, fmt.Errorf("invalid sortOrder parameter, expected asc or desc")
+	}
+
+	return sort, nil
+}
+
+// maxBatchKeyLength matches the batch_history.batch_key column
+const maxBatchKeyLength = 255
+
+// parseBatchKey reads the optional batchKey query parameter that makes a Send idempotent
+func parseBatchKey(r *http.Request) (string, error) {
+	batchKey := r.URL.Query().Get("batchKey")
+	if len(batchKey) > maxBatchKeyLength {
+		return "", fmt.Errorf("invalid batchKey parameter, must be at most %d characters", maxBatchKeyLength)
+	}
+	return batchKey, nil
 }
 
 // writeJSONResponse writes a JSON response with the given status code
@@ -4,19 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/config"
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/repository"
+	"github.com/kasbench/globeco-allocation-service/internal/service"
 )
 
 // ExecutionServiceInterface defines the interface for execution service operations
@@ -123,7 +130,11 @@ func (h *TestableExecutionHandler) GetExecution(w http.ResponseWriter, r *http.R
 	// Call service
 	execution, err := h.service.GetByID(ctx, id)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusNotFound, "execution not found", err)
+		if errors.Is(err, apperrors.ErrExecutionNotFound) {
+			h.writeErrorResponse(w, http.StatusNotFound, "execution not found", err)
+			return
+		}
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to retrieve execution", err)
 		return
 	}
 
@@ -153,7 +164,7 @@ func (h *TestableExecutionHandler) SendExecutions(w http.ResponseWriter, r *http
 	// Call service
 	response, err := h.service.Send(ctx)
 	if err != nil {
-		if err.Error() == "duplicate batch process already started" {
+		if errors.Is(err, apperrors.ErrDuplicateBatch) {
 			h.writeErrorResponse(w, http.StatusConflict, "batch process already in progress", err)
 			return
 		}
@@ -204,12 +215,12 @@ func TestExecutionHandler_CreateExecutions(t *testing.T) {
 			Destination:        "NYSE",
 			SecurityID:         "12345678901234567890ABCD",
 			Ticker:             "AAPL",
-			Quantity:           100.5,
+			Quantity:           domain.DecimalFromFloat(100.5),
 			ReceivedTimestamp:  fixedTime,
 			SentTimestamp:      fixedTime.Add(1 * time.Minute),
-			QuantityFilled:     100.5,
-			TotalAmount:        15075.0,
-			AveragePrice:       150.0,
+			QuantityFilled:     domain.DecimalFromFloat(100.5),
+			TotalAmount:        domain.DecimalFromFloat(15075.0),
+			AveragePrice:       domain.DecimalFromFloat(150.0),
 		},
 	}
 
@@ -274,7 +285,7 @@ func TestExecutionHandler_GetExecution(t *testing.T) {
 		SecurityID:         "12345678901234567890ABCD",
 		Ticker:             "AAPL",
 		PortfolioID:        &portfolioID,
-		Quantity:           100.5,
+		Quantity:           domain.DecimalFromFloat(100.5),
 		ReceivedTimestamp:  now,
 		Version:            1,
 	}
@@ -463,10 +474,10 @@ func TestExecutionHandler_CreateExecutions_ServiceError(t *testing.T) {
 			Destination:        "NYSE",
 			SecurityID:         "12345678901234567890ABCD",
 			Ticker:             "AAPL",
-			Quantity:           100.5,
+			Quantity:           domain.DecimalFromFloat(100.5),
 			ReceivedTimestamp:  fixedTime,
 			SentTimestamp:      fixedTime.Add(1 * time.Minute),
-			AveragePrice:       150.0,
+			AveragePrice:       domain.DecimalFromFloat(150.0),
 		},
 	}
 
@@ -532,7 +543,7 @@ func TestExecutionHandler_GetExecution_NotFound(t *testing.T) {
 	}
 
 	// Mock service to return error for not found
-	mockService.On("GetByID", mock.Anything, 999).Return(nil, fmt.Errorf("execution not found"))
+	mockService.On("GetByID", mock.Anything, 999).Return(nil, fmt.Errorf("failed to get execution: %w", apperrors.ErrExecutionNotFound))
 
 	// Create request
 	req := httptest.NewRequest("GET", "/api/v1/executions/999", nil)
@@ -565,7 +576,7 @@ func TestExecutionHandler_SendExecutions_ConflictError(t *testing.T) {
 	}
 
 	// Mock service to return duplicate batch error
-	mockService.On("Send", mock.Anything).Return(nil, fmt.Errorf("duplicate batch process already started"))
+	mockService.On("Send", mock.Anything).Return(nil, fmt.Errorf("failed to create batch history: %w", apperrors.ErrDuplicateBatch))
 
 	// Create request
 	req := httptest.NewRequest("POST", "/api/v1/executions/send", nil)
@@ -586,6 +597,115 @@ func TestExecutionHandler_SendExecutions_ConflictError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// newTestSQLExecutionHandler returns the real handler over a service whose database is mocked
+func newTestSQLExecutionHandler(t *testing.T) (*ExecutionHandler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	logger := zap.NewNop()
+	dbWrapper := &repository.DB{DB: sqlx.NewDb(db, "postgres")}
+	svc, err := service.NewExecutionService(
+		repository.NewExecutionRepository(dbWrapper, logger),
+		repository.NewBatchHistoryRepository(dbWrapper, logger),
+		service.NewTradeServiceClient("http://trade-service.invalid", logger),
+		logger,
+		&config.Config{CLICommand: []string{"true"}, OutputDir: t.TempDir(), TradeDateTimezone: "America/New_York"},
+	)
+	require.NoError(t, err)
+	return NewExecutionHandler(svc, logger), mock
+}
+
+func TestExecutionHandler_RetryBatch_SendInProgress(t *testing.T) {
+	h, mock := newTestSQLExecutionHandler(t)
+
+	end := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version"}).
+			AddRow(7, end, end.Add(-time.Hour), domain.BatchStatusFailed, 2))
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batches/7/retry", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	h.RetryBatch(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var response domain.ErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "batch process already in progress", response.Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionHandler_BulkLoadExecutions(t *testing.T) {
+	h, mock := newTestSQLExecutionHandler(t)
+
+	portfolioID := "PORTFOLIO123456789012ABC"
+	execution := domain.ExecutionPostDTO{
+		ExecutionServiceID: 123,
+		ExecutionStatus:    "FILLED",
+		TradeType:          "BUY",
+		Destination:        "NYSE",
+		SecurityID:         "12345678901234567890ABCD",
+		Ticker:             "AAPL",
+		Quantity:           domain.DecimalFromFloat(100),
+		ReceivedTimestamp:  time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		SentTimestamp:      time.Date(2024, 1, 15, 10, 1, 0, 0, time.UTC),
+		QuantityFilled:     domain.DecimalFromFloat(100),
+		TotalAmount:        domain.DecimalFromFloat(15000),
+		AveragePrice:       domain.DecimalFromFloat(150),
+		PortfolioID:        &portfolioID,
+	}
+	unresolved := execution
+	unresolved.ExecutionServiceID = 124
+	unresolved.PortfolioID = nil
+
+	tests := []struct {
+		name         string
+		body         interface{}
+		expectedCode int
+	}{
+		{name: "empty", body: []domain.ExecutionPostDTO{}, expectedCode: http.StatusBadRequest},
+		{name: "unresolved portfolio holds the load back", body: []domain.ExecutionPostDTO{execution, unresolved}, expectedCode: http.StatusBadRequest},
+		{name: "disabled", body: []domain.ExecutionPostDTO{execution}, expectedCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/executions/bulk-load", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+
+			h.BulkLoadExecutions(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+
+	t.Run("results name the executions that held the load back", func(t *testing.T) {
+		body, err := json.Marshal([]domain.ExecutionPostDTO{execution, unresolved})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/executions/bulk-load", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		h.BulkLoadExecutions(rr, req)
+
+		var response domain.BulkLoadResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, 1, response.Results[0].Index)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestExecutionHandler_CreateExecutions_MixedResults(t *testing.T) {
 	mockService := new(MockExecutionService)
 	logger := zap.NewNop()
@@ -604,10 +724,10 @@ func TestExecutionHandler_CreateExecutions_MixedResults(t *testing.T) {
 			Destination:        "NYSE",
 			SecurityID:         "12345678901234567890ABCD",
 			Ticker:             "AAPL",
-			Quantity:           100.5,
+			Quantity:           domain.DecimalFromFloat(100.5),
 			ReceivedTimestamp:  fixedTime,
 			SentTimestamp:      fixedTime.Add(1 * time.Minute),
-			AveragePrice:       150.0,
+			AveragePrice:       domain.DecimalFromFloat(150.0),
 		},
 	}
 
@@ -646,3 +766,268 @@ func TestExecutionHandler_CreateExecutions_MixedResults(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestParseExecutionFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expectError bool
+		check       func(t *testing.T, filter domain.ExecutionFilter)
+	}{
+		{
+			name:  "no parameters",
+			query: "",
+			check: func(t *testing.T, filter domain.ExecutionFilter) {
+				assert.False(t, filter.IncludeDeleted)
+				assert.Nil(t, filter.TradeDateFrom)
+				assert.Nil(t, filter.TradeDateTo)
+			},
+		},
+		{
+			name:  "date range and include deleted",
+			query: "tradeDateFrom=2024-01-01&tradeDateTo=2024-01-31&includeDeleted=true",
+			check: func(t *testing.T, filter domain.ExecutionFilter) {
+				assert.True(t, filter.IncludeDeleted)
+				require.NotNil(t, filter.TradeDateFrom)
+				require.NotNil(t, filter.TradeDateTo)
+				assert.Equal(t, "2024-01-01", filter.TradeDateFrom.Format("2006-01-02"))
+				assert.Equal(t, "2024-01-31", filter.TradeDateTo.Format("2006-01-02"))
+			},
+		},
+		{name: "invalid date", query: "tradeDateFrom=01/01/2024", expectError: true},
+		{name: "inverted range", query: "tradeDateFrom=2024-02-01&tradeDateTo=2024-01-01", expectError: true},
+		{name: "invalid includeDeleted", query: "includeDeleted=maybe", expectError: true},
+		{
+			name:  "ticker prefix",
+			query: "tickerPrefix=%20aap%20",
+			check: func(t *testing.T, filter domain.ExecutionFilter) {
+				assert.Equal(t, "aap", filter.TickerPrefix)
+			},
+		},
+		{name: "empty ticker prefix", query: "tickerPrefix=", expectError: true},
+		{name: "overlong ticker prefix", query: "tickerPrefix=ABCDEFGHIJKLMNOPQRSTU", expectError: true},
+		{
+			name:  "destination",
+			query: "destination=NYSE",
+			check: func(t *testing.T, filter domain.ExecutionFilter) {
+				assert.Equal(t, "NYSE", filter.Destination)
+			},
+		},
+		{name: "empty destination", query: "destination=%20", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/executions?"+tt.query, nil)
+
+			filter, err := parseExecutionFilter(req)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, filter)
+		})
+	}
+}
+
+func TestParseBulkDeleteFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expectError string
+	}{
+		{name: "filter with confirmation", query: "destination=NYSE&tradeDateFrom=2024-01-15&confirm=true"},
+		{name: "missing confirmation", query: "destination=NYSE", expectError: "confirm=true is required"},
+		{name: "confirmation false", query: "destination=NYSE&confirm=false", expectError: "confirm=true is required"},
+		{name: "no criteria", query: "confirm=true", expectError: "at least one of"},
+		{name: "include deleted", query: "destination=NYSE&includeDeleted=true&confirm=true", expectError: "includeDeleted is not supported"},
+		{name: "invalid filter", query: "tradeDateFrom=yesterday&confirm=true", expectError: "invalid tradeDateFrom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/executions?"+tt.query, nil)
+
+			filter, err := parseBulkDeleteFilter(req)
+
+			if tt.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "NYSE", filter.Destination)
+			assert.True(t, filter.HasCriteria())
+		})
+	}
+}
+
+func TestParseExecutionSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedSort  domain.ExecutionSort
+		expectedError string
+	}{
+		{name: "default", query: ""},
+		{name: "trade date ascending", query: "sortBy=tradeDate&sortOrder=asc", expectedSort: domain.ExecutionSort{Field: domain.SortByTradeDate, Ascending: true}},
+		{name: "quantity descending", query: "sortBy=quantity&sortOrder=DESC", expectedSort: domain.ExecutionSort{Field: domain.SortByQuantity}},
+		{name: "order only", query: "sortOrder=asc", expectedSort: domain.ExecutionSort{Ascending: true}},
+		{name: "column name rejected", query: "sortBy=trade_date", expectedError: "invalid sortBy parameter, expected one of: id, tradeDate, receivedTimestamp, quantity"},
+		{name: "invalid order", query: "sortOrder=up", expectedError: "invalid sortOrder parameter, expected asc or desc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/executions?"+tt.query, nil)
+
+			sort, err := parseExecutionSort(req)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSort, sort)
+		})
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedLimit  int
+		expectedOffset int
+		expectedError  string
+	}{
+		{name: "defaults", query: "", expectedLimit: 50, expectedOffset: 0},
+		{name: "explicit values", query: "limit=10&offset=20", expectedLimit: 10, expectedOffset: 20},
+		{name: "invalid limit", query: "limit=abc", expectedError: "invalid limit parameter"},
+		{name: "invalid offset", query: "offset=abc", expectedError: "invalid offset parameter"},
+		{name: "limit too large", query: "limit=1001", expectedError: "limit must be between 1 and 1000"},
+		{name: "limit zero", query: "limit=0", expectedError: "limit must be between 1 and 1000"},
+		{name: "negative offset", query: "offset=-1", expectedError: "offset must be non-negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+tt.query, nil)
+
+			limit, offset, err := parsePagination(req, 50, 1000)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLimit, limit)
+			assert.Equal(t, tt.expectedOffset, offset)
+		})
+	}
+}
+
+func TestParsePagination_ConfiguredLimits(t *testing.T) {
+	limit, _, err := parsePagination(httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil), 20, 200)
+	require.NoError(t, err)
+	assert.Equal(t, 20, limit)
+
+	limit, _, err = parsePagination(httptest.NewRequest(http.MethodGet, "/api/v1/executions?limit=200", nil), 20, 200)
+	require.NoError(t, err)
+	assert.Equal(t, 200, limit)
+
+	_, _, err = parsePagination(httptest.NewRequest(http.MethodGet, "/api/v1/executions?limit=201", nil), 20, 200)
+	assert.EqualError(t, err, "limit must be between 1 and 200")
+}
+
+func TestSendStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *domain.SendResponse
+		err      error
+		expected int
+	}{
+		{name: "success", response: &domain.SendResponse{Status: "success"}, expected: http.StatusOK},
+		{name: "CLI failure", response: &domain.SendResponse{Status: "error"}, err: errors.New("CLI invocation failed"), expected: http.StatusInternalServerError},
+		{
+			name:     "reconciliation abort",
+			response: &domain.SendResponse{Status: "error", MismatchedExecutionIDs: []int{2}},
+			err:      fmt.Errorf("failed to generate file: %w", apperrors.ErrReconciliationFailed),
+			expected: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sendStatusCode(tt.response, tt.err))
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 30, retryAfterSeconds(30*time.Second))
+	assert.Equal(t, 2, retryAfterSeconds(1500*time.Millisecond))
+	assert.Equal(t, 1, retryAfterSeconds(0))
+}
+
+func TestValidateReprocessRequest(t *testing.T) {
+	tooMany := make([]int, domain.MaxReprocessBatchSize+1)
+
+	tests := []struct {
+		name          string
+		request       domain.ReprocessRequest
+		expectedError string
+	}{
+		{name: "by IDs", request: domain.ReprocessRequest{ExecutionServiceIDs: []int{1, 2}}},
+		{name: "by reason", request: domain.ReprocessRequest{Reason: domain.SkipReasonPortfolioLookupFailed}},
+		{name: "by payloads", request: domain.ReprocessRequest{Executions: []domain.ExecutionPostDTO{{ExecutionServiceID: 1}}}},
+		{name: "empty", request: domain.ReprocessRequest{}, expectedError: "executionServiceIds, reason or executions is required"},
+		{
+			name:          "IDs and reason",
+			request:       domain.ReprocessRequest{ExecutionServiceIDs: []int{1}, Reason: domain.SkipReasonOpen},
+			expectedError: "specify only one of executionServiceIds, reason or executions",
+		},
+		{
+			name:          "reason and payloads",
+			request:       domain.ReprocessRequest{Reason: domain.SkipReasonOpen, Executions: []domain.ExecutionPostDTO{{ExecutionServiceID: 1}}},
+			expectedError: "specify only one of executionServiceIds, reason or executions",
+		},
+		{name: "too many IDs", request: domain.ReprocessRequest{ExecutionServiceIDs: tooMany}, expectedError: "reprocess size exceeds maximum of 100 executions"},
+		{
+			name:          "too many payloads",
+			request:       domain.ReprocessRequest{Executions: make([]domain.ExecutionPostDTO, domain.MaxReprocessBatchSize+1)},
+			expectedError: "reprocess size exceeds maximum of 100 executions",
+		},
+		{
+			name:          "final skip reason",
+			request:       domain.ReprocessRequest{Reason: domain.SkipReasonAlreadyExists},
+			expectedError: `reason must be "execution_open" or "portfolio_lookup_failed"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReprocessRequest(tt.request)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBatchCreateStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusCreated, batchCreateStatusCode(&domain.BatchCreateResponse{ProcessedCount: 1, SkippedCount: 1}))
+	assert.Equal(t, http.StatusMultiStatus, batchCreateStatusCode(&domain.BatchCreateResponse{ProcessedCount: 1, ErrorCount: 1}))
+	assert.Equal(t, http.StatusBadRequest, batchCreateStatusCode(&domain.BatchCreateResponse{ErrorCount: 2}))
+}
+
+func TestValidateBatchSize(t *testing.T) {
+	assert.EqualError(t, validateBatchSize(nil), "no executions provided")
+	assert.NoError(t, validateBatchSize(make([]domain.ExecutionPostDTO, maxCreateBatchSize)))
+	assert.EqualError(t, validateBatchSize(make([]domain.ExecutionPostDTO, maxCreateBatchSize+1)),
+		"batch size exceeds maximum of 100 executions")
+}
@@ -1,8 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,18 +22,81 @@ import (
 
 // HealthHandler handles health check endpoints
 type HealthHandler struct {
-	db     *repository.DB
-	logger *zap.Logger
+	db        *repository.DB
+	logger    *zap.Logger
+	outputDir string
+	cliCheck  CLIChecker
+
+	poolWaitThreshold int64
+	mu                sync.Mutex
+	lastWaitCount     int64
+
+	// Readiness is held back until warmupDelay has passed since startedAt and
+	// warmupMinChecks probes have passed; once warmed up it stays so
+	now              func() time.Time
+	startedAt        time.Time
+	warmupDelay      time.Duration
+	warmupMinChecks  int
+	successfulChecks int
+	warmedUp         bool
+
+	// shuttingDown fails readiness from the moment shutdown begins
+	shuttingDown atomic.Bool
+}
+
+// CLIChecker verifies the Portfolio Accounting CLI can be invoked
+type CLIChecker interface {
+	CheckCLI(ctx context.Context) error
 }
 
+// poolSaturationWarnRatio is the in-use/max connection ratio reported as a warning
+const poolSaturationWarnRatio = 0.8
+
 // NewHealthHandler creates a new health handler
 func NewHealthHandler(db *repository.DB, logger *zap.Logger) *HealthHandler {
 	return &HealthHandler{
-		db:     db,
-		logger: logger,
+		db:        db,
+		logger:    logger,
+		now:       time.Now,
+		startedAt: time.Now(),
+		warmedUp:  true,
 	}
 }
 
+// SetOutputDirCheck enables the readiness check that dir is writable; empty disables it
+func (h *HealthHandler) SetOutputDirCheck(dir string) {
+	h.outputDir = dir
+}
+
+// SetCLICheck enables the readiness check that the Portfolio Accounting CLI can be
+// invoked; nil disables it
+func (h *HealthHandler) SetCLICheck(checker CLIChecker) {
+	h.cliCheck = checker
+}
+
+// SetPoolWaitThreshold fails readiness when more than threshold connection waits occur
+// between probes; zero only reports pool usage
+func (h *HealthHandler) SetPoolWaitThreshold(threshold int64) {
+	h.poolWaitThreshold = threshold
+}
+
+// SetWarmup holds readiness at 503 until delay has passed since the handler was created
+// and minSuccessfulChecks probes have found every dependency healthy, giving the
+// connection pool and dependencies time to settle. Zero values disable either gate.
+func (h *HealthHandler) SetWarmup(delay time.Duration, minSuccessfulChecks int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.warmupDelay = delay
+	h.warmupMinChecks = minSuccessfulChecks
+	h.warmedUp = delay <= 0 && minSuccessfulChecks <= 0
+}
+
+// StartShutdown makes readiness fail from now on so the load balancer stops routing to
+// this instance, while liveness and in-flight requests are unaffected
+func (h *HealthHandler) StartShutdown() {
+	h.shuttingDown.Store(true)
+}
+
 // Liveness handles the liveness probe endpoint
 func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
 	response := domain.HealthResponse{
@@ -42,6 +114,15 @@ func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
 
 // Readiness handles the readiness probe endpoint
 func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	// A shutting-down instance is never ready, however healthy its dependencies
+	if h.shuttingDown.Load() {
+		h.writeReadiness(w, http.StatusServiceUnavailable, domain.HealthResponse{
+			Status:    "shutting_down",
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
 	checks := make(map[string]string)
 	status := "ok"
 	statusCode := http.StatusOK
@@ -54,14 +135,58 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error("Database health check failed", zap.Error(err))
 	} else {
 		checks["database"] = "healthy"
+		checks["migrations"] = h.migrationStatus(r.Context())
 	}
 
-	response := domain.HealthResponse{
+	// Report connection pool usage; saturation only fails readiness when a wait threshold is set
+	poolStatus, poolStarved := h.poolStatus()
+	checks["db_pool"] = poolStatus
+	if poolStarved {
+		status = "error"
+		statusCode = http.StatusServiceUnavailable
+		h.logger.Error("Database connection pool starved", zap.String("db_pool", poolStatus))
+	}
+
+	// Check the output directory accepts new files
+	if h.outputDir != "" {
+		if err := checkDirWritable(h.outputDir); err != nil {
+			checks["output_dir"] = "unhealthy: " + err.Error()
+			status = "error"
+			statusCode = http.StatusServiceUnavailable
+			h.logger.Error("Output directory health check failed", zap.String("output_dir", h.outputDir), zap.Error(err))
+		} else {
+			checks["output_dir"] = "healthy"
+		}
+	}
+
+	// Check the CLI binary or image can be started
+	if h.cliCheck != nil {
+		if err := h.cliCheck.CheckCLI(r.Context()); err != nil {
+			checks["cli"] = "unhealthy: " + err.Error()
+			status = "error"
+			statusCode = http.StatusServiceUnavailable
+			h.logger.Error("CLI health check failed", zap.Error(err))
+		} else {
+			checks["cli"] = "healthy"
+		}
+	}
+
+	// Until warmed up, report not ready even when every dependency is healthy
+	if warmupStatus, warming := h.warmupStatus(statusCode == http.StatusOK); warming {
+		checks["warmup"] = warmupStatus
+		status = "error"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	h.writeReadiness(w, statusCode, domain.HealthResponse{
 		Status:    status,
 		Timestamp: time.Now(),
 		Checks:    checks,
-	}
+	})
+}
 
+// writeReadiness writes a readiness response with the given status code
+func (h *HealthHandler) writeReadiness(w http.ResponseWriter, statusCode int, response domain.HealthResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -69,3 +194,101 @@ func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error("Failed to encode readiness response", zap.Error(err))
 	}
 }
+
+// warmupStatus counts a healthy probe towards warm-up and reports whether the service
+// is still warming up, with what it is waiting for
+func (h *HealthHandler) warmupStatus(healthy bool) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.warmedUp {
+		return "", false
+	}
+	if healthy {
+		h.successfulChecks++
+	}
+
+	remaining := h.warmupDelay - h.now().Sub(h.startedAt)
+	if remaining <= 0 && h.successfulChecks >= h.warmupMinChecks {
+		h.warmedUp = true
+		h.logger.Info("Readiness warm-up complete", zap.Int("successful_checks", h.successfulChecks))
+		return "", false
+	}
+	return fmt.Sprintf("warming up: %s remaining, %d of %d successful checks",
+		max(remaining, 0).Round(time.Second), h.successfulChecks, h.warmupMinChecks), true
+}
+
+// checkDirWritable creates and removes a small temp file in dir, or in its nearest
+// existing ancestor when dir doesn't exist yet
+func checkDirWritable(dir string) error {
+	// A missing directory is created on the first Send, so the nearest existing
+	// ancestor only has to accept it
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	file, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return fmt.Errorf("output directory not writable: %w", err)
+	}
+	name := file.Name()
+
+	_, writeErr := file.Write([]byte("ok"))
+	closeErr := file.Close()
+	removeErr := os.Remove(name)
+
+	if writeErr != nil {
+		return fmt.Errorf("output directory not writable: %w", writeErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("output directory not writable: %w", closeErr)
+	}
+	if removeErr != nil {
+		return fmt.Errorf("failed to remove readiness probe file: %w", removeErr)
+	}
+	return nil
+}
+
+// migrationStatus describes the applied schema migration; it never fails readiness
+func (h *HealthHandler) migrationStatus(ctx context.Context) string {
+	version, dirty, err := h.db.MigrationVersion(ctx)
+	if err != nil {
+		return "unknown: " + err.Error()
+	}
+	if dirty {
+		return fmt.Sprintf("warning: version %d is dirty", version)
+	}
+	return fmt.Sprintf("version %d", version)
+}
+
+// poolStatus describes connection pool usage and reports whether connection waits
+// since the previous probe exceeded the configured threshold
+func (h *HealthHandler) poolStatus() (string, bool) {
+	stats := h.db.Stats()
+
+	h.mu.Lock()
+	waits := stats.WaitCount - h.lastWaitCount
+	h.lastWaitCount = stats.WaitCount
+	h.mu.Unlock()
+
+	maxOpen := "unlimited"
+	if stats.MaxOpenConnections > 0 {
+		maxOpen = strconv.Itoa(stats.MaxOpenConnections)
+	}
+	detail := fmt.Sprintf("in_use=%d max=%s wait_count=%d", stats.InUse, maxOpen, stats.WaitCount)
+
+	if h.poolWaitThreshold > 0 && waits > h.poolWaitThreshold {
+		return fmt.Sprintf("unhealthy: %d waits since last check, %s", waits, detail), true
+	}
+	if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= poolSaturationWarnRatio*float64(stats.MaxOpenConnections) {
+		return "warning: near saturation, " + detail, false
+	}
+	return "healthy: " + detail, false
+}
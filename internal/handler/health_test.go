@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/repository"
+)
+
+func newTestHealthHandler(t *testing.T) *HealthHandler {
+	t.Helper()
+	return newTestHealthHandlerWithProbes(t, 1)
+}
+
+// newTestHealthHandlerWithProbes expects probes healthy readiness checks of the database
+func newTestHealthHandlerWithProbes(t *testing.T, probes int) *HealthHandler {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	for i := 0; i < probes; i++ {
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+		mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(5, false))
+	}
+
+	db.SetMaxOpenConns(10)
+	return NewHealthHandler(&repository.DB{DB: sqlx.NewDb(db, "postgres")}, zap.NewNop())
+}
+
+func doReadiness(t *testing.T, h *HealthHandler) (int, domain.HealthResponse) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var response domain.HealthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	return w.Code, response
+}
+
+// unwritableDir returns a directory path that can't be created, as it sits under a file
+func unwritableDir(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	return filepath.Join(file, "output")
+}
+
+func TestHealthHandler_Readiness_OutputDirWritable(t *testing.T) {
+	h := newTestHealthHandler(t)
+	dir := t.TempDir()
+	h.SetOutputDirCheck(dir)
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", response.Checks["output_dir"])
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probe file should be removed")
+}
+
+func TestHealthHandler_Readiness_OutputDirMissing(t *testing.T) {
+	h := newTestHealthHandler(t)
+	dir := filepath.Join(t.TempDir(), "missing", "nested")
+	h.SetOutputDirCheck(dir)
+
+	code, response := doReadiness(t, h)
+
+	// The directory is created on the first Send, so a writable parent is enough
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", response.Checks["output_dir"])
+	assert.NoDirExists(t, dir, "readiness must not create the directory")
+}
+
+func TestHealthHandler_Readiness_OutputDirNotWritable(t *testing.T) {
+	h := newTestHealthHandler(t)
+	h.SetOutputDirCheck(unwritableDir(t))
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "error", response.Status)
+	assert.Contains(t, response.Checks["output_dir"], "unhealthy")
+	assert.Equal(t, "healthy", response.Checks["database"])
+}
+
+func TestHealthHandler_Readiness_OutputDirCheckDisabled(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, response.Checks, "output_dir")
+}
+
+func TestHealthHandler_Readiness_ReportsMigrationsAndPool(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "version 5", response.Checks["migrations"])
+	assert.Equal(t, "healthy: in_use=0 max=10 wait_count=0", response.Checks["db_pool"])
+}
+
+func TestHealthHandler_Readiness_PoolStarved(t *testing.T) {
+	h := newTestHealthHandler(t)
+	h.SetPoolWaitThreshold(3)
+	// Pretend the pool's wait count grew by 5 since the previous probe
+	h.lastWaitCount = -5
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, response.Checks["db_pool"], "unhealthy: 5 waits since last check")
+
+	// Without further waits the next probe recovers
+	_, response = doReadiness(t, h)
+	assert.True(t, strings.HasPrefix(response.Checks["db_pool"], "healthy:"), response.Checks["db_pool"])
+}
+
+type stubCLIChecker struct {
+	err error
+}
+
+func (s stubCLIChecker) CheckCLI(ctx context.Context) error {
+	return s.err
+}
+
+func TestHealthHandler_Readiness_CLICheck(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	_, response := doReadiness(t, h)
+	assert.NotContains(t, response.Checks, "cli")
+
+	h = newTestHealthHandler(t)
+	h.SetCLICheck(stubCLIChecker{})
+	code, response := doReadiness(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", response.Checks["cli"])
+
+	h = newTestHealthHandler(t)
+	h.SetCLICheck(stubCLIChecker{err: errors.New("CLI health check timed out after 5s")})
+	code, response = doReadiness(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "error", response.Status)
+	assert.Equal(t, "unhealthy: CLI health check timed out after 5s", response.Checks["cli"])
+}
+
+func TestHealthHandler_Readiness_Warmup(t *testing.T) {
+	h := newTestHealthHandlerWithProbes(t, 3)
+	now := h.startedAt
+	h.now = func() time.Time { return now }
+	h.SetWarmup(10*time.Second, 2)
+
+	// Healthy, but still inside the warm-up delay
+	code, response := doReadiness(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "healthy", response.Checks["database"])
+	assert.Equal(t, "warming up: 10s remaining, 1 of 2 successful checks", response.Checks["warmup"])
+
+	// The delay has passed and this is the second successful check
+	now = now.Add(10 * time.Second)
+	code, response = doReadiness(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, response.Checks, "warmup")
+
+	// Once warmed up, normal readiness applies
+	code, _ = doReadiness(t, h)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthHandler_Readiness_WarmupCountsOnlyHealthyChecks(t *testing.T) {
+	h := newTestHealthHandler(t)
+	h.SetWarmup(0, 1)
+	h.SetOutputDirCheck(unwritableDir(t))
+
+	code, response := doReadiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "warming up: 0s remaining, 0 of 1 successful checks", response.Checks["warmup"])
+}
+
+func TestHealthHandler_Readiness_ShuttingDown(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	code, _ := doReadiness(t, h)
+	assert.Equal(t, http.StatusOK, code)
+
+	h.StartShutdown()
+
+	// Readiness fails without checking dependencies, while liveness still passes
+	code, response := doReadiness(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting_down", response.Status)
+	assert.Empty(t, response.Checks)
+
+	w := httptest.NewRecorder()
+	h.Liveness(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// paginationLinks builds an RFC 8288 Link header value with first, prev, next and last
+// relations for an offset/limit page of totalCount items. Links are relative to the
+// request and keep its other query parameters, such as filters. prev is omitted on the
+// first page and next on the last.
+func paginationLinks(requestURL *url.URL, limit, offset, totalCount int) string {
+	lastOffset := 0
+	if totalCount > 0 {
+		lastOffset = (totalCount - 1) / limit * limit
+	}
+
+	links := []string{paginationLink(requestURL, limit, 0, "first")}
+	if offset > 0 {
+		prevOffset := offset - limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		links = append(links, paginationLink(requestURL, limit, prevOffset, "prev"))
+	}
+	if offset+limit < totalCount {
+		links = append(links, paginationLink(requestURL, limit, offset+limit, "next"))
+	}
+	links = append(links, paginationLink(requestURL, limit, lastOffset, "last"))
+
+	return strings.Join(links, ", ")
+}
+
+// paginationLink formats one Link header entry pointing at the page starting at offset
+func paginationLink(requestURL *url.URL, limit, offset int, rel string) string {
+	query := requestURL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+
+	target := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel)
+}
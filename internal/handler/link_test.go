@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginationLinks(t *testing.T) {
+	requestURL, err := url.Parse("/api/v1/executions?limit=10&offset=20&tradeDateFrom=2024-01-01")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		offset     int
+		totalCount int
+		expected   string
+	}{
+		{
+			name:       "middle page",
+			offset:     20,
+			totalCount: 45,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=10&tradeDateFrom=2024-01-01>; rel="prev", ` +
+				`</api/v1/executions?limit=10&offset=30&tradeDateFrom=2024-01-01>; rel="next", ` +
+				`</api/v1/executions?limit=10&offset=40&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+		{
+			name:       "first page omits prev",
+			offset:     0,
+			totalCount: 45,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=10&tradeDateFrom=2024-01-01>; rel="next", ` +
+				`</api/v1/executions?limit=10&offset=40&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+		{
+			name:       "last page omits next",
+			offset:     40,
+			totalCount: 45,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=30&tradeDateFrom=2024-01-01>; rel="prev", ` +
+				`</api/v1/executions?limit=10&offset=40&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+		{
+			name:       "unaligned offset clamps prev to the start",
+			offset:     5,
+			totalCount: 45,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="prev", ` +
+				`</api/v1/executions?limit=10&offset=15&tradeDateFrom=2024-01-01>; rel="next", ` +
+				`</api/v1/executions?limit=10&offset=40&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+		{
+			name:       "empty result",
+			offset:     0,
+			totalCount: 0,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+		{
+			name:       "exact multiple of the page size",
+			offset:     0,
+			totalCount: 20,
+			expected: `</api/v1/executions?limit=10&offset=0&tradeDateFrom=2024-01-01>; rel="first", ` +
+				`</api/v1/executions?limit=10&offset=10&tradeDateFrom=2024-01-01>; rel="next", ` +
+				`</api/v1/executions?limit=10&offset=10&tradeDateFrom=2024-01-01>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, paginationLinks(requestURL, 10, tt.offset, tt.totalCount))
+		})
+	}
+}
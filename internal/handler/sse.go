@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// streamKeepAliveInterval is how often an idle stream sends a comment, so proxies and
+// load balancers don't close it
+const streamKeepAliveInterval = 15 * time.Second
+
+// writeSSEEvent writes one server-sent event with the given id, event type and data
+// encoded as single-line JSON
+func writeSSEEvent(w io.Writer, id int, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
+
+// streamExecutions writes each execution from events as an "execution" event until
+// ctx is done, events is closed or a write fails. encode picks the JSON shape of each
+// execution.
+func streamExecutions(
+	ctx context.Context,
+	w http.ResponseWriter,
+	events <-chan domain.ExecutionDTO,
+	keepAlive time.Duration,
+	encode func(domain.ExecutionDTO) interface{},
+) error {
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case execution, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeSSEEvent(w, execution.ID, "execution", encode(execution)); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+	}
+}
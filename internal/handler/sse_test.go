@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestWriteSSEEvent(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeSSEEvent(&buf, 5, "execution", map[string]string{"ticker": "AAPL"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "id: 5\nevent: execution\ndata: {\"ticker\":\"AAPL\"}\n\n", buf.String())
+}
+
+func TestStreamExecutions(t *testing.T) {
+	events := make(chan domain.ExecutionDTO, 2)
+	events <- domain.ExecutionDTO{ID: 1, Ticker: "AAPL"}
+	events <- domain.ExecutionDTO{ID: 2, Ticker: "MSFT"}
+	close(events)
+	rec := httptest.NewRecorder()
+
+	err := streamExecutions(context.Background(), rec, events, time.Hour,
+		func(execution domain.ExecutionDTO) interface{} { return map[string]string{"ticker": execution.Ticker} })
+
+	// A closed channel, as when the subscriber is dropped, ends the stream cleanly
+	require.NoError(t, err)
+	assert.True(t, rec.Flushed)
+	assert.Equal(t,
+		"id: 1\nevent: execution\ndata: {\"ticker\":\"AAPL\"}\n\n"+
+			"id: 2\nevent: execution\ndata: {\"ticker\":\"MSFT\"}\n\n",
+		rec.Body.String())
+}
+
+func TestStreamExecutions_KeepAliveAndDisconnect(t *testing.T) {
+	events := make(chan domain.ExecutionDTO)
+	rec := httptest.NewRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := streamExecutions(ctx, rec, events, 10*time.Millisecond,
+		func(execution domain.ExecutionDTO) interface{} { return execution })
+
+	// The client going away ends the stream without an error
+	require.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), ": keep-alive\n\n")
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// BearerAuth returns a middleware that rejects requests whose Authorization header is
+// not "Bearer <token>" with a 401 JSON ErrorResponse. The comparison is constant-time.
+func BearerAuth(token string, logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Warn("Rejected unauthenticated request",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_addr", r.RemoteAddr))
+
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := json.NewEncoder(w).Encode(domain.ErrorResponse{
+				Message:   "unauthorized",
+				Status:    http.StatusUnauthorized,
+				Timestamp: domain.GetCurrentTimestamp(),
+			}); err != nil {
+				logger.Error("Failed to encode unauthorized response", zap.Error(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestBearerAuth(t *testing.T) {
+	handler := BearerAuth("s3cret", zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		authorization string
+		expected      int
+	}{
+		{authorization: "Bearer s3cret", expected: http.StatusOK},
+		{authorization: "", expected: http.StatusUnauthorized},
+		{authorization: "Bearer wrong", expected: http.StatusUnauthorized},
+		{authorization: "Basic s3cret", expected: http.StatusUnauthorized},
+		{authorization: "Bearer s3cret2", expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expected, w.Code, tt.authorization)
+		if tt.expected == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			var response domain.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, http.StatusUnauthorized, response.Status)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIPResolver finds the client IP of a request. X-Forwarded-For and X-Real-IP are
+// only believed when the direct peer is a trusted proxy, since any client can send them.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver creates a resolver trusting the given proxy networks; with none,
+// the client IP is always the direct peer
+func NewClientIPResolver(trusted []*net.IPNet) *ClientIPResolver {
+	return &ClientIPResolver{trusted: trusted}
+}
+
+// ClientIP returns the client IP of r. Behind trusted proxies it is the rightmost
+// X-Forwarded-For address that is not itself a trusted proxy: addresses to its left were
+// supplied by the client and could be forged. X-Real-IP is used when X-Forwarded-For is
+// absent. A nil resolver returns the direct peer.
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	peer := remoteIP(r.RemoteAddr)
+	if c == nil || !c.isTrusted(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// A malformed hop ends the chain we can vouch for
+				break
+			}
+			client = hop
+			if !c.isTrusted(hop) {
+				break
+			}
+		}
+		if client != "" {
+			return client
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return peer
+}
+
+// isTrusted reports whether ip falls in a trusted proxy network
+func (c *ClientIPResolver) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP strips the port from a RemoteAddr; an address without one is returned as is
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
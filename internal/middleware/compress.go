@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipWriterPool reuses gzip writers, which allocate sizeable compression state
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// Gzip returns a middleware that gzips responses of at least minSize bytes for clients
+// whose Accept-Encoding allows it. Smaller responses are sent as-is with their
+// Content-Length, since compressing them costs more than it saves. Responses are held
+// back until minSize bytes are written, so streaming endpoints must not use it.
+func Gzip(minSize int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Not deferred: after a panic the buffered response is dropped so Recoverer
+			// can still answer
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+			next.ServeHTTP(gw, r)
+			gw.close()
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, honoring q=0
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, wildcardQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip":
+			gzipQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return wildcardQ > 0
+}
+
+// gzipResponseWriter buffers the start of a response until it reaches minSize, then
+// either compresses it or, if the response ends first, writes it uncompressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize     int
+	code        int
+	buf         []byte
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+// WriteHeader records the status code; headers are sent once the encoding is decided
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write buffers p until the response is large enough to compress
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(p)
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start sends the headers and buffered body, compressed unless the response already
+// has an encoding or a status that carries no body. The response may not be complete,
+// so the buffered length is never sent as its Content-Length.
+func (w *gzipResponseWriter) start() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return w.writeUncompressed(false)
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", "gzip")
+	w.ResponseWriter.WriteHeader(w.code)
+
+	w.gz = gzipWriterPool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.gz.Write(buf)
+	return err
+}
+
+// writeUncompressed sends the headers and buffered body as-is. Only a complete response
+// gets its Content-Length from the buffer; a started or flushed one may still grow.
+func (w *gzipResponseWriter) writeUncompressed(complete bool) error {
+	w.passthrough = true
+	if complete && len(w.buf) > 0 && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(w.buf)))
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush sends what has been written so far, compressing it if it is large enough
+func (w *gzipResponseWriter) Flush() {
+	if w.gz == nil && !w.passthrough && w.code != 0 {
+		if len(w.buf) >= w.minSize {
+			_ = w.start()
+		} else {
+			_ = w.writeUncompressed(false)
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the response: it completes the gzip stream, or writes a response
+// that ended below minSize uncompressed
+func (w *gzipResponseWriter) close() {
+	switch {
+	case w.gz != nil:
+		_ = w.gz.Close()
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	case !w.passthrough && w.code != 0:
+		_ = w.writeUncompressed(true)
+	}
+}
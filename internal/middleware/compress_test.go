@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// largeListHandler serves a full 1000-item executions page
+func largeListHandler(w http.ResponseWriter, r *http.Request) {
+	executions := make([]domain.ExecutionDTO, 1000)
+	for i := range executions {
+		executions[i] = domain.ExecutionDTO{ID: i + 1, Ticker: "AAPL", Destination: "NYSE", TradeType: "BUY"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(domain.ExecutionListResponse{
+		Executions: executions,
+		Pagination: domain.NewPaginationInfo(len(executions), 1000, 0),
+	})
+}
+
+func TestGzip_CompressesLargeListResponse(t *testing.T) {
+	handler := Gzip(1024)(http.HandlerFunc(largeListHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions?limit=1000", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	compressedSize := w.Body.Len()
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	var response domain.ExecutionListResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Len(t, response.Executions, 1000)
+	assert.Less(t, compressedSize, len(body)/5)
+}
+
+func TestGzip_NotRequested(t *testing.T) {
+	handler := Gzip(1024)(http.HandlerFunc(largeListHandler))
+
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0", "*;q=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"), acceptEncoding)
+		assert.True(t, json.Valid(w.Body.Bytes()), acceptEncoding)
+	}
+}
+
+func TestGzip_SmallResponseUncompressed(t *testing.T) {
+	handler := Gzip(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/executions", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, strconv.Itoa(len(`{"id":1}`)), w.Header().Get("Content-Length"))
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+}
+
+func TestGzip_FlushBelowMinSizeHasNoContentLength(t *testing.T) {
+	handler := Gzip(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("first,"))
+		http.NewResponseController(w).Flush()
+		_, _ = w.Write([]byte("second"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	// A Content-Length of the flushed part would cut the response short
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, "first,second", w.Body.String())
+}
+
+func TestGzip_AlreadyEncodedHasNoPartialContentLength(t *testing.T) {
+	chunk := strings.Repeat("x", 1024)
+	handler := Gzip(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		_, _ = w.Write([]byte(chunk))
+		_, _ = w.Write([]byte(chunk))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, 2*len(chunk), w.Body.Len())
+}
+
+func TestGzip_NoBody(t *testing.T) {
+	handler := Gzip(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"GZIP":                true,
+		"deflate, gzip;q=0.5": true,
+		"gzip;q=0":            false,
+		"*":                   true,
+		"*;q=0":               false,
+		"gzip;q=0, *":         false,
+		"br, *;q=0.1":         true,
+	}
+	for acceptEncoding, expected := range tests {
+		assert.Equal(t, expected, acceptsGzip(acceptEncoding), acceptEncoding)
+	}
+}
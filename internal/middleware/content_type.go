@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// RequireJSON returns a middleware that rejects POST, PUT and PATCH requests whose
+// Content-Type is not application/json, with any parameters such as charset, with a 415
+// JSON ErrorResponse. Other methods pass through unchecked.
+func RequireJSON(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			contentType := r.Header.Get("Content-Type")
+			if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/json" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Warn("Rejected request with unsupported content type",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("content_type", contentType))
+
+			w.Header().Set("Accept", "application/json")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			if err := json.NewEncoder(w).Encode(domain.ErrorResponse{
+				Message:   "Content-Type must be application/json",
+				Status:    http.StatusUnsupportedMediaType,
+				Timestamp: domain.GetCurrentTimestamp(),
+			}); err != nil {
+				logger.Error("Failed to encode unsupported media type response", zap.Error(err))
+			}
+		})
+	}
+}
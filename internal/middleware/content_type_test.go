@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestRequireJSON(t *testing.T) {
+	handler := RequireJSON(zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	tests := []struct {
+		method      string
+		contentType string
+		expected    int
+	}{
+		{method: http.MethodPost, contentType: "application/json", expected: http.StatusCreated},
+		{method: http.MethodPost, contentType: "application/json; charset=utf-8", expected: http.StatusCreated},
+		{method: http.MethodPut, contentType: "Application/JSON", expected: http.StatusCreated},
+		{method: http.MethodPost, contentType: "text/plain", expected: http.StatusUnsupportedMediaType},
+		{method: http.MethodPost, contentType: "", expected: http.StatusUnsupportedMediaType},
+		{method: http.MethodPost, contentType: "application/json-seq", expected: http.StatusUnsupportedMediaType},
+		{method: http.MethodPatch, contentType: "application/x-www-form-urlencoded", expected: http.StatusUnsupportedMediaType},
+		{method: http.MethodGet, contentType: "", expected: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/v1/executions", strings.NewReader(`[]`))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expected, w.Code, "%s %q", tt.method, tt.contentType)
+		if tt.expected == http.StatusUnsupportedMediaType {
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var response domain.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, http.StatusUnsupportedMediaType, response.Status)
+			assert.Equal(t, "Content-Type must be application/json", response.Message)
+		}
+	}
+}
@@ -1,36 +1,49 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 )
 
-// Logger returns a middleware that logs HTTP requests
-func Logger(logger *zap.Logger) func(next http.Handler) http.Handler {
+// Logger returns a middleware that logs HTTP requests, with the client IP found by
+// clientIP. Requests to probePaths, such as health and metrics endpoints, are only
+// logged when they do not succeed.
+func Logger(logger *zap.Logger, clientIP *ClientIPResolver, probePaths ...string) func(next http.Handler) http.Handler {
+	probes := make(map[string]bool, len(probePaths))
+	for _, path := range probePaths {
+		probes[path] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			probe := probes[r.URL.Path]
 
 			// Create a wrapped response writer to capture status code
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-			// Get request ID from context
-			requestID := middleware.GetReqID(r.Context())
-
 			// Create logger with request context
 			reqLogger := logger.With(
-				zap.String("request_id", requestID),
+				zap.String("request_id", observability.GetRequestID(r.Context())),
+				zap.String("correlation_id", observability.GetCorrelationID(r.Context())),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("remote_addr", r.RemoteAddr),
+				zap.String("client_ip", clientIP.ClientIP(r)),
 				zap.String("user_agent", r.UserAgent()),
 			)
 
 			// Log request start
-			reqLogger.Info("Request started")
+			if !probe {
+				reqLogger.Info("Request started")
+			}
 
 			// Process request
 			next.ServeHTTP(ww, r)
@@ -38,12 +51,33 @@ func Logger(logger *zap.Logger) func(next http.Handler) http.Handler {
 			// Calculate duration
 			duration := time.Since(start)
 
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if probe && status < 300 {
+				return
+			}
+
 			// Log request completion
 			reqLogger.Info("Request completed",
-				zap.Int("status", ww.Status()),
+				zap.String("route", routePattern(r)),
+				zap.Int("status", status),
+				zap.String("status_class", fmt.Sprintf("%dxx", status/100)),
 				zap.Int("bytes", ww.BytesWritten()),
 				zap.Duration("duration", duration),
 			)
 		})
 	}
 }
+
+// routePattern returns the matched chi route pattern, such as /api/v1/executions/{id},
+// so logs group by route rather than raw path; unmatched requests report the path
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_RouteAndStatusClass(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	r := chi.NewRouter()
+	r.Use(Logger(zap.New(core), nil, "/healthz"))
+	r.Get("/api/v1/executions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/executions/42", nil))
+
+	completed := logs.FilterMessage("Request completed").All()
+	require.Len(t, completed, 1)
+	fields := completed[0].ContextMap()
+	assert.Equal(t, "/api/v1/executions/{id}", fields["route"])
+	assert.Equal(t, "/api/v1/executions/42", fields["path"])
+	assert.Equal(t, "4xx", fields["status_class"])
+	assert.Equal(t, 1, logs.FilterMessage("Request started").Len())
+}
+
+func TestLogger_SuppressesSuccessfulProbes(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	healthy := true
+	r := chi.NewRouter()
+	r.Use(Logger(zap.New(core), nil, "/healthz", "/readyz"))
+	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Zero(t, logs.Len(), "successful probes are not logged")
+
+	healthy = false
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	completed := logs.FilterMessage("Request completed").All()
+	require.Len(t, completed, 1)
+	assert.Equal(t, "5xx", completed[0].ContextMap()["status_class"])
+}
+
+func TestLogger_ClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	core, logs := observer.New(zap.InfoLevel)
+	r := chi.NewRouter()
+	r.Use(Logger(zap.New(core), NewClientIPResolver([]*net.IPNet{proxies})))
+	r.Get("/api/v1/executions", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	completed := logs.FilterMessage("Request completed").All()
+	require.Len(t, completed, 1)
+	fields := completed[0].ContextMap()
+	assert.Equal(t, "198.51.100.1", fields["client_ip"])
+	assert.Equal(t, "10.1.2.3:5000", fields["remote_addr"])
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 )
 
 var (
@@ -42,7 +44,8 @@ func init() {
 	prometheus.MustRegister(httpRequestsInFlight)
 }
 
-// Metrics returns a middleware that records Prometheus metrics
+// Metrics returns a middleware that records Prometheus metrics. Request durations carry
+// the trace ID of a sampled request span as an exemplar.
 func Metrics() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -63,12 +66,14 @@ func Metrics() func(next http.Handler) http.Handler {
 			status := strconv.Itoa(ww.Status())
 
 			httpRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
-			httpRequestDuration.WithLabelValues(method, endpoint, status).Observe(duration)
+			observability.ObserveWithTraceExemplar(r.Context(), httpRequestDuration.WithLabelValues(method, endpoint, status), duration)
 		})
 	}
 }
 
-// MetricsHandler returns a handler for the /metrics endpoint
+// MetricsHandler returns a handler for the /metrics endpoint. It serves the OpenMetrics
+// format, which includes exemplars, to scrapers that ask for it.
 func MetricsHandler() http.Handler {
-	return promhttp.Handler()
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 }
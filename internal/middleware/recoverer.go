@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// Recoverer returns a middleware that recovers from handler panics, logs them with
+// the stack trace and request identifiers, and responds with a JSON ErrorResponse
+// carrying the correlation ID. It must run after the correlation ID middleware.
+func Recoverer(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// ErrAbortHandler deliberately aborts the response; let net/http handle it
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				ctx := r.Context()
+				correlationID := observability.GetCorrelationID(ctx)
+				fields := []zap.Field{
+					zap.Any("panic", rec),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("request_id", observability.GetRequestID(ctx)),
+					zap.String("correlation_id", correlationID),
+					zap.ByteString("stack", debug.Stack()),
+				}
+				if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+					fields = append(fields,
+						zap.String("trace_id", spanContext.TraceID().String()),
+						zap.String("span_id", spanContext.SpanID().String()))
+				}
+				logger.Error("Recovered from panic", fields...)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				if err := json.NewEncoder(w).Encode(domain.ErrorResponse{
+					Message:       "internal server error",
+					Status:        http.StatusInternalServerError,
+					Timestamp:     domain.GetCurrentTimestamp(),
+					CorrelationID: correlationID,
+				}); err != nil {
+					logger.Error("Failed to encode panic response", zap.Error(err))
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
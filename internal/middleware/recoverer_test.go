@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+func TestRecoverer(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	structuredLogger, err := observability.NewStructuredLogger(observability.LoggingConfig{Level: "error"})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(structuredLogger.CorrelationIDMiddleware())
+	r.Use(Recoverer(zap.New(core)))
+	r.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("something broke")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	req.Header.Set("X-Correlation-ID", "corr-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "corr-123", w.Header().Get("X-Correlation-ID"))
+
+	var response domain.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusInternalServerError, response.Status)
+	assert.Equal(t, "internal server error", response.Message)
+	assert.Equal(t, "corr-123", response.CorrelationID)
+	assert.NotEmpty(t, response.Timestamp)
+
+	entries := logs.FilterMessage("Recovered from panic").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "something broke", fields["panic"])
+	assert.Equal(t, "corr-123", fields["correlation_id"])
+	assert.Contains(t, fields["stack"], "recoverer_test.go")
+}
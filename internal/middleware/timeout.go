@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// RequestTimeout returns a middleware that gives each request timeout to complete. The
+// handler's context is cancelled at the deadline and, if it has not finished by then,
+// the client gets a 503 with a JSON ErrorResponse. Responses are buffered until the
+// handler returns, so streaming endpoints must not use it. Zero disables the timeout.
+func RequestTimeout(timeout time.Duration, logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			// Let a timeout longer than the server's write timeout actually apply
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + time.Second)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				logger.Warn("Failed to extend write deadline for request timeout", zap.Error(err))
+			}
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicChan := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				// Re-panic on the request goroutine so Recoverer handles it
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.code == 0 {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				if _, err := w.Write(tw.buf.Bytes()); err != nil {
+					logger.Debug("Failed to write response", zap.Error(err))
+				}
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.err = http.ErrHandlerTimeout
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					// The client went away; there is no one to answer
+					return
+				}
+
+				correlationID := observability.GetCorrelationID(r.Context())
+				logger.Warn("Request timed out",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Duration("timeout", timeout),
+					zap.String("correlation_id", correlationID))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				if err := json.NewEncoder(w).Encode(domain.ErrorResponse{
+					Message:       "request timed out",
+					Status:        http.StatusServiceUnavailable,
+					Timestamp:     domain.GetCurrentTimestamp(),
+					CorrelationID: correlationID,
+				}); err != nil {
+					logger.Error("Failed to encode timeout response", zap.Error(err))
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response so RequestTimeout can discard it and
+// answer with a 503 instead if the handler runs past its deadline
+type timeoutWriter struct {
+	mu     sync.Mutex
+	header http.Header
+	buf    bytes.Buffer
+	code   int
+	err    error
+}
+
+// Header returns the buffered response headers
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers p, failing once the request has timed out
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// WriteHeader records the status code; only the first call counts
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
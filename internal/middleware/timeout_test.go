@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestRequestTimeout_TimesOut(t *testing.T) {
+	handlerDone := make(chan struct{})
+	handler := RequestTimeout(20*time.Millisecond, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// Writes after the deadline are discarded
+		_, _ = w.Write([]byte("too late"))
+		close(handlerDone)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var response domain.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusServiceUnavailable, response.Status)
+	assert.Equal(t, "request timed out", response.Message)
+	<-handlerDone
+	assert.NotContains(t, w.Body.String(), "too late")
+}
+
+func TestRequestTimeout_PassesThroughResponse(t *testing.T) {
+	handler := RequestTimeout(time.Second, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/executions", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "yes", w.Header().Get("X-Test"))
+	assert.Equal(t, "created", w.Body.String())
+}
+
+func TestRequestTimeout_ZeroDisables(t *testing.T) {
+	handler := RequestTimeout(0, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.False(t, hasDeadline)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/executions/send", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestRequestTimeout_PanicReachesRecoverer(t *testing.T) {
+	handler := Recoverer(zap.NewNop())(RequestTimeout(time.Second, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("something broke")
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
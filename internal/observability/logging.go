@@ -39,6 +39,7 @@ type LoggingConfig struct {
 	OutputPaths         []string
 	ErrorOutputPaths    []string
 	CorrelationIDHeader string
+	RequestIDHeader     string
 	InitialFields       map[string]interface{}
 }
 
@@ -46,6 +47,7 @@ type LoggingConfig struct {
 type StructuredLogger struct {
 	logger *zap.Logger
 	sugar  *zap.SugaredLogger
+	level  zap.AtomicLevel
 	config LoggingConfig
 }
 
@@ -61,6 +63,9 @@ func NewStructuredLogger(config LoggingConfig) (*StructuredLogger, error) {
 	if config.CorrelationIDHeader == "" {
 		config.CorrelationIDHeader = "X-Correlation-ID"
 	}
+	if config.RequestIDHeader == "" {
+		config.RequestIDHeader = "X-Request-Id"
+	}
 	if len(config.OutputPaths) == 0 {
 		config.OutputPaths = []string{"stdout"}
 	}
@@ -126,10 +131,32 @@ func NewStructuredLogger(config LoggingConfig) (*StructuredLogger, error) {
 	return &StructuredLogger{
 		logger: logger,
 		sugar:  logger.Sugar(),
+		level:  zapConfig.Level,
 		config: config,
 	}, nil
 }
 
+// SetLevel changes the minimum enabled log level at runtime
+func (l *StructuredLogger) SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %s: %w", level, err)
+	}
+	l.level.SetLevel(parsed)
+	return nil
+}
+
+// Level returns the currently enabled log level
+func (l *StructuredLogger) Level() string {
+	return l.level.Level().String()
+}
+
+// LevelHandler returns an HTTP handler that reports (GET) and changes (PUT) the log level.
+// The request and response bodies use the form {"level":"debug"}.
+func (l *StructuredLogger) LevelHandler() http.Handler {
+	return l.level
+}
+
 // GenerateCorrelationID generates a new correlation ID
 func GenerateCorrelationID() string {
 	bytes := make([]byte, 16)
@@ -218,12 +245,33 @@ func (l *StructuredLogger) Sync() error {
 	return l.logger.Sync()
 }
 
-// CorrelationIDMiddleware is a middleware that adds correlation ID to requests
+// RequestIDMiddleware is a middleware that adds a request ID to requests, taken from
+// the configured request ID header or generated, and echoes it in the response
+func (l *StructuredLogger) RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(l.config.RequestIDHeader)
+			if requestID == "" {
+				requestID = GenerateCorrelationID()
+			}
+
+			w.Header().Set(l.config.RequestIDHeader, requestID)
+			next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+		})
+	}
+}
+
+// CorrelationIDMiddleware is a middleware that adds correlation ID to requests. It
+// must run after RequestIDMiddleware: without a correlation ID header, the request ID
+// set by the caller's gateway becomes the correlation ID so the two stay aligned.
 func (l *StructuredLogger) CorrelationIDMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get correlation ID from header or generate new one
+			// Get correlation ID from header, then the inbound request ID, or generate new one
 			correlationID := r.Header.Get(l.config.CorrelationIDHeader)
+			if correlationID == "" {
+				correlationID = r.Header.Get(l.config.RequestIDHeader)
+			}
 			if correlationID == "" {
 				correlationID = GenerateCorrelationID()
 			}
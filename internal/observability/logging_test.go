@@ -0,0 +1,103 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStructuredLogger_SetLevel(t *testing.T) {
+	logger, err := NewStructuredLogger(LoggingConfig{Level: "info"})
+	require.NoError(t, err)
+
+	core := logger.Logger().Core()
+	assert.False(t, core.Enabled(zapcore.DebugLevel))
+	assert.True(t, core.Enabled(zapcore.InfoLevel))
+
+	require.NoError(t, logger.SetLevel("debug"))
+	assert.Equal(t, "debug", logger.Level())
+	assert.True(t, core.Enabled(zapcore.DebugLevel))
+
+	require.NoError(t, logger.SetLevel("error"))
+	assert.False(t, core.Enabled(zapcore.InfoLevel))
+	assert.False(t, core.Enabled(zapcore.WarnLevel))
+	assert.True(t, core.Enabled(zapcore.ErrorLevel))
+}
+
+func TestStructuredLogger_SetLevel_Invalid(t *testing.T) {
+	logger, err := NewStructuredLogger(LoggingConfig{Level: "info"})
+	require.NoError(t, err)
+
+	err = logger.SetLevel("verbose")
+
+	assert.Error(t, err)
+	assert.Equal(t, "info", logger.Level())
+}
+
+func TestStructuredLogger_LevelHandler(t *testing.T) {
+	logger, err := NewStructuredLogger(LoggingConfig{Level: "info"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	w := httptest.NewRecorder()
+	logger.LevelHandler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "debug", logger.Level())
+	assert.True(t, logger.Logger().Core().Enabled(zapcore.DebugLevel))
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)
+	w = httptest.NewRecorder()
+	logger.LevelHandler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+}
+
+func TestStructuredLogger_RequestAndCorrelationIDMiddleware(t *testing.T) {
+	logger, err := NewStructuredLogger(LoggingConfig{Level: "info", RequestIDHeader: "X-Trace-Request"})
+	require.NoError(t, err)
+
+	var requestID, correlationID string
+	handler := logger.RequestIDMiddleware()(logger.CorrelationIDMiddleware()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = GetRequestID(r.Context())
+			correlationID = GetCorrelationID(r.Context())
+		})))
+
+	// The gateway's request ID becomes the correlation ID when none is sent
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+	req.Header.Set("X-Trace-Request", "gw-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "gw-123", requestID)
+	assert.Equal(t, "gw-123", correlationID)
+	assert.Equal(t, "gw-123", w.Header().Get("X-Trace-Request"))
+	assert.Equal(t, "gw-123", w.Header().Get("X-Correlation-ID"))
+
+	// An explicit correlation ID wins over the request ID
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil)
+	req.Header.Set("X-Trace-Request", "gw-456")
+	req.Header.Set("X-Correlation-ID", "corr-789")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "gw-456", requestID)
+	assert.Equal(t, "corr-789", correlationID)
+	assert.Equal(t, "corr-789", w.Header().Get("X-Correlation-ID"))
+
+	// Both are generated when the request carries neither
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/executions", nil))
+
+	assert.NotEmpty(t, requestID)
+	assert.NotEmpty(t, correlationID)
+	assert.Equal(t, requestID, w.Header().Get("X-Trace-Request"))
+	assert.Equal(t, correlationID, w.Header().Get("X-Correlation-ID"))
+}
@@ -1,11 +1,15 @@
 package observability
 
 import (
+	"context"
+	"math"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +26,7 @@ type BusinessMetrics struct {
 	PortfolioFileGenerated     *prometheus.CounterVec
 	PortfolioCLIInvocations    *prometheus.CounterVec
 	PortfolioCLIProcessingTime *prometheus.HistogramVec
+	PortfolioCLIStepDuration   *prometheus.HistogramVec
 	PortfolioRecordsProcessed  *prometheus.CounterVec
 
 	// Trade Service metrics
@@ -29,6 +34,8 @@ type BusinessMetrics struct {
 	TradeServiceLatency *prometheus.HistogramVec
 	TradeServiceRetries *prometheus.CounterVec
 	TradeServiceErrors  *prometheus.CounterVec
+	TradeServiceCache   *prometheus.CounterVec
+	TradeServicePages   prometheus.Histogram
 
 	// Database metrics
 	DatabaseOperations       *prometheus.CounterVec
@@ -41,17 +48,96 @@ type BusinessMetrics struct {
 	BatchProcessingTime *prometheus.HistogramVec
 	BatchSize           *prometheus.HistogramVec
 	BatchConflicts      *prometheus.CounterVec
+	LastSuccessfulSend  *SendRecency
 
 	// File operations metrics
 	FileOperations        *prometheus.CounterVec
 	FileSize              *prometheus.HistogramVec
 	FileCleanupOperations *prometheus.CounterVec
 
+	// Retention metrics
+	ExecutionsArchived prometheus.Counter
+
 	logger *zap.Logger
 }
 
+// SendRecency tracks the newest successful Send batch for the seconds-since gauge
+type SendRecency struct {
+	mu   sync.Mutex
+	last time.Time
+	now  func() time.Time
+}
+
+// NewSendRecency creates a tracker with no successful Send recorded
+func NewSendRecency() *SendRecency {
+	return &SendRecency{now: time.Now}
+}
+
+// Record notes a successful batch; older batches (e.g. a retried one) are ignored
+func (r *SendRecency) Record(startTime time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if startTime.After(r.last) {
+		r.last = startTime
+	}
+}
+
+// SecondsSince returns the seconds since the newest successful batch, or NaN when none is known
+func (r *SendRecency) SecondsSince() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last.IsZero() {
+		return math.NaN()
+	}
+	return r.now().Sub(r.last).Seconds()
+}
+
+// Default histogram buckets, in seconds, used when no override is configured
+var (
+	defaultExecutionProcessingBuckets = prometheus.DefBuckets
+	defaultBatchProcessingBuckets     = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300}
+	defaultTradeServiceLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+)
+
+// HistogramBuckets overrides the bucket boundaries of the latency histograms. Empty
+// fields keep the default buckets. Boundaries must be strictly ascending.
+type HistogramBuckets struct {
+	ExecutionProcessing []float64
+	BatchProcessing     []float64
+	TradeServiceLatency []float64
+}
+
+// bucketsOrDefault returns buckets, or defaults when no buckets are configured
+func bucketsOrDefault(buckets, defaults []float64) []float64 {
+	if len(buckets) == 0 {
+		return defaults
+	}
+	return buckets
+}
+
+// ObserveWithTraceExemplar records value on observer. When ctx carries a sampled span,
+// its trace ID is attached as an exemplar so a slow sample links straight to its trace;
+// exemplars are only exposed to scrapers that negotiate the OpenMetrics format.
+func ObserveWithTraceExemplar(ctx context.Context, observer prometheus.Observer, value float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsValid() && spanContext.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(value)
+}
+
 // NewBusinessMetrics creates a new business metrics instance
-func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
+func NewBusinessMetrics(logger *zap.Logger, buckets HistogramBuckets) *BusinessMetrics {
+	lastSuccessfulSend := NewSendRecency()
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "allocations_seconds_since_last_successful_send",
+			Help: "Seconds since the start_time of the most recent successful Send batch (NaN until one is known)",
+		},
+		lastSuccessfulSend.SecondsSince,
+	)
+
 	return &BusinessMetrics{
 		// Execution processing metrics
 		ExecutionsBatchProcessed: promauto.NewCounterVec(
@@ -86,7 +172,7 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			prometheus.HistogramOpts{
 				Name:    "allocations_execution_processing_duration_seconds",
 				Help:    "Time spent processing executions",
-				Buckets: prometheus.DefBuckets,
+				Buckets: bucketsOrDefault(buckets.ExecutionProcessing, defaultExecutionProcessingBuckets),
 			},
 			[]string{"operation"},
 		),
@@ -114,6 +200,14 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			},
 			[]string{"command_type"},
 		),
+		PortfolioCLIStepDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "allocations_portfolio_cli_step_duration_seconds",
+				Help:    "Time spent in each step of the Portfolio Accounting CLI pipeline",
+				Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+			},
+			[]string{"step", "status"},
+		),
 		PortfolioRecordsProcessed: promauto.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "allocations_portfolio_records_processed_total",
@@ -134,7 +228,7 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			prometheus.HistogramOpts{
 				Name:    "allocations_trade_service_latency_seconds",
 				Help:    "Latency of Trade Service API calls",
-				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+				Buckets: bucketsOrDefault(buckets.TradeServiceLatency, defaultTradeServiceLatencyBuckets),
 			},
 			[]string{"method"},
 		),
@@ -152,6 +246,20 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			},
 			[]string{"method", "error_type"},
 		),
+		TradeServiceCache: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "allocations_trade_service_cache_lookups_total",
+				Help: "Total number of portfolio cache lookups by result (hit, negative_hit, miss)",
+			},
+			[]string{"result"},
+		),
+		TradeServicePages: promauto.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "allocations_trade_service_pages_per_lookup",
+				Help:    "Number of Trade Service pages fetched per execution lookup",
+				Buckets: []float64{1, 2, 3, 5, 10, 20, 50},
+			},
+		),
 
 		// Database metrics
 		DatabaseOperations: promauto.NewCounterVec(
@@ -195,7 +303,7 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			prometheus.HistogramOpts{
 				Name:    "allocations_batch_processing_duration_seconds",
 				Help:    "Time spent processing batches",
-				Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300},
+				Buckets: bucketsOrDefault(buckets.BatchProcessing, defaultBatchProcessingBuckets),
 			},
 			[]string{"operation"},
 		),
@@ -214,6 +322,7 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			},
 			[]string{"conflict_type"},
 		),
+		LastSuccessfulSend: lastSuccessfulSend,
 
 		// File operations metrics
 		FileOperations: promauto.NewCounterVec(
@@ -239,6 +348,14 @@ func NewBusinessMetrics(logger *zap.Logger) *BusinessMetrics {
 			[]string{"status"},
 		),
 
+		// Retention metrics
+		ExecutionsArchived: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Name: "allocations_executions_archived_total",
+				Help: "Total number of sent executions moved to execution_archive",
+			},
+		),
+
 		logger: logger,
 	}
 }
@@ -283,6 +400,16 @@ func (m *BusinessMetrics) RecordTradeServiceError(method, errorType string) {
 	m.TradeServiceErrors.WithLabelValues(method, errorType).Inc()
 }
 
+// RecordTradeServiceCacheLookup records a portfolio cache lookup result
+func (m *BusinessMetrics) RecordTradeServiceCacheLookup(result string) {
+	m.TradeServiceCache.WithLabelValues(result).Inc()
+}
+
+// RecordTradeServicePages records how many pages one Trade Service lookup fetched
+func (m *BusinessMetrics) RecordTradeServicePages(pages int) {
+	m.TradeServicePages.Observe(float64(pages))
+}
+
 // RecordDatabaseOperation records database operation metrics
 func (m *BusinessMetrics) RecordDatabaseOperation(operation, table, status string, duration time.Duration) {
 	m.DatabaseOperations.WithLabelValues(operation, table, status).Inc()
@@ -307,6 +434,11 @@ func (m *BusinessMetrics) RecordPortfolioCLIInvocation(status string, duration t
 	m.PortfolioRecordsProcessed.WithLabelValues(status).Add(float64(recordCount))
 }
 
+// RecordCLIStep records one step of the CLI pipeline; steps are numbered from 1
+func (m *BusinessMetrics) RecordCLIStep(step int, status string, duration time.Duration) {
+	m.PortfolioCLIStepDuration.WithLabelValues(strconv.Itoa(step), status).Observe(duration.Seconds())
+}
+
 // RecordBatchHistory records batch history creation metrics
 func (m *BusinessMetrics) RecordBatchHistory(status string) {
 	m.BatchHistoryCreated.WithLabelValues(status).Inc()
@@ -317,6 +449,11 @@ func (m *BusinessMetrics) RecordBatchConflict(conflictType string) {
 	m.BatchConflicts.WithLabelValues(conflictType).Inc()
 }
 
+// RecordSuccessfulSend records the start_time of a successfully completed Send batch
+func (m *BusinessMetrics) RecordSuccessfulSend(startTime time.Time) {
+	m.LastSuccessfulSend.Record(startTime)
+}
+
 // RecordFileOperation records file operation metrics
 func (m *BusinessMetrics) RecordFileOperation(operation, status string) {
 	m.FileOperations.WithLabelValues(operation, status).Inc()
@@ -326,3 +463,8 @@ func (m *BusinessMetrics) RecordFileOperation(operation, status string) {
 func (m *BusinessMetrics) RecordFileCleanup(status string) {
 	m.FileCleanupOperations.WithLabelValues(status).Inc()
 }
+
+// RecordExecutionsArchived records executions moved to execution_archive
+func (m *BusinessMetrics) RecordExecutionsArchived(count int) {
+	m.ExecutionsArchived.Add(float64(count))
+}
@@ -0,0 +1,82 @@
+package observability
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSendRecency(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	recency := NewSendRecency()
+	recency.now = func() time.Time { return now }
+
+	assert.True(t, math.IsNaN(recency.SecondsSince()), "no successful send known yet")
+
+	recency.Record(now.Add(-time.Hour))
+	assert.Equal(t, 3600.0, recency.SecondsSince())
+
+	recency.Record(now.Add(-2 * time.Hour))
+	assert.Equal(t, 3600.0, recency.SecondsSince(), "an older batch must not move the gauge back")
+
+	recency.Record(now.Add(-time.Minute))
+	assert.Equal(t, 60.0, recency.SecondsSince())
+}
+
+func TestBucketsOrDefault(t *testing.T) {
+	assert.Equal(t, defaultBatchProcessingBuckets, bucketsOrDefault(nil, defaultBatchProcessingBuckets))
+	assert.Equal(t, defaultBatchProcessingBuckets, bucketsOrDefault([]float64{}, defaultBatchProcessingBuckets))
+	assert.Equal(t, []float64{1, 5, 15}, bucketsOrDefault([]float64{1, 5, 15}, defaultBatchProcessingBuckets))
+}
+
+func TestObserveWithTraceExemplar(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("0102030405060708")
+	require.NoError(t, err)
+	spanContext := func(flags trace.TraceFlags) context.Context {
+		return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: flags,
+		}))
+	}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantExemplar bool
+	}{
+		{"no span", context.Background(), false},
+		{"unsampled span", spanContext(0), false},
+		{"sampled span", spanContext(trace.FlagsSampled), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_duration_seconds", Buckets: []float64{1}})
+
+			ObserveWithTraceExemplar(tt.ctx, histogram, 0.5)
+
+			var m dto.Metric
+			require.NoError(t, histogram.Write(&m))
+			assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+			exemplar := m.GetHistogram().GetBucket()[0].GetExemplar()
+			if !tt.wantExemplar {
+				assert.Nil(t, exemplar)
+				return
+			}
+			require.NotNil(t, exemplar)
+			assert.Equal(t, 0.5, exemplar.GetValue())
+			require.Len(t, exemplar.GetLabel(), 1)
+			assert.Equal(t, "trace_id", exemplar.GetLabel()[0].GetName())
+			assert.Equal(t, traceID.String(), exemplar.GetLabel()[0].GetValue())
+		})
+	}
+}
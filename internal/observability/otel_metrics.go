@@ -2,6 +2,7 @@ package observability
 
 import (
 	"context"
+	"math"
 	"runtime"
 	"time"
 
@@ -44,6 +45,16 @@ type OTELMetricsManager struct {
 	executionsProcessed   metric.Int64Counter
 	batchProcessingTime   metric.Float64Histogram
 	portfolioFilesGenerated metric.Int64Counter
+
+	// Business metrics mirroring the Prometheus BusinessMetrics series
+	executionsSkipped         metric.Int64Counter
+	executionsErrored         metric.Int64Counter
+	cliStepDuration           metric.Float64Histogram
+	executionsArchived        metric.Int64Counter
+	tradeServiceCacheLookups  metric.Int64Counter
+	tradeServicePages         metric.Int64Histogram
+	secondsSinceLastSend      metric.Float64ObservableGauge
+	lastSuccessfulSend        *SendRecency
 }
 
 // NewOTELMetricsManager creates a new OpenTelemetry metrics manager
@@ -51,8 +62,9 @@ func NewOTELMetricsManager(logger *zap.Logger) (*OTELMetricsManager, error) {
 	meter := otel.Meter("globeco-allocation-service")
 
 	manager := &OTELMetricsManager{
-		meter:  meter,
-		logger: logger,
+		meter:              meter,
+		logger:             logger,
+		lastSuccessfulSend: NewSendRecency(),
 	}
 
 	if err := manager.initializeMetrics(); err != nil {
@@ -228,6 +240,79 @@ func (m *OTELMetricsManager) initializeMetrics() error {
 		return err
 	}
 
+	m.executionsSkipped, err = m.meter.Int64Counter(
+		"executions_skipped_total",
+		metric.WithDescription("Total number of executions skipped"),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.executionsErrored, err = m.meter.Int64Counter(
+		"executions_errored_total",
+		metric.WithDescription("Total number of executions that failed"),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.cliStepDuration, err = m.meter.Float64Histogram(
+		"portfolio_cli_step_duration_seconds",
+		metric.WithDescription("Time spent in each step of the Portfolio Accounting CLI pipeline"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.executionsArchived, err = m.meter.Int64Counter(
+		"executions_archived_total",
+		metric.WithDescription("Total number of sent executions moved to execution_archive"),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.tradeServiceCacheLookups, err = m.meter.Int64Counter(
+		"trade_service_cache_lookups_total",
+		metric.WithDescription("Total number of portfolio cache lookups by result (hit, negative_hit, miss)"),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.tradeServicePages, err = m.meter.Int64Histogram(
+		"trade_service_pages_per_lookup",
+		metric.WithDescription("Number of Trade Service pages fetched per execution lookup"),
+		metric.WithExplicitBucketBoundaries(1, 2, 3, 5, 10, 20, 50),
+	)
+	if err != nil {
+		return err
+	}
+
+	m.secondsSinceLastSend, err = m.meter.Float64ObservableGauge(
+		"seconds_since_last_successful_send",
+		metric.WithDescription("Seconds since the start_time of the most recent successful Send batch"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Unlike the Prometheus gauge, nothing is observed until a successful Send is known
+	_, err = m.meter.RegisterCallback(
+		func(ctx context.Context, observer metric.Observer) error {
+			if seconds := m.lastSuccessfulSend.SecondsSince(); !math.IsNaN(seconds) {
+				observer.ObserveFloat64(m.secondsSinceLastSend, seconds)
+			}
+			return nil
+		},
+		m.secondsSinceLastSend,
+	)
+	if err != nil {
+		return err
+	}
+
 	// Register callback for Go runtime metrics
 	_, err = m.meter.RegisterCallback(
 		m.collectGoRuntimeMetrics,
@@ -260,7 +345,8 @@ func (m *OTELMetricsManager) collectGoRuntimeMetrics(ctx context.Context, observ
 	return nil
 }
 
-// RecordHTTPRequest records HTTP request metrics
+// RecordHTTPRequest records HTTP request metrics. ctx should carry the request span: the
+// SDK's default trace-based exemplar filter attaches a sampled span to the duration.
 func (m *OTELMetricsManager) RecordHTTPRequest(ctx context.Context, method, path, status string, duration time.Duration) {
 	m.httpRequestsTotal.Add(ctx, 1,
 		metric.WithAttributes(
@@ -355,7 +441,7 @@ func (m *OTELMetricsManager) RecordExecutionCreated(ctx context.Context, tradeTy
 			attribute.String("destination", destination),
 		))
 
-	m.logger.Info("Recorded execution creation metrics to OpenTelemetry collector",
+	m.logger.Debug("Recorded execution creation metrics to OpenTelemetry collector",
 		zap.String("trade_type", tradeType),
 		zap.String("destination", destination))
 }
@@ -367,7 +453,7 @@ func (m *OTELMetricsManager) RecordExecutionProcessed(ctx context.Context, statu
 			attribute.String("status", status),
 		))
 
-	m.logger.Info("Recorded execution processing metrics to OpenTelemetry collector",
+	m.logger.Debug("Recorded execution processing metrics to OpenTelemetry collector",
 		zap.String("status", status),
 		zap.Int("count", count))
 }
@@ -379,7 +465,7 @@ func (m *OTELMetricsManager) RecordBatchProcessing(ctx context.Context, operatio
 			attribute.String("operation", operation),
 		))
 
-	m.logger.Info("Recorded batch processing metrics to OpenTelemetry collector",
+	m.logger.Debug("Recorded batch processing metrics to OpenTelemetry collector",
 		zap.String("operation", operation),
 		zap.Duration("duration", duration),
 		zap.Int("batch_size", batchSize))
@@ -392,6 +478,44 @@ func (m *OTELMetricsManager) RecordPortfolioFileGenerated(ctx context.Context, s
 			attribute.String("status", status),
 		))
 
-	m.logger.Info("Recorded portfolio file generation metrics to OpenTelemetry collector",
+	m.logger.Debug("Recorded portfolio file generation metrics to OpenTelemetry collector",
 		zap.String("status", status))
-}
\ No newline at end of file
+}
+// RecordExecutionSkipped records an execution skipped for the given reason
+func (m *OTELMetricsManager) RecordExecutionSkipped(ctx context.Context, reason string) {
+	m.executionsSkipped.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+// RecordExecutionError records an execution that failed with the given error type
+func (m *OTELMetricsManager) RecordExecutionError(ctx context.Context, errorType string) {
+	m.executionsErrored.Add(ctx, 1, metric.WithAttributes(attribute.String("error_type", errorType)))
+}
+
+// RecordCLIStep records one step of the CLI pipeline; steps are numbered from 1
+func (m *OTELMetricsManager) RecordCLIStep(ctx context.Context, step int, status string, duration time.Duration) {
+	m.cliStepDuration.Record(ctx, duration.Seconds(),
+		metric.WithAttributes(
+			attribute.Int("step", step),
+			attribute.String("status", status),
+		))
+}
+
+// RecordExecutionsArchived records executions moved to execution_archive
+func (m *OTELMetricsManager) RecordExecutionsArchived(ctx context.Context, count int) {
+	m.executionsArchived.Add(ctx, int64(count))
+}
+
+// RecordTradeServiceCacheLookup records a portfolio cache lookup result
+func (m *OTELMetricsManager) RecordTradeServiceCacheLookup(ctx context.Context, result string) {
+	m.tradeServiceCacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
+
+// RecordTradeServicePages records how many pages one Trade Service lookup fetched
+func (m *OTELMetricsManager) RecordTradeServicePages(ctx context.Context, pages int) {
+	m.tradeServicePages.Record(ctx, int64(pages))
+}
+
+// RecordSuccessfulSend records the start_time of a successfully completed Send batch
+func (m *OTELMetricsManager) RecordSuccessfulSend(startTime time.Time) {
+	m.lastSuccessfulSend.Record(startTime)
+}
@@ -0,0 +1,248 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Metrics backends selectable with the metrics_backend setting
+const (
+	MetricsBackendPrometheus = "prometheus"
+	MetricsBackendOTEL       = "otel"
+	MetricsBackendBoth       = "both"
+)
+
+// MetricsRecorder records the business metrics emitted by the service and repository
+// layers. Callers record each event once; the recorder dispatches it to the configured
+// backend(s), so the two metric systems never diverge in what they count.
+type MetricsRecorder interface {
+	RecordExecutionCreated(ctx context.Context, tradeType, destination string)
+	RecordExecutionSkipped(ctx context.Context, reason string)
+	RecordExecutionError(ctx context.Context, errorType string)
+	// RecordBatchProcessed records a finished Send or retry batch of batchSize executions
+	RecordBatchProcessed(ctx context.Context, operation, status string, duration time.Duration, batchSize int)
+	RecordPortfolioFileGenerated(ctx context.Context, status string)
+	RecordSuccessfulSend(ctx context.Context, startTime time.Time)
+	RecordCLIStep(ctx context.Context, step int, status string, duration time.Duration)
+	RecordTradeServiceCacheLookup(ctx context.Context, result string)
+	RecordTradeServicePages(ctx context.Context, pages int)
+	RecordDatabaseOperation(ctx context.Context, operation, table, status string, duration time.Duration)
+	RecordExecutionsArchived(ctx context.Context, count int)
+}
+
+// ValidateMetricsBackend rejects unknown metrics backends
+func ValidateMetricsBackend(backend string) error {
+	switch backend {
+	case MetricsBackendPrometheus, MetricsBackendOTEL, MetricsBackendBoth:
+		return nil
+	default:
+		return fmt.Errorf("unsupported metrics backend %q, expected %q, %q or %q",
+			backend, MetricsBackendPrometheus, MetricsBackendOTEL, MetricsBackendBoth)
+	}
+}
+
+// NewMetricsRecorder returns the recorder for backend, built on the given Prometheus
+// metrics and OpenTelemetry manager. The selected backend(s) must not be nil.
+func NewMetricsRecorder(backend string, prometheus *BusinessMetrics, otel *OTELMetricsManager) (MetricsRecorder, error) {
+	if err := ValidateMetricsBackend(backend); err != nil {
+		return nil, err
+	}
+	if backend != MetricsBackendOTEL && prometheus == nil {
+		return nil, fmt.Errorf("metrics backend %q requires Prometheus metrics", backend)
+	}
+	if backend != MetricsBackendPrometheus && otel == nil {
+		return nil, fmt.Errorf("metrics backend %q requires OpenTelemetry metrics", backend)
+	}
+
+	switch backend {
+	case MetricsBackendPrometheus:
+		return NewPrometheusRecorder(prometheus), nil
+	case MetricsBackendOTEL:
+		return NewOTELRecorder(otel), nil
+	default:
+		return multiRecorder{NewPrometheusRecorder(prometheus), NewOTELRecorder(otel)}, nil
+	}
+}
+
+// PrometheusRecorder records through the Prometheus BusinessMetrics
+type PrometheusRecorder struct {
+	metrics *BusinessMetrics
+}
+
+// NewPrometheusRecorder creates a recorder backed by Prometheus metrics
+func NewPrometheusRecorder(metrics *BusinessMetrics) *PrometheusRecorder {
+	return &PrometheusRecorder{metrics: metrics}
+}
+
+func (r *PrometheusRecorder) RecordExecutionCreated(_ context.Context, tradeType, destination string) {
+	r.metrics.RecordExecutionCreated(tradeType, destination)
+}
+
+func (r *PrometheusRecorder) RecordExecutionSkipped(_ context.Context, reason string) {
+	r.metrics.RecordExecutionSkipped(reason)
+}
+
+func (r *PrometheusRecorder) RecordExecutionError(_ context.Context, errorType string) {
+	r.metrics.RecordExecutionError(errorType)
+}
+
+func (r *PrometheusRecorder) RecordBatchProcessed(ctx context.Context, operation, status string, duration time.Duration, batchSize int) {
+	r.metrics.ExecutionsBatchProcessed.WithLabelValues(status).Inc()
+	r.metrics.BatchSize.WithLabelValues(operation).Observe(float64(batchSize))
+	ObserveWithTraceExemplar(ctx, r.metrics.BatchProcessingTime.WithLabelValues(operation), duration.Seconds())
+}
+
+func (r *PrometheusRecorder) RecordPortfolioFileGenerated(_ context.Context, status string) {
+	r.metrics.PortfolioFileGenerated.WithLabelValues(status).Inc()
+}
+
+func (r *PrometheusRecorder) RecordSuccessfulSend(_ context.Context, startTime time.Time) {
+	r.metrics.RecordSuccessfulSend(startTime)
+}
+
+func (r *PrometheusRecorder) RecordCLIStep(_ context.Context, step int, status string, duration time.Duration) {
+	r.metrics.RecordCLIStep(step, status, duration)
+}
+
+func (r *PrometheusRecorder) RecordTradeServiceCacheLookup(_ context.Context, result string) {
+	r.metrics.RecordTradeServiceCacheLookup(result)
+}
+
+func (r *PrometheusRecorder) RecordTradeServicePages(_ context.Context, pages int) {
+	r.metrics.RecordTradeServicePages(pages)
+}
+
+func (r *PrometheusRecorder) RecordDatabaseOperation(_ context.Context, operation, table, status string, duration time.Duration) {
+	r.metrics.RecordDatabaseOperation(operation, table, status, duration)
+}
+
+func (r *PrometheusRecorder) RecordExecutionsArchived(_ context.Context, count int) {
+	r.metrics.RecordExecutionsArchived(count)
+}
+
+// OTELRecorder records through the OpenTelemetry metrics manager
+type OTELRecorder struct {
+	metrics *OTELMetricsManager
+}
+
+// NewOTELRecorder creates a recorder backed by OpenTelemetry metrics
+func NewOTELRecorder(metrics *OTELMetricsManager) *OTELRecorder {
+	return &OTELRecorder{metrics: metrics}
+}
+
+func (r *OTELRecorder) RecordExecutionCreated(ctx context.Context, tradeType, destination string) {
+	r.metrics.RecordExecutionCreated(ctx, tradeType, destination)
+}
+
+func (r *OTELRecorder) RecordExecutionSkipped(ctx context.Context, reason string) {
+	r.metrics.RecordExecutionSkipped(ctx, reason)
+}
+
+func (r *OTELRecorder) RecordExecutionError(ctx context.Context, errorType string) {
+	r.metrics.RecordExecutionError(ctx, errorType)
+}
+
+func (r *OTELRecorder) RecordBatchProcessed(ctx context.Context, operation, status string, duration time.Duration, batchSize int) {
+	r.metrics.RecordBatchProcessing(ctx, operation, duration, batchSize)
+	if batchSize > 0 {
+		r.metrics.RecordExecutionProcessed(ctx, status, batchSize)
+	}
+}
+
+func (r *OTELRecorder) RecordPortfolioFileGenerated(ctx context.Context, status string) {
+	r.metrics.RecordPortfolioFileGenerated(ctx, status)
+}
+
+func (r *OTELRecorder) RecordSuccessfulSend(_ context.Context, startTime time.Time) {
+	r.metrics.RecordSuccessfulSend(startTime)
+}
+
+func (r *OTELRecorder) RecordCLIStep(ctx context.Context, step int, status string, duration time.Duration) {
+	r.metrics.RecordCLIStep(ctx, step, status, duration)
+}
+
+func (r *OTELRecorder) RecordTradeServiceCacheLookup(ctx context.Context, result string) {
+	r.metrics.RecordTradeServiceCacheLookup(ctx, result)
+}
+
+func (r *OTELRecorder) RecordTradeServicePages(ctx context.Context, pages int) {
+	r.metrics.RecordTradeServicePages(ctx, pages)
+}
+
+func (r *OTELRecorder) RecordDatabaseOperation(ctx context.Context, operation, table, status string, duration time.Duration) {
+	r.metrics.RecordDatabaseOperation(ctx, operation, table, status, duration)
+}
+
+func (r *OTELRecorder) RecordExecutionsArchived(ctx context.Context, count int) {
+	r.metrics.RecordExecutionsArchived(ctx, count)
+}
+
+// multiRecorder records every event on each of its recorders
+type multiRecorder []MetricsRecorder
+
+func (m multiRecorder) RecordExecutionCreated(ctx context.Context, tradeType, destination string) {
+	for _, r := range m {
+		r.RecordExecutionCreated(ctx, tradeType, destination)
+	}
+}
+
+func (m multiRecorder) RecordExecutionSkipped(ctx context.Context, reason string) {
+	for _, r := range m {
+		r.RecordExecutionSkipped(ctx, reason)
+	}
+}
+
+func (m multiRecorder) RecordExecutionError(ctx context.Context, errorType string) {
+	for _, r := range m {
+		r.RecordExecutionError(ctx, errorType)
+	}
+}
+
+func (m multiRecorder) RecordBatchProcessed(ctx context.Context, operation, status string, duration time.Duration, batchSize int) {
+	for _, r := range m {
+		r.RecordBatchProcessed(ctx, operation, status, duration, batchSize)
+	}
+}
+
+func (m multiRecorder) RecordPortfolioFileGenerated(ctx context.Context, status string) {
+	for _, r := range m {
+		r.RecordPortfolioFileGenerated(ctx, status)
+	}
+}
+
+func (m multiRecorder) RecordSuccessfulSend(ctx context.Context, startTime time.Time) {
+	for _, r := range m {
+		r.RecordSuccessfulSend(ctx, startTime)
+	}
+}
+
+func (m multiRecorder) RecordCLIStep(ctx context.Context, step int, status string, duration time.Duration) {
+	for _, r := range m {
+		r.RecordCLIStep(ctx, step, status, duration)
+	}
+}
+
+func (m multiRecorder) RecordTradeServiceCacheLookup(ctx context.Context, result string) {
+	for _, r := range m {
+		r.RecordTradeServiceCacheLookup(ctx, result)
+	}
+}
+
+func (m multiRecorder) RecordTradeServicePages(ctx context.Context, pages int) {
+	for _, r := range m {
+		r.RecordTradeServicePages(ctx, pages)
+	}
+}
+
+func (m multiRecorder) RecordDatabaseOperation(ctx context.Context, operation, table, status string, duration time.Duration) {
+	for _, r := range m {
+		r.RecordDatabaseOperation(ctx, operation, table, status, duration)
+	}
+}
+
+func (m multiRecorder) RecordExecutionsArchived(ctx context.Context, count int) {
+	for _, r := range m {
+		r.RecordExecutionsArchived(ctx, count)
+	}
+}
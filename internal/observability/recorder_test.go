@@ -0,0 +1,70 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+)
+
+func TestNewMetricsRecorder_Invalid(t *testing.T) {
+	prometheusMetrics := &BusinessMetrics{}
+
+	_, err := NewMetricsRecorder("statsd", prometheusMetrics, nil)
+	assert.ErrorContains(t, err, `unsupported metrics backend "statsd"`)
+
+	_, err = NewMetricsRecorder(MetricsBackendPrometheus, nil, nil)
+	assert.ErrorContains(t, err, "requires Prometheus metrics")
+
+	_, err = NewMetricsRecorder(MetricsBackendBoth, prometheusMetrics, nil)
+	assert.ErrorContains(t, err, "requires OpenTelemetry metrics")
+}
+
+func TestNewMetricsRecorder_Backends(t *testing.T) {
+	tests := []struct {
+		backend        string
+		wantPrometheus float64
+		wantOTEL       bool
+	}{
+		{MetricsBackendPrometheus, 1, false},
+		{MetricsBackendOTEL, 0, true},
+		{MetricsBackendBoth, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			previous := otel.GetMeterProvider()
+			otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+			t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+			otelMetrics, err := NewOTELMetricsManager(zap.NewNop())
+			require.NoError(t, err)
+			prometheusMetrics := &BusinessMetrics{
+				ExecutionsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_executions_created_total"}, []string{"trade_type", "destination"}),
+			}
+
+			recorder, err := NewMetricsRecorder(tt.backend, prometheusMetrics, otelMetrics)
+			require.NoError(t, err)
+			recorder.RecordExecutionCreated(context.Background(), "BUY", "NYSE")
+
+			assert.Equal(t, tt.wantPrometheus, testutil.ToFloat64(prometheusMetrics.ExecutionsCreated.WithLabelValues("BUY", "NYSE")))
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			recorded := false
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					recorded = recorded || m.Name == "executions_created_total"
+				}
+			}
+			assert.Equal(t, tt.wantOTEL, recorded)
+		})
+	}
+}
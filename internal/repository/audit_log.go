@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// AuditLogRepository handles database operations for the append-only audit log
+type AuditLogRepository struct {
+	db     *DB
+	logger *zap.Logger
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *DB, logger *zap.Logger) *AuditLogRepository {
+	return &AuditLogRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create inserts a new audit log record. There is deliberately no Update or Delete.
+func (r *AuditLogRepository) Create(ctx context.Context, auditLog *domain.AuditLog) error {
+	query := `
+		INSERT INTO audit_log (action, batch_id, correlation_id, actor, processed_count, file_name, outcome, message)
+		VALUES (:action, :batch_id, :correlation_id, :actor, :processed_count, :file_name, :outcome, :message)
+		RETURNING id, created_at`
+
+	err := r.db.observeQuery(ctx, "insert", "audit_log", func(ctx context.Context) error {
+		return r.db.namedQueryRow(ctx, query, auditLog, &auditLog.ID, &auditLog.CreatedAt)
+	})
+	if err != nil {
+		r.logger.Error("Failed to create audit log", zap.String("action", auditLog.Action), zap.Error(err))
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves audit log records, newest first, with pagination
+func (r *AuditLogRepository) List(ctx context.Context, limit, offset int) ([]domain.AuditLog, int, error) {
+	var auditLogs []domain.AuditLog
+	var totalCount int
+
+	countQuery := "SELECT COUNT(*) FROM audit_log"
+	if err := r.db.observeQuery(ctx, "select", "audit_log", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &totalCount, countQuery)
+	}); err != nil {
+		r.logger.Error("Failed to get audit log count", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to get audit log count: %w", err)
+	}
+
+	query := "SELECT * FROM audit_log ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2"
+	if err := r.db.observeQuery(ctx, "select", "audit_log", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &auditLogs, query, limit, offset)
+	}); err != nil {
+		r.logger.Error("Failed to list audit logs", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return auditLogs, totalCount, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func newTestAuditLogRepository(t *testing.T) (*AuditLogRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), logger: zap.NewNop()}
+	return NewAuditLogRepository(dbWrapper, zap.NewNop()), mock
+}
+
+func TestAuditLogRepository_Create(t *testing.T) {
+	repo, mock := newTestAuditLogRepository(t)
+
+	batchID := 42
+	correlationID := "corr-123"
+	message := "Successfully processed 3 executions"
+	auditLog := &domain.AuditLog{
+		Action:         domain.AuditActionSend,
+		BatchID:        &batchID,
+		CorrelationID:  &correlationID,
+		ProcessedCount: 3,
+		Outcome:        domain.AuditOutcomeSuccess,
+		Message:        &message,
+	}
+	createdAt := time.Now().UTC()
+
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WithArgs(domain.AuditActionSend, &batchID, &correlationID, nil, 3, nil, domain.AuditOutcomeSuccess, &message).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, createdAt))
+
+	err := repo.Create(context.Background(), auditLog)
+
+	require.NoError(t, err)
+	assert.Equal(t, 9, auditLog.ID)
+	assert.Equal(t, createdAt, auditLog.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditLogRepository_Create_Error(t *testing.T) {
+	repo, mock := newTestAuditLogRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnError(errors.New("connection refused"))
+
+	err := repo.Create(context.Background(), &domain.AuditLog{Action: domain.AuditActionSend, Outcome: domain.AuditOutcomeError})
+
+	assert.ErrorContains(t, err, "failed to create audit log")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditLogRepository_List(t *testing.T) {
+	repo, mock := newTestAuditLogRepository(t)
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM audit_log`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT \* FROM audit_log ORDER BY created_at DESC, id DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "batch_id", "correlation_id", "actor", "processed_count", "file_name", "outcome", "message", "created_at"}).
+			AddRow(3, domain.AuditActionSend, 5, "corr-3", nil, 10, "file.csv", domain.AuditOutcomeSuccess, "ok", now).
+			AddRow(2, domain.AuditActionBatchRetry, 4, nil, nil, 0, nil, domain.AuditOutcomeError, "failed", now.Add(-time.Minute)))
+
+	auditLogs, total, err := repo.List(context.Background(), 2, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, auditLogs, 2)
+	assert.Equal(t, 3, auditLogs[0].ID)
+	assert.Equal(t, "corr-3", *auditLogs[0].CorrelationID)
+	assert.Nil(t, auditLogs[1].CorrelationID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -8,6 +8,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
 )
 
@@ -17,6 +18,9 @@ type BatchHistoryRepository struct {
 	logger *zap.Logger
 }
 
+// sendLockKey is the advisory lock key that serializes Send across all instances
+const sendLockKey int64 = 0x676c6f6265636f // "globeco"
+
 // NewBatchHistoryRepository creates a new batch history repository
 func NewBatchHistoryRepository(db *DB, logger *zap.Logger) *BatchHistoryRepository {
 	return &BatchHistoryRepository{
@@ -25,12 +29,20 @@ func NewBatchHistoryRepository(db *DB, logger *zap.Logger) *BatchHistoryReposito
 	}
 }
 
+// TryAcquireSendLock takes the cluster-wide Send lock without blocking. It returns a
+// nil lock when another instance is already sending.
+func (r *BatchHistoryRepository) TryAcquireSendLock(ctx context.Context) (*AdvisoryLock, error) {
+	return r.db.TryAdvisoryLock(ctx, sendLockKey)
+}
+
 // GetMaxStartTime retrieves the maximum start time from batch history
 func (r *BatchHistoryRepository) GetMaxStartTime(ctx context.Context) (time.Time, error) {
 	var maxTime sql.NullTime
 	query := "SELECT MAX(start_time) FROM batch_history"
 
-	err := r.db.GetContext(ctx, &maxTime, query)
+	err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &maxTime, query)
+	})
 	if err != nil {
 		r.logger.Error("Failed to get max start time", zap.Error(err))
 		return time.Time{}, fmt.Errorf("failed to get max start time: %w", err)
@@ -44,29 +56,46 @@ func (r *BatchHistoryRepository) GetMaxStartTime(ctx context.Context) (time.Time
 	return maxTime.Time, nil
 }
 
-// Create inserts a new batch history record
+// GetLatestCompletedStartTime returns the start_time of the newest completed batch,
+// or nil when no batch has completed
+func (r *BatchHistoryRepository) GetLatestCompletedStartTime(ctx context.Context) (*time.Time, error) {
+	var maxTime sql.NullTime
+	query := "SELECT MAX(start_time) FROM batch_history WHERE status = $1"
+
+	if err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &maxTime, query, domain.BatchStatusCompleted)
+	}); err != nil {
+		r.logger.Error("Failed to get latest completed start time", zap.Error(err))
+		return nil, fmt.Errorf("failed to get latest completed start time: %w", err)
+	}
+
+	if !maxTime.Valid {
+		return nil, nil
+	}
+
+	return &maxTime.Time, nil
+}
+
+// Create inserts a new batch history record. Duplicate batches are detected solely by
+// the unique indexes on start_time and previous_start_time: when two Sends race for the
+// same window the losing insert fails and apperrors.ErrDuplicateBatch is returned.
 func (r *BatchHistoryRepository) Create(ctx context.Context, batchHistory *domain.BatchHistory) error {
 	query := `
-		INSERT INTO batch_history (start_time, previous_start_time, version) 
-		VALUES (:start_time, :previous_start_time, :version) 
+		INSERT INTO batch_history (start_time, previous_start_time, status, version, batch_key) 
+		VALUES (:start_time, :previous_start_time, :status, :version, :batch_key) 
 		RETURNING id`
 
-	rows, err := r.db.NamedQueryContext(ctx, query, batchHistory)
+	err := r.db.observeQuery(ctx, "insert", "batch_history", func(ctx context.Context) error {
+		return r.db.namedQueryRow(ctx, query, batchHistory, &batchHistory.ID)
+	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			r.logger.Warn("Duplicate batch history", zap.Time("start_time", batchHistory.StartTime), zap.Error(err))
+			return fmt.Errorf("%w: %v", apperrors.ErrDuplicateBatch, err)
+		}
 		r.logger.Error("Failed to create batch history", zap.Error(err))
 		return fmt.Errorf("failed to create batch history: %w", err)
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			r.logger.Error("failed to close rows", zap.Error(err))
-		}
-	}()
-
-	if rows.Next() {
-		if err := rows.Scan(&batchHistory.ID); err != nil {
-			return fmt.Errorf("failed to scan batch history ID: %w", err)
-		}
-	}
 
 	r.logger.Info("Created batch history",
 		zap.Int("id", batchHistory.ID),
@@ -81,10 +110,12 @@ func (r *BatchHistoryRepository) GetByID(ctx context.Context, id int) (*domain.B
 	var batchHistory domain.BatchHistory
 	query := "SELECT * FROM batch_history WHERE id = $1"
 
-	err := r.db.GetContext(ctx, &batchHistory, query, id)
+	err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &batchHistory, query, id)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("batch history not found: %d", id)
+			return nil, fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
 		}
 		r.logger.Error("Failed to get batch history by ID", zap.Int("id", id), zap.Error(err))
 		return nil, fmt.Errorf("failed to get batch history: %w", err)
@@ -93,6 +124,25 @@ func (r *BatchHistoryRepository) GetByID(ctx context.Context, id int) (*domain.B
 	return &batchHistory, nil
 }
 
+// GetByBatchKey retrieves the batch history record created by a Send with the given key
+func (r *BatchHistoryRepository) GetByBatchKey(ctx context.Context, batchKey string) (*domain.BatchHistory, error) {
+	var batchHistory domain.BatchHistory
+	query := "SELECT * FROM batch_history WHERE batch_key = $1"
+
+	err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &batchHistory, query, batchKey)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: batch key %q", apperrors.ErrBatchNotFound, batchKey)
+		}
+		r.logger.Error("Failed to get batch history by batch key", zap.String("batch_key", batchKey), zap.Error(err))
+		return nil, fmt.Errorf("failed to get batch history: %w", err)
+	}
+
+	return &batchHistory, nil
+}
+
 // List retrieves batch history records with pagination
 func (r *BatchHistoryRepository) List(ctx context.Context, limit, offset int) ([]domain.BatchHistory, int, error) {
 	var batches []domain.BatchHistory
@@ -100,14 +150,18 @@ func (r *BatchHistoryRepository) List(ctx context.Context, limit, offset int) ([
 
 	// Get total count
 	countQuery := "SELECT COUNT(*) FROM batch_history"
-	if err := r.db.GetContext(ctx, &totalCount, countQuery); err != nil {
+	if err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &totalCount, countQuery)
+	}); err != nil {
 		r.logger.Error("Failed to get batch history count", zap.Error(err))
 		return nil, 0, fmt.Errorf("failed to get batch history count: %w", err)
 	}
 
 	// Get batch history with pagination
 	query := "SELECT * FROM batch_history ORDER BY start_time DESC LIMIT $1 OFFSET $2"
-	if err := r.db.SelectContext(ctx, &batches, query, limit, offset); err != nil {
+	if err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &batches, query, limit, offset)
+	}); err != nil {
 		r.logger.Error("Failed to list batch history", zap.Error(err))
 		return nil, 0, fmt.Errorf("failed to list batch history: %w", err)
 	}
@@ -120,7 +174,9 @@ func (r *BatchHistoryRepository) GetLatest(ctx context.Context) (*domain.BatchHi
 	var batchHistory domain.BatchHistory
 	query := "SELECT * FROM batch_history ORDER BY start_time DESC LIMIT 1"
 
-	err := r.db.GetContext(ctx, &batchHistory, query)
+	err := r.db.observeQuery(ctx, "select", "batch_history", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &batchHistory, query)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no batch history found")
@@ -138,10 +194,15 @@ func (r *BatchHistoryRepository) Update(ctx context.Context, batchHistory *domai
 		UPDATE batch_history SET
 			start_time = :start_time,
 			previous_start_time = :previous_start_time,
+			status = :status,
 			version = :version + 1
 		WHERE id = :id AND version = :version`
 
-	result, err := r.db.NamedExecContext(ctx, query, batchHistory)
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.NamedExecContext(ctx, query, batchHistory)
+		return err
+	})
 	if err != nil {
 		r.logger.Error("Failed to update batch history", zap.Int("id", batchHistory.ID), zap.Error(err))
 		return fmt.Errorf("failed to update batch history: %w", err)
@@ -153,7 +214,7 @@ func (r *BatchHistoryRepository) Update(ctx context.Context, batchHistory *domai
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("batch history not found or version conflict: %d", batchHistory.ID)
+		return fmt.Errorf("%w: batch %d not found or modified concurrently", apperrors.ErrVersionConflict, batchHistory.ID)
 	}
 
 	batchHistory.Version++
@@ -161,10 +222,143 @@ func (r *BatchHistoryRepository) Update(ctx context.Context, batchHistory *domai
 	return nil
 }
 
+// UpdateStatus sets the status of a batch history record
+func (r *BatchHistoryRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	query := "UPDATE batch_history SET status = $1, version = version + 1 WHERE id = $2"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, status, id)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to update batch history status", zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to update batch history status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
+	}
+
+	r.logger.Info("Updated batch history status", zap.Int("id", id), zap.String("status", status))
+	return nil
+}
+
+// ClaimForRetry moves a failed batch to in_progress. It reports false, changing nothing,
+// when the batch is no longer failed, so of two retries of one batch only one proceeds.
+func (r *BatchHistoryRepository) ClaimForRetry(ctx context.Context, id int) (bool, error) {
+	query := "UPDATE batch_history SET status = $1, version = version + 1 WHERE id = $2 AND status = $3"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, domain.BatchStatusInProgress, id, domain.BatchStatusFailed)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to claim batch for retry", zap.Int("id", id), zap.Error(err))
+		return false, fmt.Errorf("failed to claim batch for retry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// AddLoadedPortfolio records that the CLI loaded the file of portfolioID in a batch split
+// by portfolio
+func (r *BatchHistoryRepository) AddLoadedPortfolio(ctx context.Context, id int, portfolioID string) error {
+	query := "UPDATE batch_history SET loaded_portfolios = array_append(loaded_portfolios, $1) WHERE id = $2"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, portfolioID, id)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to record loaded portfolio", zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to record loaded portfolio: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateSendResponse stores the JSON SendResponse of a batch so a Send repeating its
+// batch key can replay it
+func (r *BatchHistoryRepository) UpdateSendResponse(ctx context.Context, id int, sendResponse []byte) error {
+	query := "UPDATE batch_history SET send_response = $1 WHERE id = $2"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, sendResponse, id)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to update batch history send response", zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to update batch history send response: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
+	}
+
+	return nil
+}
+
+// UpdateBatchKey gives an already created batch its batch key along with the JSON
+// SendResponse replayed for it, for a split Send whose last batch turned out to be an
+// earlier one than planned
+func (r *BatchHistoryRepository) UpdateBatchKey(ctx context.Context, id int, batchKey string, sendResponse []byte) error {
+	query := "UPDATE batch_history SET batch_key = $1, send_response = $2 WHERE id = $3"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, batchKey, sendResponse, id)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to update batch history batch key", zap.Int("id", id), zap.Error(err))
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: %v", apperrors.ErrDuplicateBatch, err)
+		}
+		return fmt.Errorf("failed to update batch history batch key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
+	}
+
+	return nil
+}
+
 // Delete removes a batch history record
 func (r *BatchHistoryRepository) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM batch_history WHERE id = $1"
-	result, err := r.db.ExecContext(ctx, query, id)
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "delete", "batch_history", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, id)
+		return err
+	})
 	if err != nil {
 		r.logger.Error("Failed to delete batch history", zap.Int("id", id), zap.Error(err))
 		return fmt.Errorf("failed to delete batch history: %w", err)
@@ -176,7 +370,7 @@ func (r *BatchHistoryRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("batch history not found: %d", id)
+		return fmt.Errorf("%w: %d", apperrors.ErrBatchNotFound, id)
 	}
 
 	r.logger.Info("Deleted batch history", zap.Int("id", id))
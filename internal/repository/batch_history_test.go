@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func newTestBatchHistoryRepository(t *testing.T) (*BatchHistoryRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), logger: zap.NewNop()}
+	return NewBatchHistoryRepository(dbWrapper, zap.NewNop()), mock
+}
+
+func TestBatchHistoryRepository_Create(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	now := time.Now().UTC()
+	batch := &domain.BatchHistory{
+		StartTime:         now,
+		PreviousStartTime: now.Add(-time.Hour),
+		Status:            domain.BatchStatusInProgress,
+		Version:           1,
+	}
+
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(batch.StartTime, batch.PreviousStartTime, batch.Status, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	err := repo.Create(context.Background(), batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, batch.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_Create_UniqueViolation(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	now := time.Now().UTC()
+	batch := &domain.BatchHistory{
+		StartTime:         now,
+		PreviousStartTime: now.Add(-time.Hour),
+		Status:            domain.BatchStatusInProgress,
+		Version:           1,
+	}
+
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "batch_history_previous_start_time_ndx"})
+
+	err := repo.Create(context.Background(), batch)
+
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_Create_OtherError(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WillReturnError(&pq.Error{Code: "23502"})
+
+	err := repo.Create(context.Background(), &domain.BatchHistory{Version: 1})
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, apperrors.ErrDuplicateBatch)
+}
+
+func TestBatchHistoryRepository_Create_SameWindowTwiceIsDuplicateBatch(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	// The database accepts the first insert for the window and rejects the second
+	// through batch_history_previous_start_time_ndx
+	previous := time.Now().UTC().Add(-time.Hour)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(previous.Add(time.Second), previous, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(previous.Add(2*time.Second), previous, domain.BatchStatusInProgress, 1, nil).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "batch_history_previous_start_time_ndx"})
+
+	first := repo.Create(context.Background(), &domain.BatchHistory{
+		StartTime:         previous.Add(time.Second),
+		PreviousStartTime: previous,
+		Status:            domain.BatchStatusInProgress,
+		Version:           1,
+	})
+	second := repo.Create(context.Background(), &domain.BatchHistory{
+		StartTime:         previous.Add(2 * time.Second),
+		PreviousStartTime: previous,
+		Status:            domain.BatchStatusInProgress,
+		Version:           1,
+	})
+
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_TryAcquireSendLock(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(sendLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(sendLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	lock, err := repo.TryAcquireSendLock(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	assert.NoError(t, lock.Release(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_TryAcquireSendLock_Held(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(sendLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	lock, err := repo.TryAcquireSendLock(context.Background())
+
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_GetLatestCompletedStartTime(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	latest := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history WHERE status = \$1`).
+		WithArgs(domain.BatchStatusCompleted).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(latest))
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history WHERE status = \$1`).
+		WithArgs(domain.BatchStatusCompleted).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+	startTime, err := repo.GetLatestCompletedStartTime(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, startTime)
+	assert.Equal(t, latest, *startTime)
+
+	startTime, err = repo.GetLatestCompletedStartTime(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, startTime, "no completed batch yet")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_Create_WithBatchKey(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	now := time.Now().UTC()
+	batchKey := "nightly-2024-01-15"
+	batch := &domain.BatchHistory{
+		StartTime:         now,
+		PreviousStartTime: now.Add(-time.Hour),
+		Status:            domain.BatchStatusInProgress,
+		Version:           1,
+		BatchKey:          &batchKey,
+	}
+
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(batch.StartTime, batch.PreviousStartTime, batch.Status, 1, batchKey).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	require.NoError(t, repo.Create(context.Background(), batch))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_GetByBatchKey(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	end := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version", "batch_key", "send_response"}).
+			AddRow(3, end, end.Add(-time.Hour), domain.BatchStatusCompleted, 2, "nightly", []byte(`{"processedCount":5}`)))
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	batch, err := repo.GetByBatchKey(context.Background(), "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, 3, batch.ID)
+	require.NotNil(t, batch.BatchKey)
+	assert.Equal(t, "nightly", *batch.BatchKey)
+	assert.JSONEq(t, `{"processedCount":5}`, string(batch.SendResponse))
+
+	_, err = repo.GetByBatchKey(context.Background(), "unknown")
+	assert.ErrorIs(t, err, apperrors.ErrBatchNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_UpdateSendResponse(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	sendResponse := []byte(`{"processedCount":5}`)
+	mock.ExpectExec(`UPDATE batch_history SET send_response = \$1 WHERE id = \$2`).
+		WithArgs(sendResponse, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE batch_history SET send_response = \$1 WHERE id = \$2`).
+		WithArgs(sendResponse, 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.UpdateSendResponse(context.Background(), 3, sendResponse))
+	assert.ErrorIs(t, repo.UpdateSendResponse(context.Background(), 4, sendResponse), apperrors.ErrBatchNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_UpdateBatchKey(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	sendResponse := []byte(`{"processedCount":5}`)
+	mock.ExpectExec(`UPDATE batch_history SET batch_key = \$1, send_response = \$2 WHERE id = \$3`).
+		WithArgs("nightly", sendResponse, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE batch_history SET batch_key = \$1, send_response = \$2 WHERE id = \$3`).
+		WithArgs("nightly", sendResponse, 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.UpdateBatchKey(context.Background(), 3, "nightly", sendResponse))
+	assert.ErrorIs(t, repo.UpdateBatchKey(context.Background(), 4, "nightly", sendResponse), apperrors.ErrBatchNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_ClaimForRetry(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	claimQuery := `UPDATE batch_history SET status = \$1, version = version \+ 1 WHERE id = \$2 AND status = \$3`
+	mock.ExpectExec(claimQuery).
+		WithArgs(domain.BatchStatusInProgress, 7, domain.BatchStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(claimQuery).
+		WithArgs(domain.BatchStatusInProgress, 7, domain.BatchStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := repo.ClaimForRetry(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// The batch is in_progress now, so a second claim matches no row
+	claimed, err = repo.ClaimForRetry(context.Background(), 7)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchHistoryRepository_AddLoadedPortfolio(t *testing.T) {
+	repo, mock := newTestBatchHistoryRepository(t)
+
+	appendQuery := `UPDATE batch_history SET loaded_portfolios = array_append\(loaded_portfolios, \$1\) WHERE id = \$2`
+	mock.ExpectExec(appendQuery).
+		WithArgs("PORTFOLIOA", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(appendQuery).
+		WithArgs("PORTFOLIOA", 8).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.AddLoadedPortfolio(context.Background(), 7, "PORTFOLIOA"))
+	assert.ErrorIs(t, repo.AddLoadedPortfolio(context.Background(), 8, "PORTFOLIOA"), apperrors.ErrBatchNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// bulkLoadColumns are the execution columns written by BulkLoad, in copy row order
+var bulkLoadColumns = []string{
+	"execution_service_id", "is_open", "execution_status", "trade_type", "destination",
+	"trade_date", "security_id", "ticker", "portfolio_id", "quantity", "limit_price",
+	"received_timestamp", "sent_timestamp", "last_fill_timestamp", "quantity_filled",
+	"total_amount", "average_price", "ready_to_send_timestamp", "version",
+	"create_trace_id", "create_span_id",
+}
+
+// BulkLoad inserts executions with PostgreSQL COPY over a dedicated pgx connection,
+// which is far faster than per-row inserts for backfills of tens of thousands of
+// executions. It returns the number of rows loaded.
+//
+// BulkLoad is a raw load: executions are written as given, so portfolio IDs must
+// already be resolved, and generated IDs are not written back. Like executions made
+// by Create, one without a ready_to_send_timestamp is ready from the time of the load. The load is a
+// single statement, so a failure (such as a duplicate execution_service_id) loads
+// nothing. It is not bounded by the per-query timeout. BulkLoad returns
+// apperrors.ErrBulkLoadDisabled unless database.bulk_load_enabled is set.
+func (r *ExecutionRepository) BulkLoad(ctx context.Context, executions []domain.Execution) (int, error) {
+	if r.db.bulkLoadDSN == "" {
+		return 0, apperrors.ErrBulkLoadDisabled
+	}
+	if len(executions) == 0 {
+		return 0, nil
+	}
+
+	conn, err := pgx.Connect(ctx, r.db.bulkLoadDSN)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect for bulk load: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(context.WithoutCancel(ctx)); err != nil {
+			r.logger.Warn("Failed to close bulk load connection", zap.Error(err))
+		}
+	}()
+
+	loadedAt := time.Now().UTC()
+	copied, err := conn.CopyFrom(ctx, pgx.Identifier{"execution"}, bulkLoadColumns,
+		pgx.CopyFromSlice(len(executions), func(i int) ([]interface{}, error) {
+			return bulkLoadRow(&executions[i], loadedAt), nil
+		}))
+	if err != nil {
+		r.logger.Error("Failed to bulk load executions", zap.Int("count", len(executions)), zap.Error(err))
+		return 0, fmt.Errorf("failed to bulk load executions: %w", err)
+	}
+
+	r.logger.Info("Bulk loaded executions", zap.Int64("count", copied))
+	return int(copied), nil
+}
+
+// bulkLoadRow returns the copy row for an execution in bulkLoadColumns order. A zero
+// ready_to_send_timestamp becomes loadedAt and a zero version the initial version 1.
+func bulkLoadRow(e *domain.Execution, loadedAt time.Time) []interface{} {
+	readyToSend := e.ReadyToSendTimestamp
+	if readyToSend.IsZero() {
+		readyToSend = loadedAt
+	}
+	version := e.Version
+	if version == 0 {
+		version = 1
+	}
+
+	return []interface{}{
+		e.ExecutionServiceID, e.IsOpen, e.ExecutionStatus, e.TradeType, e.Destination,
+		e.TradeDate, e.SecurityID, e.Ticker, e.PortfolioID, numeric(e.Quantity), optionalNumeric(e.LimitPrice),
+		e.ReceivedTimestamp, e.SentTimestamp, e.LastFillTimestamp, numeric(e.QuantityFilled),
+		numeric(e.TotalAmount), numeric(e.AveragePrice), readyToSend, version,
+		e.CreateTraceID, e.CreateSpanID,
+	}
+}
+
+// numeric converts a decimal for COPY, whose binary numeric encoding takes neither
+// strings nor driver.Valuers
+func numeric(d domain.Decimal) pgtype.Numeric {
+	var n pgtype.Numeric
+	_ = n.Scan(d.String()) // a Decimal always formats as a valid numeric
+	return n
+}
+
+// optionalNumeric is numeric for a nullable decimal; nil becomes NULL
+func optionalNumeric(d *domain.Decimal) pgtype.Numeric {
+	if d == nil {
+		return pgtype.Numeric{}
+	}
+	return numeric(*d)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestExecutionRepository_BulkLoad_Disabled(t *testing.T) {
+	repo := NewExecutionRepository(&DB{logger: zap.NewNop()}, zap.NewNop())
+
+	count, err := repo.BulkLoad(context.Background(), []domain.Execution{{ExecutionServiceID: 1}})
+
+	assert.ErrorIs(t, err, apperrors.ErrBulkLoadDisabled)
+	assert.Zero(t, count)
+}
+
+func TestBulkLoadRow(t *testing.T) {
+	portfolioID := "PORTFOLIO123456789012"
+	now := time.Now()
+	execution := &domain.Execution{
+		ExecutionServiceID:   123,
+		ExecutionStatus:      "FULL",
+		TradeType:            "BUY",
+		PortfolioID:          &portfolioID,
+		Quantity:             domain.DecimalFromFloat(100),
+		ReadyToSendTimestamp: now,
+		Version:              1,
+	}
+
+	row := bulkLoadRow(execution, now.Add(time.Hour))
+
+	require.Len(t, row, len(bulkLoadColumns))
+	assert.Equal(t, 123, row[0])
+	assert.Equal(t, &portfolioID, row[8])
+	assert.Equal(t, now, row[17])
+	assert.Equal(t, 1, row[18])
+}
+
+func TestBulkLoadRow_DefaultsReadyToSendAndVersion(t *testing.T) {
+	loadedAt := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	row := bulkLoadRow(&domain.Execution{ExecutionServiceID: 123}, loadedAt)
+
+	// A zero timestamp would put the execution before every send window
+	assert.Equal(t, loadedAt, row[17])
+	assert.Equal(t, 1, row[18])
+}
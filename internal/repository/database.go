@@ -2,25 +2,54 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
 	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 )
 
-// DB wraps sqlx.DB with additional functionality
+// pqUniqueViolation is the PostgreSQL SQLSTATE for unique_violation
+const pqUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
+
+// DB wraps sqlx.DB with additional functionality. The embedded DB is the primary;
+// replica, when configured, serves read-only reporting queries.
 type DB struct {
 	*sqlx.DB
-	logger *zap.Logger
+	replica      *sqlx.DB
+	queryTimeout time.Duration
+	metrics      observability.MetricsRecorder
+	logger       *zap.Logger
+
+	// prepareStatements enables the prepared statement cache for hot queries. It is
+	// off for DBs built directly in tests so sqlmock expectations stay plain queries.
+	prepareStatements bool
+	stmtMu            sync.Mutex
+	stmts             map[string]*sqlx.NamedStmt
+
+	// bulkLoadDSN is the primary's connection string for COPY-based bulk loads;
+	// empty when bulk loading is disabled
+	bulkLoadDSN string
 }
 
 // NewPostgresDB creates a new PostgreSQL database connection
@@ -31,10 +60,7 @@ func NewPostgresDB(cfg config.Database) (*DB, error) {
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetConnMaxIdleTime(2 * time.Minute)
+	configurePool(db, cfg)
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
@@ -71,14 +97,66 @@ func NewPostgresDB(cfg config.Database) (*DB, error) {
 	}
 	// --- End migration ---
 
+	var bulkLoadDSN string
+	if cfg.BulkLoadEnabled {
+		bulkLoadDSN = cfg.ConnectionString()
+	}
+
+	var replica *sqlx.DB
+	if cfg.ReplicaDSN != "" {
+		replica, err = sqlx.Connect("postgres", cfg.ReplicaDSN)
+		if err != nil {
+			db.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+		configurePool(replica, cfg)
+	}
+
 	return &DB{
-		DB:     db,
-		logger: zap.NewNop(), // Will be replaced by caller
+		DB:           db,
+		replica:      replica,
+		queryTimeout: cfg.QueryTimeout,
+		logger:       zap.NewNop(), // Will be replaced by caller
+
+		prepareStatements: true,
+		bulkLoadDSN:       bulkLoadDSN,
 	}, nil
 }
 
-// Close closes the database connection
+// configurePool applies the configured connection pool sizing to db
+func configurePool(db *sqlx.DB, cfg config.Database) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// reader returns the connection pool for read-only queries: the replica when
+// configured, otherwise the primary
+func (db *DB) reader() *sqlx.DB {
+	if db.replica != nil {
+		return db.replica
+	}
+	return db.DB
+}
+
+// Close closes cached prepared statements and the database connections
 func (db *DB) Close() error {
+	db.stmtMu.Lock()
+	for query, stmt := range db.stmts {
+		if err := stmt.Close(); err != nil {
+			db.logger.Warn("Failed to close prepared statement", zap.String("query", query), zap.Error(err))
+		}
+	}
+	db.stmts = nil
+	db.stmtMu.Unlock()
+
+	if db.replica != nil {
+		if err := db.replica.Close(); err != nil {
+			db.DB.Close() //nolint:errcheck
+			return err
+		}
+	}
 	return db.DB.Close()
 }
 
@@ -87,17 +165,195 @@ func (db *DB) SetLogger(logger *zap.Logger) {
 	db.logger = logger
 }
 
-// HealthCheck performs a health check on the database
+// SetMetrics enables database operation metrics; nil disables them
+func (db *DB) SetMetrics(metrics observability.MetricsRecorder) {
+	db.metrics = metrics
+}
+
+// Database operation metric statuses
+const (
+	operationStatusSuccess = "success"
+	operationStatusError   = "error"
+	operationStatusTimeout = "timeout"
+)
+
+// observeQuery runs fn with ctx bounded by the configured query timeout and records the
+// outcome in the database operation metrics. A query cut off by that timeout returns
+// apperrors.ErrQueryTimeout in place of the driver's cancellation error; deadlines set
+// by the caller are left to surface as they are.
+func (db *DB) observeQuery(ctx context.Context, operation, table string, fn func(ctx context.Context) error) error {
+	if db.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, db.queryTimeout, apperrors.ErrQueryTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+
+	status := operationStatusSuccess
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		status = operationStatusError
+		if errors.Is(context.Cause(ctx), apperrors.ErrQueryTimeout) {
+			status = operationStatusTimeout
+			err = fmt.Errorf("%w after %s: %v", apperrors.ErrQueryTimeout, db.queryTimeout, err)
+		}
+	}
+	if db.metrics != nil {
+		db.metrics.RecordDatabaseOperation(ctx, operation, table, status, time.Since(start))
+	}
+
+	return err
+}
+
+// preparedNamed returns the cached prepared statement for a named query on the
+// primary, preparing it on first use. The statement is safe for concurrent use.
+func (db *DB) preparedNamed(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if db.stmts == nil {
+		db.stmts = make(map[string]*sqlx.NamedStmt)
+	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
+
+// preparedNamedQueryRow is namedQueryRow through a cached prepared statement, which
+// skips binding the named query and having the server parse and plan it on every
+// call. It falls back to namedQueryRow when statement caching is off or the
+// statement cannot be prepared.
+func (db *DB) preparedNamedQueryRow(ctx context.Context, query string, arg interface{}, dest ...interface{}) error {
+	if !db.prepareStatements {
+		return db.namedQueryRow(ctx, query, arg, dest...)
+	}
+
+	stmt, err := db.preparedNamed(ctx, query)
+	if err != nil {
+		db.logger.Warn("Failed to prepare statement, running unprepared", zap.Error(err))
+		return db.namedQueryRow(ctx, query, arg, dest...)
+	}
+
+	return stmt.QueryRowxContext(ctx, arg).Scan(dest...)
+}
+
+// namedQueryRow runs a named query returning at most one row, such as an INSERT ...
+// RETURNING, and scans that row into dest
+func (db *DB) namedQueryRow(ctx context.Context, query string, arg interface{}, dest ...interface{}) error {
+	rows, err := db.NamedQueryContext(ctx, query, arg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			db.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan returned row: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+// MigrationVersion returns the applied schema migration version and whether the
+// last migration left the schema dirty
+func (db *DB) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var result struct {
+		Version uint `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+	if err := db.GetContext(ctx, &result, "SELECT version, dirty FROM schema_migrations LIMIT 1"); err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return result.Version, result.Dirty, nil
+}
+
+// AdvisoryLock is a held PostgreSQL session-level advisory lock. The lock belongs to
+// the pinned connection, so it must be released through Release.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock attempts to take the advisory lock for key without blocking.
+// It returns a nil lock when another session holds it.
+func (db *DB) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for advisory lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+
+	if !acquired {
+		conn.Close() //nolint:errcheck
+		return nil, nil
+	}
+
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// advisoryUnlockTimeout bounds the unlock in Release, which runs detached from the
+// caller's context
+const advisoryUnlockTimeout = 5 * time.Second
+
+// Release unlocks the advisory lock and returns its connection to the pool. The unlock
+// runs even when ctx is already cancelled, such as after a client timeout. If it fails,
+// the connection may still hold the lock, so it is discarded rather than pooled, which
+// ends its session and with it the lock.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	defer l.conn.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), advisoryUnlockTimeout)
+	defer cancel()
+
+	if _, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		_ = l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck performs a health check on the database and, when configured, the read replica
 func (db *DB) HealthCheck() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var result int
-	err := db.QueryRowContext(ctx, "SELECT 1").Scan(&result)
-	if err != nil {
+	if err := selectOne(ctx, db.DB); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
 
+	if db.replica != nil {
+		if err := selectOne(ctx, db.replica); err != nil {
+			return fmt.Errorf("read replica health check failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// selectOne runs the health check query against a single connection pool
+func selectOne(ctx context.Context, db *sqlx.DB) error {
+	var result int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&result); err != nil {
+		return err
+	}
+
 	if result != 1 {
 		return fmt.Errorf("unexpected health check result: %d", result)
 	}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// newTestDatabaseMetrics builds unregistered database operation metrics
+func newTestDatabaseMetrics() *observability.BusinessMetrics {
+	return &observability.BusinessMetrics{
+		DatabaseOperations: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_database_operations_total"},
+			[]string{"operation", "table", "status"}),
+		DatabaseLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_database_operation_duration_seconds"},
+			[]string{"operation", "table"}),
+	}
+}
+
+func TestDB_QueryTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	metrics := newTestDatabaseMetrics()
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), queryTimeout: 20 * time.Millisecond, logger: zap.NewNop()}
+	dbWrapper.SetMetrics(observability.NewPrometheusRecorder(metrics))
+	repo := NewExecutionRepository(dbWrapper, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE id = \$1 AND deleted_at IS NULL`).
+		WithArgs(1).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	start := time.Now()
+	_, err = repo.GetByID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, apperrors.ErrQueryTimeout)
+	assert.ErrorContains(t, err, "after 20ms")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DatabaseOperations.WithLabelValues("select", "execution", "timeout")))
+}
+
+func TestDB_QueryTimeout_CallerDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	metrics := newTestDatabaseMetrics()
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), queryTimeout: time.Second, logger: zap.NewNop()}
+	dbWrapper.SetMetrics(observability.NewPrometheusRecorder(metrics))
+	repo := NewExecutionRepository(dbWrapper, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE id = \$1 AND deleted_at IS NULL`).
+		WithArgs(1).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = repo.GetByID(ctx, 1)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, apperrors.ErrQueryTimeout)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DatabaseOperations.WithLabelValues("select", "execution", "error")))
+}
+
+func TestDB_ObserveQuery_RecordsStatus(t *testing.T) {
+	metrics := newTestDatabaseMetrics()
+	dbWrapper := &DB{logger: zap.NewNop()}
+	dbWrapper.SetMetrics(observability.NewPrometheusRecorder(metrics))
+	ctx := context.Background()
+
+	require.NoError(t, dbWrapper.observeQuery(ctx, "update", "batch_history", func(context.Context) error { return nil }))
+	assert.Error(t, dbWrapper.observeQuery(ctx, "update", "batch_history", func(context.Context) error {
+		return errors.New("connection reset")
+	}))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DatabaseOperations.WithLabelValues("update", "batch_history", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DatabaseOperations.WithLabelValues("update", "batch_history", "error")))
+}
+
+func TestDB_HealthCheck_ReadReplica(t *testing.T) {
+	primaryDB, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primaryDB.Close() //nolint:errcheck
+	replicaDB, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replicaDB.Close() //nolint:errcheck
+
+	dbWrapper := &DB{
+		DB:      sqlx.NewDb(primaryDB, "postgres"),
+		replica: sqlx.NewDb(replicaDB, "postgres"),
+		logger:  zap.NewNop(),
+	}
+
+	primaryMock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+	replicaMock.ExpectQuery("SELECT 1").WillReturnError(errors.New("connection refused"))
+
+	err = dbWrapper.HealthCheck()
+
+	assert.ErrorContains(t, err, "read replica health check failed")
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestAdvisoryLock_Release(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := &DB{DB: sqlx.NewDb(sqlDB, "postgres"), logger: zap.NewNop()}
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(42).
+		WillDelayFor(10 * time.Millisecond). // lets sqlmock observe a cancelled context
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	lock, err := db.TryAdvisoryLock(context.Background(), 42)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	// A request that timed out still releases the lock it held
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, lock.Release(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdvisoryLock_Release_UnlockFailureDiscardsConnection(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := &DB{DB: sqlx.NewDb(sqlDB, "postgres"), logger: zap.NewNop()}
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WithArgs(42).
+		WillReturnError(errors.New("connection reset"))
+	// The connection still holding the lock is closed, not returned to the pool
+	mock.ExpectClose()
+
+	lock, err := db.TryAdvisoryLock(context.Background(), 42)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	assert.ErrorContains(t, lock.Release(context.Background()), "failed to release advisory lock")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
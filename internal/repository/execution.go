@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -11,9 +12,18 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
 )
 
+// executionColumns lists every execution column, in the order execution_archive holds
+// them; a column added to execution must be added here and to execution_archive
+const executionColumns = `id, execution_service_id, is_open, execution_status, trade_type, destination,
+	trade_date, security_id, ticker, portfolio_id, quantity, limit_price,
+	received_timestamp, sent_timestamp, last_fill_timestamp, quantity_filled,
+	total_amount, average_price, ready_to_send_timestamp, version, deleted_at,
+	create_trace_id, create_span_id`
+
 // ExecutionRepository handles database operations for executions
 type ExecutionRepository struct {
 	db     *DB
@@ -28,7 +38,9 @@ func NewExecutionRepository(db *DB, logger *zap.Logger) *ExecutionRepository {
 	}
 }
 
-// Create inserts a new execution record
+// Create inserts a new execution record and, in the same statement, clears any skip
+// stored for its executionServiceId. The insert runs through a cached prepared
+// statement since it is on the bulk ingestion path.
 func (r *ExecutionRepository) Create(ctx context.Context, execution *domain.Execution) error {
 	// Start OpenTelemetry span for database operation
 	tracer := otel.Tracer("globeco-allocation-service")
@@ -46,19 +58,29 @@ func (r *ExecutionRepository) Create(ctx context.Context, execution *domain.Exec
 	)
 
 	query := `
-		INSERT INTO execution (
-			execution_service_id, is_open, execution_status, trade_type, destination,
-			trade_date, security_id, ticker, portfolio_id, quantity, limit_price,
-			received_timestamp, sent_timestamp, last_fill_timestamp, quantity_filled,
-			total_amount, average_price, ready_to_send_timestamp, version
-		) VALUES (
-			:execution_service_id, :is_open, :execution_status, :trade_type, :destination,
-			:trade_date, :security_id, :ticker, :portfolio_id, :quantity, :limit_price,
-			:received_timestamp, :sent_timestamp, :last_fill_timestamp, :quantity_filled,
-			:total_amount, :average_price, :ready_to_send_timestamp, :version
-		) RETURNING id`
-
-	rows, err := r.db.NamedQueryContext(ctx, query, execution)
+		WITH created AS (
+			INSERT INTO execution (
+				execution_service_id, is_open, execution_status, trade_type, destination,
+				trade_date, security_id, ticker, portfolio_id, quantity, limit_price,
+				received_timestamp, sent_timestamp, last_fill_timestamp, quantity_filled,
+				total_amount, average_price, ready_to_send_timestamp, version,
+				create_trace_id, create_span_id
+			) VALUES (
+				:execution_service_id, :is_open, :execution_status, :trade_type, :destination,
+				:trade_date, :security_id, :ticker, :portfolio_id, :quantity, :limit_price,
+				:received_timestamp, :sent_timestamp, :last_fill_timestamp, :quantity_filled,
+				:total_amount, :average_price, :ready_to_send_timestamp, :version,
+				:create_trace_id, :create_span_id
+			) RETURNING id, execution_service_id
+		), cleared AS (
+			DELETE FROM skipped_execution
+			WHERE execution_service_id IN (SELECT execution_service_id FROM created)
+		)
+		SELECT id FROM created`
+
+	err := r.db.observeQuery(ctx, "insert", "execution", func(ctx context.Context) error {
+		return r.db.preparedNamedQueryRow(ctx, query, execution, &execution.ID)
+	})
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "database insert failed")
@@ -68,19 +90,6 @@ func (r *ExecutionRepository) Create(ctx context.Context, execution *domain.Exec
 			zap.String("span_id", span.SpanContext().SpanID().String()))
 		return fmt.Errorf("failed to create execution: %w", err)
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			r.logger.Error("failed to close rows", zap.Error(err))
-		}
-	}()
-
-	if rows.Next() {
-		if err := rows.Scan(&execution.ID); err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "failed to scan execution ID")
-			return fmt.Errorf("failed to scan execution ID: %w", err)
-		}
-	}
 
 	// Add success attributes
 	span.SetAttributes(attribute.Int("execution.id", execution.ID))
@@ -110,14 +119,16 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id int) (*domain.Exec
 	)
 
 	var execution domain.Execution
-	query := "SELECT * FROM execution WHERE id = $1"
+	query := "SELECT * FROM execution WHERE id = $1 AND deleted_at IS NULL"
 
-	err := r.db.GetContext(ctx, &execution, query, id)
+	err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &execution, query, id)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			span.SetStatus(codes.Ok, "execution not found")
 			span.SetAttributes(attribute.Bool("found", false))
-			return nil, fmt.Errorf("execution not found: %d", id)
+			return nil, fmt.Errorf("%w: %d", apperrors.ErrExecutionNotFound, id)
 		}
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "database select failed")
@@ -139,15 +150,24 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id int) (*domain.Exec
 	return &execution, nil
 }
 
-// GetByExecutionServiceID retrieves an execution by execution service ID
-func (r *ExecutionRepository) GetByExecutionServiceID(ctx context.Context, executionServiceID int) (*domain.Execution, error) {
+// GetByExecutionServiceID retrieves an execution by execution service ID. Soft-deleted
+// and archived executions, whose executionServiceId is still taken, are only returned
+// when includeRemoved is true.
+func (r *ExecutionRepository) GetByExecutionServiceID(ctx context.Context, executionServiceID int, includeRemoved bool) (*domain.Execution, error) {
 	var execution domain.Execution
-	query := "SELECT * FROM execution WHERE execution_service_id = $1"
+	query := "SELECT " + executionColumns + " FROM execution WHERE execution_service_id = $1"
+	if includeRemoved {
+		query += " UNION ALL SELECT " + executionColumns + " FROM execution_archive WHERE execution_service_id = $1 LIMIT 1"
+	} else {
+		query += " AND deleted_at IS NULL"
+	}
 
-	err := r.db.GetContext(ctx, &execution, query, executionServiceID)
+	err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &execution, query, executionServiceID)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("execution not found for service ID: %d", executionServiceID)
+			return nil, fmt.Errorf("%w for service ID: %d", apperrors.ErrExecutionNotFound, executionServiceID)
 		}
 		r.logger.Error("Failed to get execution by service ID", zap.Int("execution_service_id", executionServiceID), zap.Error(err))
 		return nil, fmt.Errorf("failed to get execution: %w", err)
@@ -156,21 +176,30 @@ func (r *ExecutionRepository) GetByExecutionServiceID(ctx context.Context, execu
 	return &execution, nil
 }
 
-// List retrieves executions with pagination
-func (r *ExecutionRepository) List(ctx context.Context, limit, offset int) ([]domain.Execution, int, error) {
-	var executions []domain.Execution
-	var totalCount int
+// List retrieves executions matching the filter with pagination
+func (r *ExecutionRepository) List(ctx context.Context, filter domain.ExecutionFilter, sort domain.ExecutionSort, limit, offset int) ([]domain.Execution, int, error) {
+	orderBy, err := buildExecutionOrder(sort)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// Get total count
-	countQuery := "SELECT COUNT(*) FROM execution"
-	if err := r.db.GetContext(ctx, &totalCount, countQuery); err != nil {
-		r.logger.Error("Failed to get execution count", zap.Error(err))
-		return nil, 0, fmt.Errorf("failed to get execution count: %w", err)
+	totalCount, err := r.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
+	// The count tells how many rows the page holds, so the scan appends into a single
+	// allocation instead of regrowing the slice as rows arrive
+	executions := make([]domain.Execution, 0, max(0, min(limit, totalCount-offset)))
+
 	// Get executions with pagination
-	query := "SELECT * FROM execution ORDER BY id DESC LIMIT $1 OFFSET $2"
-	if err := r.db.SelectContext(ctx, &executions, query, limit, offset); err != nil {
+	where, args := buildExecutionFilter(filter)
+	query := fmt.Sprintf("SELECT * FROM execution%s%s LIMIT $%d OFFSET $%d", where, orderBy, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &executions, query, args...)
+	}); err != nil {
 		r.logger.Error("Failed to list executions", zap.Error(err))
 		return nil, 0, fmt.Errorf("failed to list executions: %w", err)
 	}
@@ -178,16 +207,166 @@ func (r *ExecutionRepository) List(ctx context.Context, limit, offset int) ([]do
 	return executions, totalCount, nil
 }
 
-// GetForBatch retrieves executions ready for batch processing
+// Count returns how many executions match the filter without fetching any rows
+func (r *ExecutionRepository) Count(ctx context.Context, filter domain.ExecutionFilter) (int, error) {
+	where, args := buildExecutionFilter(filter)
+
+	var count int
+	query := "SELECT COUNT(*) FROM execution" + where
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &count, query, args...)
+	}); err != nil {
+		r.logger.Error("Failed to get execution count", zap.Error(err))
+		return 0, fmt.Errorf("failed to get execution count: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetStats returns aggregate counts for executions matching the filter. An execution
+// counts as sent once its ready_to_send_timestamp falls before the latest batch start time.
+func (r *ExecutionRepository) GetStats(ctx context.Context, filter domain.ExecutionFilter) (*domain.ExecutionStats, error) {
+	where, args := buildExecutionFilter(filter)
+
+	stats := &domain.ExecutionStats{
+		ByTradeType:   make(map[string]int),
+		ByDestination: make(map[string]int),
+	}
+
+	var totals struct {
+		Total int `db:"total"`
+		Sent  int `db:"sent"`
+	}
+	totalsQuery := `
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE ready_to_send_timestamp < (SELECT COALESCE(MAX(start_time), '-infinity') FROM batch_history)) AS sent
+		FROM execution` + where
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &totals, totalsQuery, args...)
+	}); err != nil {
+		r.logger.Error("Failed to get execution totals", zap.Error(err))
+		return nil, fmt.Errorf("failed to get execution totals: %w", err)
+	}
+	stats.TotalExecutions = totals.Total
+	stats.SentCount = totals.Sent
+	stats.UnsentCount = totals.Total - totals.Sent
+
+	groupings := []struct {
+		column string
+		counts map[string]int
+	}{
+		{column: "trade_type", counts: stats.ByTradeType},
+		{column: "destination", counts: stats.ByDestination},
+	}
+	for _, grouping := range groupings {
+		var groups []struct {
+			Key   string `db:"key"`
+			Count int    `db:"count"`
+		}
+		query := fmt.Sprintf("SELECT %s AS key, COUNT(*) AS count FROM execution%s GROUP BY %s", grouping.column, where, grouping.column)
+		if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+			return r.db.reader().SelectContext(ctx, &groups, query, args...)
+		}); err != nil {
+			r.logger.Error("Failed to get execution counts", zap.String("group_by", grouping.column), zap.Error(err))
+			return nil, fmt.Errorf("failed to get execution counts by %s: %w", grouping.column, err)
+		}
+		for _, g := range groups {
+			grouping.counts[g.Key] = g.Count
+		}
+	}
+
+	return stats, nil
+}
+
+// buildExecutionFilter returns the WHERE clause (with a leading space, or empty) and
+// positional arguments for the given filter
+func buildExecutionFilter(filter domain.ExecutionFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.TradeDateFrom != nil {
+		args = append(args, *filter.TradeDateFrom)
+		conditions = append(conditions, fmt.Sprintf("trade_date >= $%d", len(args)))
+	}
+	if filter.TradeDateTo != nil {
+		args = append(args, *filter.TradeDateTo)
+		conditions = append(conditions, fmt.Sprintf("trade_date <= $%d", len(args)))
+	}
+	if filter.TickerPrefix != "" {
+		// A trailing wildcard on lower(ticker) can use execution_ticker_prefix_ndx; ILIKE or
+		// a leading wildcard would scan the table
+		args = append(args, escapeLike(filter.TickerPrefix))
+		conditions = append(conditions, fmt.Sprintf(`lower(ticker) LIKE lower($%d) || '%%'`, len(args)))
+	}
+	if filter.Destination != "" {
+		args = append(args, filter.Destination)
+		conditions = append(conditions, fmt.Sprintf("destination = $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// executionSortColumns maps the API sort fields to their columns. Only these columns
+// are ever interpolated into ORDER BY.
+var executionSortColumns = map[string]string{
+	domain.SortByID:                "id",
+	domain.SortByTradeDate:         "trade_date",
+	domain.SortByReceivedTimestamp: "received_timestamp",
+	domain.SortByQuantity:          "quantity",
+}
+
+// buildExecutionOrder returns the ORDER BY clause (with a leading space) for sort. Ties
+// are broken by id in the same direction so pages never overlap or skip rows.
+func buildExecutionOrder(sort domain.ExecutionSort) (string, error) {
+	field := sort.Field
+	if field == "" {
+		field = domain.SortByID
+	}
+	column, ok := executionSortColumns[field]
+	if !ok {
+		return "", fmt.Errorf("unsupported sort field %q", sort.Field)
+	}
+
+	direction := "DESC"
+	if sort.Ascending {
+		direction = "ASC"
+	}
+	if column == "id" {
+		return " ORDER BY id " + direction, nil
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction), nil
+}
+
+// escapeLike escapes LIKE wildcards so s matches literally. The backslash is
+// PostgreSQL's default LIKE escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// forBatchWhere selects the live executions of a batch window [$1, $2)
+const forBatchWhere = `
+		WHERE ready_to_send_timestamp >= $1 
+		AND ready_to_send_timestamp < $2
+		AND deleted_at IS NULL`
+
+// GetForBatch retrieves executions ready for batch processing, ordered by
+// ready_to_send_timestamp with id breaking ties, so executions made ready together
+// always come back in the same order
 func (r *ExecutionRepository) GetForBatch(ctx context.Context, startTime, endTime time.Time) ([]domain.Execution, error) {
 	var executions []domain.Execution
 	query := `
-		SELECT * FROM execution 
-		WHERE ready_to_send_timestamp >= $1 
-		AND ready_to_send_timestamp < $2
-		ORDER BY ready_to_send_timestamp ASC`
+		SELECT * FROM execution` + forBatchWhere + `
+		ORDER BY ready_to_send_timestamp ASC, id ASC`
 
-	if err := r.db.SelectContext(ctx, &executions, query, startTime, endTime); err != nil {
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.SelectContext(ctx, &executions, query, startTime, endTime)
+	}); err != nil {
 		r.logger.Error("Failed to get executions for batch",
 			zap.Time("start_time", startTime),
 			zap.Time("end_time", endTime),
@@ -203,6 +382,174 @@ func (r *ExecutionRepository) GetForBatch(ctx context.Context, startTime, endTim
 	return executions, nil
 }
 
+// ListForBatch retrieves one page of the executions GetForBatch returns for the window
+// [startTime, endTime), in the same order, with the window's total count
+func (r *ExecutionRepository) ListForBatch(ctx context.Context, startTime, endTime time.Time, limit, offset int) ([]domain.Execution, int, error) {
+	var totalCount int
+	countQuery := "SELECT COUNT(*) FROM execution" + forBatchWhere
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &totalCount, countQuery, startTime, endTime)
+	}); err != nil {
+		r.logger.Error("Failed to count executions for batch", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to count executions for batch: %w", err)
+	}
+
+	executions := make([]domain.Execution, 0, max(0, min(limit, totalCount-offset)))
+	query := `
+		SELECT * FROM execution` + forBatchWhere + `
+		ORDER BY ready_to_send_timestamp ASC, id ASC
+		LIMIT $3 OFFSET $4`
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &executions, query, startTime, endTime, limit, offset)
+	}); err != nil {
+		r.logger.Error("Failed to list executions for batch", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list executions for batch: %w", err)
+	}
+
+	return executions, totalCount, nil
+}
+
+// ReadyTimestampAt returns the ready_to_send_timestamp of the execution at the
+// zero-based position offset within [startTime, endTime), in batch order, or nil
+// when the window holds no more than offset executions
+func (r *ExecutionRepository) ReadyTimestampAt(ctx context.Context, startTime, endTime time.Time, offset int) (*time.Time, error) {
+	query := `
+		SELECT ready_to_send_timestamp FROM execution
+		WHERE ready_to_send_timestamp >= $1
+		AND ready_to_send_timestamp < $2
+		AND deleted_at IS NULL
+		ORDER BY ready_to_send_timestamp ASC, id ASC
+		LIMIT 1 OFFSET $3`
+
+	var timestamp time.Time
+	err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &timestamp, query, startTime, endTime, offset)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get ready timestamp at offset",
+			zap.Time("start_time", startTime),
+			zap.Time("end_time", endTime),
+			zap.Int("offset", offset),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to get ready timestamp at offset: %w", err)
+	}
+
+	return &timestamp, nil
+}
+
+// NextReadyTimestampAfter returns the earliest ready_to_send_timestamp strictly
+// after startTime and before endTime, or nil when there is none
+func (r *ExecutionRepository) NextReadyTimestampAfter(ctx context.Context, startTime, endTime time.Time) (*time.Time, error) {
+	query := `
+		SELECT MIN(ready_to_send_timestamp) FROM execution
+		WHERE ready_to_send_timestamp > $1
+		AND ready_to_send_timestamp < $2
+		AND deleted_at IS NULL`
+
+	var timestamp sql.NullTime
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &timestamp, query, startTime, endTime)
+	}); err != nil {
+		r.logger.Error("Failed to get next ready timestamp",
+			zap.Time("start_time", startTime),
+			zap.Time("end_time", endTime),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to get next ready timestamp: %w", err)
+	}
+	if !timestamp.Valid {
+		return nil, nil
+	}
+
+	return &timestamp.Time, nil
+}
+
+// TradeDateMismatches returns the IDs, newest first, of executions among the sampleSize
+// most recent whose trade_date is not the calendar date of sent_timestamp in timezone.
+// The rule is checked here rather than by a constraint because the timezone is
+// configuration, not schema.
+func (r *ExecutionRepository) TradeDateMismatches(ctx context.Context, timezone string, sampleSize int) ([]int, error) {
+	query := `
+		SELECT id FROM (
+			SELECT id, trade_date, sent_timestamp FROM execution ORDER BY id DESC LIMIT $2
+		) recent
+		WHERE trade_date <> (sent_timestamp AT TIME ZONE $1)::date
+		ORDER BY id DESC`
+
+	var ids []int
+	if err := r.db.observeQuery(ctx, "select", "execution", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &ids, query, timezone, sampleSize)
+	}); err != nil {
+		r.logger.Error("Failed to check trade dates", zap.Error(err))
+		return nil, fmt.Errorf("failed to check trade dates: %w", err)
+	}
+
+	return ids, nil
+}
+
+// StreamForBatch calls fn for each execution ready for batch processing in
+// [startTime, endTime), in the same order as GetForBatch, without loading the whole
+// window into memory. Iteration stops at the first error returned by fn. The stream
+// runs as long as the caller's context allows; the per-query timeout is not applied
+// because the batch file is written while rows are read.
+func (r *ExecutionRepository) StreamForBatch(ctx context.Context, startTime, endTime time.Time, fn func(domain.Execution) error) error {
+	return r.streamForBatch(ctx, startTime, endTime, "ready_to_send_timestamp ASC, id ASC", fn)
+}
+
+// StreamForBatchByPortfolio is StreamForBatch with each portfolio's executions yielded
+// together, so a file per portfolio can be written one at a time
+func (r *ExecutionRepository) StreamForBatchByPortfolio(ctx context.Context, startTime, endTime time.Time, fn func(domain.Execution) error) error {
+	return r.streamForBatch(ctx, startTime, endTime, "portfolio_id ASC NULLS FIRST, ready_to_send_timestamp ASC, id ASC", fn)
+}
+
+// streamForBatch streams the batch window [startTime, endTime) in the given order
+func (r *ExecutionRepository) streamForBatch(ctx context.Context, startTime, endTime time.Time, orderBy string, fn func(domain.Execution) error) error {
+	query := `
+		SELECT * FROM execution 
+		WHERE ready_to_send_timestamp >= $1 
+		AND ready_to_send_timestamp < $2
+		AND deleted_at IS NULL
+		ORDER BY ` + orderBy
+
+	rows, err := r.db.QueryxContext(ctx, query, startTime, endTime)
+	if err != nil {
+		r.logger.Error("Failed to stream executions for batch",
+			zap.Time("start_time", startTime),
+			zap.Time("end_time", endTime),
+			zap.Error(err))
+		return fmt.Errorf("failed to stream executions for batch: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	count := 0
+	for rows.Next() {
+		var execution domain.Execution
+		if err := rows.StructScan(&execution); err != nil {
+			return fmt.Errorf("failed to scan execution: %w", err)
+		}
+		if err := fn(execution); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream executions for batch: %w", err)
+	}
+
+	r.logger.Info("Streamed executions for batch",
+		zap.Int("count", count),
+		zap.Time("start_time", startTime),
+		zap.Time("end_time", endTime))
+
+	return nil
+}
+
 // Update updates an execution record
 func (r *ExecutionRepository) Update(ctx context.Context, execution *domain.Execution) error {
 	query := `
@@ -227,7 +574,11 @@ func (r *ExecutionRepository) Update(ctx context.Context, execution *domain.Exec
 			version = :version + 1
 		WHERE id = :id AND version = :version`
 
-	result, err := r.db.NamedExecContext(ctx, query, execution)
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "execution", func(ctx context.Context) (err error) {
+		result, err = r.db.NamedExecContext(ctx, query, execution)
+		return err
+	})
 	if err != nil {
 		r.logger.Error("Failed to update execution", zap.Int("id", execution.ID), zap.Error(err))
 		return fmt.Errorf("failed to update execution: %w", err)
@@ -239,7 +590,7 @@ func (r *ExecutionRepository) Update(ctx context.Context, execution *domain.Exec
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("execution not found or version conflict: %d", execution.ID)
+		return fmt.Errorf("%w: execution %d not found or modified concurrently", apperrors.ErrVersionConflict, execution.ID)
 	}
 
 	execution.Version++
@@ -247,10 +598,103 @@ func (r *ExecutionRepository) Update(ctx context.Context, execution *domain.Exec
 	return nil
 }
 
-// Delete removes an execution record
+// DeleteByFilter soft-deletes every live execution matching filter in a single
+// statement and returns how many were deleted. Nothing is deleted, and
+// apperrors.ErrExecutionsAlreadySent is returned, if any match falls inside a batch
+// window, since those have been or are being loaded into Portfolio Accounting.
+func (r *ExecutionRepository) DeleteByFilter(ctx context.Context, filter domain.ExecutionFilter) (int, error) {
+	filter.IncludeDeleted = false
+	where, args := buildExecutionFilter(filter)
+
+	// The guard counts matches inside any window recorded in batch_history, whatever its
+	// status: a completed batch was loaded, an in-progress one is being sent and a failed
+	// one will be retried. A Send that records its window after this statement starts is
+	// not seen by the guard.
+	query := `
+		WITH matched AS (
+			SELECT id, ready_to_send_timestamp FROM execution` + where + `
+		), sent AS (
+			SELECT COUNT(*) AS count FROM matched m
+			WHERE EXISTS (
+				SELECT 1 FROM batch_history b
+				WHERE m.ready_to_send_timestamp >= b.previous_start_time
+				AND m.ready_to_send_timestamp < b.start_time)
+		), deleted AS (
+			UPDATE execution SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE id IN (SELECT id FROM matched) AND (SELECT count FROM sent) = 0
+			RETURNING id
+		)
+		SELECT (SELECT COUNT(*) FROM deleted) AS deleted, (SELECT count FROM sent) AS sent`
+
+	var result struct {
+		Deleted int `db:"deleted"`
+		Sent    int `db:"sent"`
+	}
+	if err := r.db.observeQuery(ctx, "update", "execution", func(ctx context.Context) error {
+		return r.db.GetContext(ctx, &result, query, args...)
+	}); err != nil {
+		r.logger.Error("Failed to delete executions by filter", zap.Error(err))
+		return 0, fmt.Errorf("failed to delete executions: %w", err)
+	}
+
+	if result.Sent > 0 {
+		return 0, fmt.Errorf("%w: %d of the matching executions", apperrors.ErrExecutionsAlreadySent, result.Sent)
+	}
+
+	r.logger.Info("Deleted executions by filter", zap.Int("count", result.Deleted))
+	return result.Deleted, nil
+}
+
+// ArchiveSent moves up to limit executions made ready before cutoff, and already sent in
+// a completed batch, from execution to execution_archive and returns how many moved.
+// Each call is one short transaction; rows locked by a concurrent archiver are skipped.
+func (r *ExecutionRepository) ArchiveSent(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	query := `
+		WITH batch AS (
+			SELECT e.id FROM execution e
+			WHERE e.ready_to_send_timestamp < $1
+			AND EXISTS (
+				SELECT 1 FROM batch_history b
+				WHERE b.status = '` + domain.BatchStatusCompleted + `'
+				AND e.ready_to_send_timestamp >= b.previous_start_time
+				AND e.ready_to_send_timestamp < b.start_time)
+			ORDER BY e.ready_to_send_timestamp, e.id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), archived AS (
+			DELETE FROM execution WHERE id IN (SELECT id FROM batch)
+			RETURNING ` + executionColumns + `
+		)
+		INSERT INTO execution_archive (` + executionColumns + `)
+		SELECT ` + executionColumns + ` FROM archived`
+
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "delete", "execution", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, cutoff, limit)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to archive executions", zap.Time("cutoff", cutoff), zap.Error(err))
+		return 0, fmt.Errorf("failed to archive executions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// Delete soft-deletes an execution record by setting deleted_at. The row is kept
+// for audit purposes and is excluded from queries by default.
 func (r *ExecutionRepository) Delete(ctx context.Context, id int) error {
-	query := "DELETE FROM execution WHERE id = $1"
-	result, err := r.db.ExecContext(ctx, query, id)
+	query := "UPDATE execution SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1 AND deleted_at IS NULL"
+	var result sql.Result
+	err := r.db.observeQuery(ctx, "update", "execution", func(ctx context.Context) (err error) {
+		result, err = r.db.ExecContext(ctx, query, id)
+		return err
+	})
 	if err != nil {
 		r.logger.Error("Failed to delete execution", zap.Int("id", id), zap.Error(err))
 		return fmt.Errorf("failed to delete execution: %w", err)
@@ -262,7 +706,7 @@ func (r *ExecutionRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("execution not found: %d", id)
+		return fmt.Errorf("%w: %d", apperrors.ErrExecutionNotFound, id)
 	}
 
 	r.logger.Info("Deleted execution", zap.Int("id", id))
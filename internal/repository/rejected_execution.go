@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// RejectedExecutionRepository handles database operations for rejected executions
+type RejectedExecutionRepository struct {
+	db     *DB
+	logger *zap.Logger
+}
+
+// NewRejectedExecutionRepository creates a new rejected execution repository
+func NewRejectedExecutionRepository(db *DB, logger *zap.Logger) *RejectedExecutionRepository {
+	return &RejectedExecutionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create inserts a rejected execution record
+func (r *RejectedExecutionRepository) Create(ctx context.Context, rejected *domain.RejectedExecution) error {
+	query := `
+		INSERT INTO rejected_execution (execution_service_id, status, reason, error, payload)
+		VALUES (:execution_service_id, :status, :reason, :error, :payload)
+		RETURNING id, created_at`
+
+	err := r.db.observeQuery(ctx, "insert", "rejected_execution", func(ctx context.Context) error {
+		return r.db.namedQueryRow(ctx, query, rejected, &rejected.ID, &rejected.CreatedAt)
+	})
+	if err != nil {
+		r.logger.Error("Failed to create rejected execution",
+			zap.Int("execution_service_id", rejected.ExecutionServiceID),
+			zap.Error(err))
+		return fmt.Errorf("failed to create rejected execution: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves rejected executions, newest first, with pagination
+func (r *RejectedExecutionRepository) List(ctx context.Context, limit, offset int) ([]domain.RejectedExecution, int, error) {
+	var rejected []domain.RejectedExecution
+	var totalCount int
+
+	countQuery := "SELECT COUNT(*) FROM rejected_execution"
+	if err := r.db.observeQuery(ctx, "select", "rejected_execution", func(ctx context.Context) error {
+		return r.db.reader().GetContext(ctx, &totalCount, countQuery)
+	}); err != nil {
+		r.logger.Error("Failed to get rejected execution count", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to get rejected execution count: %w", err)
+	}
+
+	query := "SELECT * FROM rejected_execution ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2"
+	if err := r.db.observeQuery(ctx, "select", "rejected_execution", func(ctx context.Context) error {
+		return r.db.reader().SelectContext(ctx, &rejected, query, limit, offset)
+	}); err != nil {
+		r.logger.Error("Failed to list rejected executions", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list rejected executions: %w", err)
+	}
+
+	return rejected, totalCount, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func newTestRejectedExecutionRepository(t *testing.T) (*RejectedExecutionRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), logger: zap.NewNop()}
+	return NewRejectedExecutionRepository(dbWrapper, zap.NewNop()), mock
+}
+
+func TestRejectedExecutionRepository_Create(t *testing.T) {
+	repo, mock := newTestRejectedExecutionRepository(t)
+
+	reason := domain.SkipReasonOpen
+	payload := json.RawMessage(`{"executionServiceId":7}`)
+	rejected := &domain.RejectedExecution{
+		ExecutionServiceID: 7,
+		Status:             "skipped",
+		Reason:             &reason,
+		Payload:            payload,
+	}
+	createdAt := time.Now().UTC()
+
+	mock.ExpectQuery(`INSERT INTO rejected_execution`).
+		WithArgs(7, "skipped", &reason, nil, payload).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+
+	require.NoError(t, repo.Create(context.Background(), rejected))
+	assert.Equal(t, 3, rejected.ID)
+	assert.Equal(t, createdAt, rejected.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRejectedExecutionRepository_Create_Error(t *testing.T) {
+	repo, mock := newTestRejectedExecutionRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO rejected_execution`).WillReturnError(errors.New("connection refused"))
+
+	err := repo.Create(context.Background(), &domain.RejectedExecution{ExecutionServiceID: 7, Status: "error", Payload: json.RawMessage(`{}`)})
+
+	assert.ErrorContains(t, err, "failed to create rejected execution")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRejectedExecutionRepository_List(t *testing.T) {
+	repo, mock := newTestRejectedExecutionRepository(t)
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM rejected_execution`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery(`SELECT \* FROM rejected_execution ORDER BY created_at DESC, id DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "execution_service_id", "status", "reason", "error", "payload", "created_at"}).
+			AddRow(5, 9, "error", nil, "failed to get portfolio ID", []byte(`{"executionServiceId":9}`), now).
+			AddRow(4, 8, "skipped", domain.SkipReasonOpen, nil, []byte(`{"executionServiceId":8}`), now.Add(-time.Minute)))
+
+	rejected, total, err := repo.List(context.Background(), 2, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, rejected, 2)
+	assert.Equal(t, "failed to get portfolio ID", *rejected[0].Error)
+	assert.Nil(t, rejected[0].Reason)
+	assert.JSONEq(t, `{"executionServiceId":9}`, string(rejected[0].Payload))
+	assert.Equal(t, domain.SkipReasonOpen, *rejected[1].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// SkippedExecutionRepository handles database operations for skipped executions kept
+// for reprocessing
+type SkippedExecutionRepository struct {
+	db     *DB
+	logger *zap.Logger
+}
+
+// NewSkippedExecutionRepository creates a new skipped execution repository
+func NewSkippedExecutionRepository(db *DB, logger *zap.Logger) *SkippedExecutionRepository {
+	return &SkippedExecutionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Upsert stores a skipped execution, replacing any earlier skip for the same
+// executionServiceId with the latest reason and payload
+func (r *SkippedExecutionRepository) Upsert(ctx context.Context, skipped *domain.SkippedExecution) error {
+	query := `
+		INSERT INTO skipped_execution (execution_service_id, reason, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (execution_service_id) DO UPDATE
+		SET reason = EXCLUDED.reason, payload = EXCLUDED.payload, skipped_at = CURRENT_TIMESTAMP`
+
+	err := r.db.observeQuery(ctx, "upsert", "skipped_execution", func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, query, skipped.ExecutionServiceID, skipped.Reason, skipped.Payload)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to store skipped execution",
+			zap.Int("execution_service_id", skipped.ExecutionServiceID),
+			zap.Error(err))
+		return fmt.Errorf("failed to store skipped execution: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the stored skip for an executionServiceId; deleting a missing skip is not an error
+func (r *SkippedExecutionRepository) Delete(ctx context.Context, executionServiceID int) error {
+	query := "DELETE FROM skipped_execution WHERE execution_service_id = $1"
+
+	err := r.db.observeQuery(ctx, "delete", "skipped_execution", func(ctx context.Context) error {
+		_, err := r.db.ExecContext(ctx, query, executionServiceID)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("Failed to delete skipped execution",
+			zap.Int("execution_service_id", executionServiceID),
+			zap.Error(err))
+		return fmt.Errorf("failed to delete skipped execution: %w", err)
+	}
+
+	return nil
+}
+
+// ListByExecutionServiceIDs retrieves the stored skips for the given executionServiceIds.
+// IDs without a stored skip are left out.
+func (r *SkippedExecutionRepository) ListByExecutionServiceIDs(ctx context.Context, executionServiceIDs []int) ([]domain.SkippedExecution, error) {
+	var skipped []domain.SkippedExecution
+	query := "SELECT * FROM skipped_execution WHERE execution_service_id = ANY($1) ORDER BY skipped_at, execution_service_id"
+
+	err := r.db.observeQuery(ctx, "select", "skipped_execution", func(ctx context.Context) error {
+		return r.db.SelectContext(ctx, &skipped, query, pq.Array(executionServiceIDs))
+	})
+	if err != nil {
+		r.logger.Error("Failed to list skipped executions", zap.Ints("execution_service_ids", executionServiceIDs), zap.Error(err))
+		return nil, fmt.Errorf("failed to list skipped executions: %w", err)
+	}
+
+	return skipped, nil
+}
+
+// ListByReason retrieves up to limit stored skips with the given reason, oldest first
+func (r *SkippedExecutionRepository) ListByReason(ctx context.Context, reason string, limit int) ([]domain.SkippedExecution, error) {
+	var skipped []domain.SkippedExecution
+	query := "SELECT * FROM skipped_execution WHERE reason = $1 ORDER BY skipped_at, execution_service_id LIMIT $2"
+
+	err := r.db.observeQuery(ctx, "select", "skipped_execution", func(ctx context.Context) error {
+		return r.db.SelectContext(ctx, &skipped, query, reason, limit)
+	})
+	if err != nil {
+		r.logger.Error("Failed to list skipped executions", zap.String("reason", reason), zap.Error(err))
+		return nil, fmt.Errorf("failed to list skipped executions: %w", err)
+	}
+
+	return skipped, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func newTestSkippedExecutionRepository(t *testing.T) (*SkippedExecutionRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	dbWrapper := &DB{DB: sqlx.NewDb(db, "postgres"), logger: zap.NewNop()}
+	return NewSkippedExecutionRepository(dbWrapper, zap.NewNop()), mock
+}
+
+func TestSkippedExecutionRepository_Upsert(t *testing.T) {
+	repo, mock := newTestSkippedExecutionRepository(t)
+	payload := []byte(`{"executionServiceId":7}`)
+
+	mock.ExpectExec(`INSERT INTO skipped_execution .* ON CONFLICT \(execution_service_id\) DO UPDATE`).
+		WithArgs(7, domain.SkipReasonPortfolioLookupFailed, payload).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Upsert(context.Background(), &domain.SkippedExecution{
+		ExecutionServiceID: 7,
+		Reason:             domain.SkipReasonPortfolioLookupFailed,
+		Payload:            payload,
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSkippedExecutionRepository_Delete(t *testing.T) {
+	repo, mock := newTestSkippedExecutionRepository(t)
+
+	mock.ExpectExec(`DELETE FROM skipped_execution WHERE execution_service_id = \$1`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Delete(context.Background(), 7), "deleting a missing skip is not an error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSkippedExecutionRepository_List(t *testing.T) {
+	skippedAt := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	columns := []string{"execution_service_id", "reason", "payload", "skipped_at"}
+
+	t.Run("by execution service IDs", func(t *testing.T) {
+		repo, mock := newTestSkippedExecutionRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE execution_service_id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]int{7, 8})).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, domain.SkipReasonOpen, []byte(`{}`), skippedAt))
+
+		skipped, err := repo.ListByExecutionServiceIDs(context.Background(), []int{7, 8})
+
+		require.NoError(t, err)
+		require.Len(t, skipped, 1)
+		assert.Equal(t, 7, skipped[0].ExecutionServiceID)
+		assert.Equal(t, domain.SkipReasonOpen, skipped[0].Reason)
+		assert.Equal(t, []byte(`{}`), skipped[0].Payload)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("by reason", func(t *testing.T) {
+		repo, mock := newTestSkippedExecutionRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE reason = \$1 ORDER BY skipped_at, execution_service_id LIMIT \$2`).
+			WithArgs(domain.SkipReasonPortfolioLookupFailed, 100).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(9, domain.SkipReasonPortfolioLookupFailed, []byte(`{}`), skippedAt))
+
+		skipped, err := repo.ListByReason(context.Background(), domain.SkipReasonPortfolioLookupFailed, 100)
+
+		require.NoError(t, err)
+		require.Len(t, skipped, 1)
+		assert.Equal(t, 9, skipped[0].ExecutionServiceID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
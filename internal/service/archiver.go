@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+	"github.com/kasbench/globeco-allocation-service/internal/repository"
+)
+
+// ExecutionArchiver periodically moves sent executions older than the retention period
+// to execution_archive, in batches so no transaction holds locks on the hot table for long
+type ExecutionArchiver struct {
+	cron      *cron.Cron
+	archive   func(ctx context.Context, cutoff time.Time, limit int) (int, error)
+	retention time.Duration
+	batchSize int
+	metrics   observability.MetricsRecorder
+	now       func() time.Time
+	logger    *zap.Logger
+
+	// ctx is cancelled by Stop so a run in progress ends after its current batch
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewExecutionArchiver creates an archiver for the configured retention and schedule
+func NewExecutionArchiver(cfg *config.Config, executionRepo *repository.ExecutionRepository, logger *zap.Logger) (*ExecutionArchiver, error) {
+	return newExecutionArchiver(cfg.ArchiveSchedule, cfg.ExecutionRetention, cfg.ArchiveBatchSize, executionRepo.ArchiveSent, logger)
+}
+
+func newExecutionArchiver(schedule string, retention time.Duration, batchSize int, archive func(ctx context.Context, cutoff time.Time, limit int) (int, error), logger *zap.Logger) (*ExecutionArchiver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &ExecutionArchiver{
+		// A run still in progress when the next is due is skipped rather than queued
+		cron:      cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		archive:   archive,
+		retention: retention,
+		batchSize: batchSize,
+		now:       time.Now,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	if _, err := a.cron.AddFunc(schedule, a.run); err != nil {
+		cancel()
+		return nil, fmt.Errorf("invalid archive schedule %q: %w", schedule, err)
+	}
+
+	return a, nil
+}
+
+// SetMetrics enables the archived executions counter
+func (a *ExecutionArchiver) SetMetrics(metrics observability.MetricsRecorder) {
+	a.metrics = metrics
+}
+
+// Start begins archiving on the schedule
+func (a *ExecutionArchiver) Start() {
+	a.logger.Info("Starting execution archival", zap.Duration("retention", a.retention))
+	a.cron.Start()
+}
+
+// Stop prevents further runs, ends a running one after its current batch and waits for
+// it to finish or ctx to expire
+func (a *ExecutionArchiver) Stop(ctx context.Context) error {
+	a.logger.Info("Stopping execution archival")
+	a.cancel()
+	select {
+	case <-a.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("execution archival did not finish: %w", ctx.Err())
+	}
+}
+
+// run archives every eligible execution, one batch at a time, and logs the total
+func (a *ExecutionArchiver) run() {
+	correlationID := observability.GenerateCorrelationID()
+	ctx := observability.WithCorrelationID(a.ctx, correlationID)
+	logger := a.logger.With(zap.String("correlation_id", correlationID))
+
+	cutoff := a.now().UTC().Add(-a.retention)
+	logger.Info("Running execution archival", zap.Time("cutoff", cutoff))
+
+	total := 0
+	for ctx.Err() == nil {
+		archived, err := a.archive(ctx, cutoff, a.batchSize)
+		total += archived
+		if a.metrics != nil && archived > 0 {
+			a.metrics.RecordExecutionsArchived(ctx, archived)
+		}
+		if err != nil {
+			logger.Error("Execution archival failed", zap.Int("archived_count", total), zap.Error(err))
+			return
+		}
+		// A short batch means nothing eligible is left
+		if archived < a.batchSize {
+			break
+		}
+	}
+
+	logger.Info("Execution archival completed", zap.Int("archived_count", total))
+}
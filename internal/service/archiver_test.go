@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewExecutionArchiver_InvalidSchedule(t *testing.T) {
+	_, err := newExecutionArchiver("every night", time.Hour, 10, nil, zap.NewNop())
+
+	assert.ErrorContains(t, err, `invalid archive schedule "every night"`)
+}
+
+func TestExecutionArchiver_Run(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		batches       []int
+		err           error
+		expectCalls   int
+		expectMsg     string
+		expectArchive int
+	}{
+		{
+			name:          "stops after a short batch",
+			batches:       []int{10, 10, 3},
+			expectCalls:   3,
+			expectMsg:     "Execution archival completed",
+			expectArchive: 23,
+		},
+		{
+			name:          "nothing to archive",
+			batches:       []int{0},
+			expectCalls:   1,
+			expectMsg:     "Execution archival completed",
+			expectArchive: 0,
+		},
+		{
+			name:          "stops at the first failure",
+			batches:       []int{10, 0},
+			err:           errors.New("connection reset"),
+			expectCalls:   2,
+			expectMsg:     "Execution archival failed",
+			expectArchive: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+			calls := 0
+			archiver, err := newExecutionArchiver("@hourly", 30*24*time.Hour, 10, func(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+				assert.Equal(t, now.Add(-30*24*time.Hour), cutoff)
+				assert.Equal(t, 10, limit)
+				archived := tt.batches[calls]
+				calls++
+				if calls == len(tt.batches) {
+					return archived, tt.err
+				}
+				return archived, nil
+			}, zap.New(core))
+			require.NoError(t, err)
+			archiver.now = func() time.Time { return now }
+
+			archiver.run()
+
+			assert.Equal(t, tt.expectCalls, calls)
+			entries := logs.FilterMessage(tt.expectMsg).All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, int64(tt.expectArchive), entries[0].ContextMap()["archived_count"])
+		})
+	}
+}
+
+func TestExecutionArchiver_StopEndsRunningPass(t *testing.T) {
+	calls := 0
+	var archiver *ExecutionArchiver
+	archiver, err := newExecutionArchiver("@hourly", time.Hour, 10, func(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+		calls++
+		require.NoError(t, archiver.Stop(context.Background()))
+		return limit, nil
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	archiver.run()
+
+	assert.Equal(t, 1, calls, "no further batch starts once stopped")
+}
@@ -1,66 +1,183 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// Exit codes the Portfolio Accounting CLI uses to signal specific failures
+const (
+	CLIExitCodeBadInput              = 2
+	CLIExitCodeDownstreamUnavailable = 3
 )
 
+// cliWaitDelay bounds how long a cancelled CLI may keep its output pipes open
+const cliWaitDelay = time.Second
+
+// maxPartialOutputBytes caps the output kept from an aborted CLI; the end is kept
+// since that is where an error usually is
+const maxPartialOutputBytes = 4096
+
+// CLIError reports a non-zero exit from the Portfolio Accounting CLI
+type CLIError struct {
+	ExitCode int
+	Stderr   string
+}
+
+// Error implements the error interface
+func (e *CLIError) Error() string {
+	return fmt.Sprintf("exit code %d, stderr: %s", e.ExitCode, e.Stderr)
+}
+
+// Retryable reports whether the failure is transient and the same file may succeed later
+func (e *CLIError) Retryable() bool {
+	return e.ExitCode == CLIExitCodeDownstreamUnavailable
+}
+
+// CLIAbortedError reports a CLI that was stopped before it exited, on timeout or
+// cancellation, with whatever output it produced up to then
+type CLIAbortedError struct {
+	Cause  error
+	Stdout string
+	Stderr string
+}
+
+// Error implements the error interface
+func (e *CLIAbortedError) Error() string {
+	return fmt.Sprintf("command aborted: %v, stderr: %s", e.Cause, e.Stderr)
+}
+
+// Unwrap returns the context error that stopped the CLI
+func (e *CLIAbortedError) Unwrap() error {
+	return e.Cause
+}
+
 // CLIInvokerService handles execution of Portfolio Accounting CLI commands
 type CLIInvokerService struct {
-	cliCommand string
+	commands   []string
 	logger     *zap.Logger
+	metrics    observability.MetricsRecorder
 	timeout    time.Duration
+	grace      time.Duration
+	workingDir string
+	env        map[string]string
+
+	healthCheckCommand string
+	healthCheckTimeout time.Duration
 }
 
-// NewCLIInvokerService creates a new CLI invoker service
-func NewCLIInvokerService(cliCommand string, logger *zap.Logger) *CLIInvokerService {
-	home, err := os.UserHomeDir()
-	if err == nil && strings.Contains(cliCommand, "{home}") {
-		cliCommand = strings.ReplaceAll(cliCommand, "{home}", home)
+// NewCLIInvokerService creates a new CLI invoker service for the given commands; several
+// make a pipeline run in order, such as a validation step before the load. Blank
+// commands are ignored.
+func NewCLIInvokerService(cliCommands []string, logger *zap.Logger) *CLIInvokerService {
+	var commands []string
+	for _, command := range cliCommands {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, expandHome(command))
+		}
 	}
 	return &CLIInvokerService{
-		cliCommand: cliCommand,
-		logger:     logger,
-		timeout:    5 * time.Minute, // Default timeout
+		commands: commands,
+		logger:   logger,
+		timeout:  5 * time.Minute, // Default timeout
 	}
 }
 
+// SetMetrics enables per-step metrics for the CLI pipeline
+func (s *CLIInvokerService) SetMetrics(metrics observability.MetricsRecorder) {
+	s.metrics = metrics
+}
+
 // SetTimeout configures the CLI execution timeout
 func (s *CLIInvokerService) SetTimeout(timeout time.Duration) {
 	s.timeout = timeout
 }
 
-// InvokePortfolioAccountingCLI executes the Portfolio Accounting CLI with the given file and output directory
+// SetTimeoutGrace gives a timed-out or cancelled CLI this long after SIGTERM to flush
+// its output before it is killed; zero kills it immediately
+func (s *CLIInvokerService) SetTimeoutGrace(grace time.Duration) {
+	s.grace = grace
+}
+
+// SetWorkingDir configures the directory the CLI runs in; empty uses the service's own
+func (s *CLIInvokerService) SetWorkingDir(dir string) {
+	s.workingDir = dir
+}
+
+// SetEnv configures extra environment variables for the CLI, layered over the service's
+// environment. Values may hold credentials and are never logged.
+func (s *CLIInvokerService) SetEnv(env map[string]string) {
+	s.env = env
+}
+
+// SetHealthCheck configures the command CheckInvokable runs to verify the CLI can be
+// started, such as the CLI image with --version, and how long it may take
+func (s *CLIInvokerService) SetHealthCheck(command string, timeout time.Duration) {
+	s.healthCheckCommand = expandHome(command)
+	s.healthCheckTimeout = timeout
+}
+
+// expandHome replaces the {home} placeholder with the user's home directory
+func expandHome(command string) string {
+	home, err := os.UserHomeDir()
+	if err == nil && strings.Contains(command, "{home}") {
+		command = strings.ReplaceAll(command, "{home}", home)
+	}
+	return command
+}
+
+// InvokePortfolioAccountingCLI executes the Portfolio Accounting CLI pipeline with the
+// given file and output directory. Steps run in order and the first failure aborts the
+// rest; the timeout covers the whole pipeline.
 func (s *CLIInvokerService) InvokePortfolioAccountingCLI(ctx context.Context, filename string, outputDir string) error {
-	if s.cliCommand == "" {
+	if len(s.commands) == 0 {
 		return fmt.Errorf("CLI command not configured")
 	}
 
-	// Replace placeholders in command
-	command := strings.ReplaceAll(s.cliCommand, "{filename}", filename)
-	command = strings.ReplaceAll(command, "{output_dir}", outputDir)
-
-	s.logger.Info("Invoking Portfolio Accounting CLI",
-		zap.String("command", command),
-		zap.String("filename", filename),
-		zap.String("outputDir", outputDir))
-
-	// Create context with timeout
+	// Derive from ctx so the CLI is killed when the caller cancels, such as a client
+	// disconnecting from a synchronous Send, as well as on timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	// Parse and execute command
-	if err := s.executeCommand(cmdCtx, command); err != nil {
-		s.logger.Error("Portfolio Accounting CLI execution failed",
+	for i, step := range s.commands {
+		// Replace placeholders in command
+		command := strings.ReplaceAll(step, "{filename}", filename)
+		command = strings.ReplaceAll(command, "{output_dir}", outputDir)
+
+		s.logger.Info("Invoking Portfolio Accounting CLI",
+			zap.Int("step", i+1),
+			zap.Int("steps", len(s.commands)),
 			zap.String("command", command),
-			zap.Error(err))
-		return fmt.Errorf("CLI execution failed: %w", err)
+			zap.String("filename", filename),
+			zap.String("outputDir", outputDir),
+			zap.String("workingDir", s.workingDir),
+			zap.Strings("envKeys", s.envKeys()))
+
+		// Parse and execute command
+		start := time.Now()
+		err := s.executeCommand(cmdCtx, command)
+		s.recordStep(ctx, i+1, err, time.Since(start))
+		if err != nil {
+			s.logger.Error("Portfolio Accounting CLI execution failed",
+				zap.Int("step", i+1),
+				zap.String("command", command),
+				zap.Error(err))
+			if len(s.commands) > 1 {
+				return fmt.Errorf("CLI execution failed at step %d of %d: %w", i+1, len(s.commands), err)
+			}
+			return fmt.Errorf("CLI execution failed: %w", err)
+		}
 	}
 
 	s.logger.Info("Portfolio Accounting CLI executed successfully",
@@ -69,12 +186,104 @@ func (s *CLIInvokerService) InvokePortfolioAccountingCLI(ctx context.Context, fi
 	return nil
 }
 
+// recordStep records the outcome and duration of one pipeline step
+func (s *CLIInvokerService) recordStep(ctx context.Context, step int, err error, duration time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	s.metrics.RecordCLIStep(ctx, step, status, duration)
+}
+
+// CheckInvokable runs the health check command and reports whether it could be started
+// and exited successfully within the health check timeout. Output is discarded unless
+// the command fails.
+func (s *CLIInvokerService) CheckInvokable(ctx context.Context) error {
+	if s.healthCheckCommand == "" {
+		return fmt.Errorf("CLI health check command not configured")
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, s.healthCheckTimeout)
+	defer cancel()
+
+	cmd, stop, err := s.newCommand(cmdCtx, s.healthCheckCommand)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	output, err := cmd.CombinedOutput()
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("CLI health check timed out after %s", s.healthCheckTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("CLI health check failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // executeCommand parses and executes the CLI command
 func (s *CLIInvokerService) executeCommand(ctx context.Context, command string) error {
+	cmd, stop, err := s.newCommand(ctx, command)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// Capture stdout and stderr separately so progress output and errors don't interleave
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		s.logger.Error("Command execution failed",
+			zap.String("command", command),
+			zap.String("stdout", stdout.String()),
+			zap.String("stderr", stderr.String()),
+			zap.Error(err))
+		// A killed CLI reports why it was stopped rather than its signal exit, keeping
+		// the partial output since it often holds the actual error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return &CLIAbortedError{
+				Cause:  ctxErr,
+				Stdout: outputTail(stdout.String()),
+				Stderr: outputTail(stderr.String()),
+			}
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("command failed: %w", &CLIError{ExitCode: exitErr.ExitCode(), Stderr: stderr.String()})
+		}
+		return fmt.Errorf("command failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	s.logger.Info("Command executed successfully",
+		zap.String("command", command),
+		zap.String("stdout", stdout.String()),
+		zap.String("stderr", stderr.String()))
+
+	return nil
+}
+
+// outputTail returns at most the last maxPartialOutputBytes of output
+func outputTail(output string) string {
+	if len(output) <= maxPartialOutputBytes {
+		return output
+	}
+	return output[len(output)-maxPartialOutputBytes:]
+}
+
+// newCommand parses command into an exec.Cmd bound to ctx, with the configured
+// working directory and environment. The caller must call stop once the command has
+// finished, to cancel any SIGKILL still pending from the timeout grace period.
+func (s *CLIInvokerService) newCommand(ctx context.Context, command string) (*exec.Cmd, func(), error) {
 	// Parse command into parts
 	parts := s.parseCommand(command)
 	if len(parts) == 0 {
-		return fmt.Errorf("empty command")
+		return nil, nil, fmt.Errorf("empty command")
 	}
 
 	var cmd *exec.Cmd
@@ -88,22 +297,20 @@ func (s *CLIInvokerService) executeCommand(ctx context.Context, command string)
 		cmd = exec.CommandContext(ctx, parts[0], parts[1:]...)
 	}
 
-	// Capture output for logging
-	output, err := cmd.CombinedOutput()
+	// Cancelling ctx, whether by timeout or because the caller went away, kills the CLI
+	// and anything it started; WaitDelay stops a child that escaped from holding the
+	// output pipes open
+	stop := killProcessGroupOnCancel(cmd, s.grace)
 
-	if err != nil {
-		s.logger.Error("Command execution failed",
-			zap.String("command", command),
-			zap.String("output", string(output)),
-			zap.Error(err))
-		return fmt.Errorf("command failed: %w, output: %s", err, string(output))
+	cmd.Dir = s.workingDir
+	if len(s.env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range s.env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
 	}
 
-	s.logger.Info("Command executed successfully",
-		zap.String("command", command),
-		zap.String("output", string(output)))
-
-	return nil
+	return cmd, stop, nil
 }
 
 // parseCommand splits a command string into executable parts
@@ -141,21 +348,33 @@ func (s *CLIInvokerService) parseCommand(command string) []string {
 
 // ValidateCommand checks if the CLI command is properly configured
 func (s *CLIInvokerService) ValidateCommand() error {
-	if s.cliCommand == "" {
+	if len(s.commands) == 0 {
 		return fmt.Errorf("CLI command is not configured")
 	}
 
-	// Basic validation - check if it contains expected patterns
-	if !strings.Contains(s.cliCommand, "globeco-portfolio-cli") &&
-		!strings.Contains(s.cliCommand, "portfolio") {
-		s.logger.Warn("CLI command may not be valid Portfolio Accounting CLI command",
-			zap.String("command", s.cliCommand))
+	// Basic validation - check if any step contains expected patterns
+	for _, command := range s.commands {
+		if strings.Contains(command, "globeco-portfolio-cli") || strings.Contains(command, "portfolio") {
+			return nil
+		}
 	}
+	s.logger.Warn("CLI command may not be valid Portfolio Accounting CLI command",
+		zap.Strings("commands", s.commands))
 
 	return nil
 }
 
-// GetCommand returns the configured CLI command (for testing/debugging)
-func (s *CLIInvokerService) GetCommand() string {
-	return s.cliCommand
+// GetCommands returns the configured CLI pipeline (for testing/debugging)
+func (s *CLIInvokerService) GetCommands() []string {
+	return s.commands
+}
+
+// envKeys returns the sorted names of the configured environment variables, for logging
+func (s *CLIInvokerService) envKeys() []string {
+	keys := make([]string, 0, len(s.env))
+	for key := range s.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
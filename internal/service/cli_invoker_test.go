@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+func TestCLIInvokerService_WorkingDirAndEnv(t *testing.T) {
+	workDir := t.TempDir()
+
+	invoker := NewCLIInvokerService([]string{`sh -c "pwd > cli_out.txt; printenv PA_CONFIG_PATH >> cli_out.txt"`}, zap.NewNop())
+	invoker.SetWorkingDir(workDir)
+	invoker.SetEnv(map[string]string{"PA_CONFIG_PATH": "/etc/pa/config.yaml"})
+
+	err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", workDir)
+	require.NoError(t, err)
+
+	output, err := os.ReadFile(filepath.Join(workDir, "cli_out.txt"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	require.Len(t, lines, 2)
+
+	resolvedWorkDir, err := filepath.EvalSymlinks(workDir)
+	require.NoError(t, err)
+	assert.Equal(t, resolvedWorkDir, lines[0])
+	assert.Equal(t, "/etc/pa/config.yaml", lines[1])
+}
+
+func TestCLIInvokerService_EnvKeysOmitValues(t *testing.T) {
+	invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+	invoker.SetEnv(map[string]string{"PA_TOKEN": "secret", "PA_CONFIG_PATH": "/etc/pa"})
+
+	assert.Equal(t, []string{"PA_CONFIG_PATH", "PA_TOKEN"}, invoker.envKeys())
+}
+
+func TestCLIInvokerService_SeparatesStdoutAndStderr(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	invoker := NewCLIInvokerService([]string{`sh -c "echo progress; echo warning >&2"`}, zap.New(core))
+
+	err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+	require.NoError(t, err)
+
+	entries := logs.FilterMessage("Command executed successfully").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "progress\n", fields["stdout"])
+	assert.Equal(t, "warning\n", fields["stderr"])
+}
+
+func TestCLIInvokerService_ErrorIncludesOnlyStderr(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	invoker := NewCLIInvokerService([]string{`sh -c "echo progress; echo bad input >&2; exit 1"`}, zap.New(core))
+
+	err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad input")
+	assert.NotContains(t, err.Error(), "progress")
+
+	entries := logs.FilterMessage("Command execution failed").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "progress\n", entries[0].ContextMap()["stdout"])
+}
+
+func TestCLIInvokerService_ExitCodeClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitCode  int
+		retryable bool
+	}{
+		{name: "bad input file", exitCode: CLIExitCodeBadInput, retryable: false},
+		{name: "downstream unavailable", exitCode: CLIExitCodeDownstreamUnavailable, retryable: true},
+		{name: "generic failure", exitCode: 1, retryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := fmt.Sprintf(`sh -c "echo failed >&2; exit %d"`, tt.exitCode)
+			invoker := NewCLIInvokerService([]string{command}, zap.NewNop())
+
+			err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+
+			var cliErr *CLIError
+			require.ErrorAs(t, err, &cliErr)
+			assert.Equal(t, tt.exitCode, cliErr.ExitCode)
+			assert.Equal(t, "failed\n", cliErr.Stderr)
+			assert.Equal(t, tt.retryable, cliErr.Retryable())
+		})
+	}
+}
+
+func TestCLIInvokerService_CheckInvokable(t *testing.T) {
+	t.Run("succeeds", func(t *testing.T) {
+		invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+		invoker.SetHealthCheck(`sh -c "echo 1.2.3"`, time.Second)
+
+		assert.NoError(t, invoker.CheckInvokable(context.Background()))
+	})
+
+	t.Run("missing binary", func(t *testing.T) {
+		invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+		invoker.SetHealthCheck("globeco-portfolio-cli-missing --version", time.Second)
+
+		err := invoker.CheckInvokable(context.Background())
+		assert.ErrorContains(t, err, "CLI health check failed")
+	})
+
+	t.Run("non-zero exit includes output", func(t *testing.T) {
+		invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+		invoker.SetHealthCheck(`sh -c "echo no such image >&2; exit 125"`, time.Second)
+
+		err := invoker.CheckInvokable(context.Background())
+		assert.ErrorContains(t, err, "no such image")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+		invoker.SetHealthCheck("sleep 5", 50*time.Millisecond)
+
+		start := time.Now()
+		err := invoker.CheckInvokable(context.Background())
+		assert.ErrorContains(t, err, "timed out after 50ms")
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		invoker := NewCLIInvokerService([]string{"true"}, zap.NewNop())
+
+		assert.ErrorContains(t, invoker.CheckInvokable(context.Background()), "not configured")
+	})
+}
+
+func TestCLIInvokerService_ParentCancellationKillsCommand(t *testing.T) {
+	// The sleep runs as a child of sh, so only killing the process group stops it
+	invoker := NewCLIInvokerService([]string{`sh -c "sleep 30; echo done"`}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := invoker.InvokePortfolioAccountingCLI(ctx, "transactions.csv", t.TempDir())
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var cliErr *CLIError
+	assert.False(t, errors.As(err, &cliErr), "a cancelled CLI is not a CLI exit")
+}
+
+func TestCLIInvokerService_TimeoutKeepsPartialOutput(t *testing.T) {
+	invoker := NewCLIInvokerService([]string{`sh -c "echo loaded 10 rows; echo bad row 11 >&2; sleep 30"`}, zap.NewNop())
+	invoker.SetTimeout(200 * time.Millisecond)
+
+	err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+
+	var abortedErr *CLIAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "loaded 10 rows\n", abortedErr.Stdout)
+	assert.Equal(t, "bad row 11\n", abortedErr.Stderr)
+	assert.Contains(t, err.Error(), "bad row 11")
+}
+
+func TestCLIInvokerService_TimeoutGraceLetsCLIFlush(t *testing.T) {
+	command := `sh -c "trap 'echo flushed >&2; exit 1' TERM; sleep 30 & wait"`
+
+	invoker := NewCLIInvokerService([]string{command}, zap.NewNop())
+	invoker.SetTimeout(200 * time.Millisecond)
+	invoker.SetTimeoutGrace(2 * time.Second)
+
+	err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+
+	var abortedErr *CLIAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "flushed\n", abortedErr.Stderr)
+
+	// Without a grace period the CLI is killed before it can write anything
+	invoker = NewCLIInvokerService([]string{command}, zap.NewNop())
+	invoker.SetTimeout(200 * time.Millisecond)
+
+	err = invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", t.TempDir())
+
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Empty(t, abortedErr.Stderr)
+}
+
+func TestOutputTail(t *testing.T) {
+	assert.Equal(t, "short", outputTail("short"))
+
+	long := strings.Repeat("a", maxPartialOutputBytes) + "error at the end"
+	tail := outputTail(long)
+	assert.Len(t, tail, maxPartialOutputBytes)
+	assert.True(t, strings.HasSuffix(tail, "error at the end"))
+}
+
+func TestNewCLIInvokerService_Commands(t *testing.T) {
+	assert.Equal(t, []string{"load {filename}"}, NewCLIInvokerService([]string{"load {filename}"}, zap.NewNop()).GetCommands())
+	assert.Equal(t, []string{"validate {filename}", "load {filename}"},
+		NewCLIInvokerService([]string{"validate {filename}", " ", "  load {filename}  "}, zap.NewNop()).GetCommands())
+	assert.Empty(t, NewCLIInvokerService([]string{" "}, zap.NewNop()).GetCommands())
+}
+
+func TestCLIInvokerService_Pipeline(t *testing.T) {
+	newMetrics := func() *observability.BusinessMetrics {
+		return &observability.BusinessMetrics{
+			PortfolioCLIStepDuration: prometheus.NewHistogramVec(
+				prometheus.HistogramOpts{Name: "test_cli_step_duration_seconds"}, []string{"step", "status"}),
+		}
+	}
+
+	t.Run("runs steps in order with substitutions", func(t *testing.T) {
+		outputDir := t.TempDir()
+		invoker := NewCLIInvokerService([]string{
+			`sh -c "echo validated {filename} > {output_dir}/steps.txt"`,
+			`sh -c "echo loaded {filename} >> {output_dir}/steps.txt"`,
+		}, zap.NewNop())
+		metrics := newMetrics()
+		invoker.SetMetrics(observability.NewPrometheusRecorder(metrics))
+
+		err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", outputDir)
+		require.NoError(t, err)
+
+		output, err := os.ReadFile(filepath.Join(outputDir, "steps.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "validated transactions.csv\nloaded transactions.csv\n", string(output))
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.PortfolioCLIStepDuration))
+	})
+
+	t.Run("aborts on the first failure", func(t *testing.T) {
+		outputDir := t.TempDir()
+		invoker := NewCLIInvokerService([]string{
+			`sh -c "echo invalid row >&2; exit 2"`,
+			"touch {output_dir}/loaded",
+		}, zap.NewNop())
+		metrics := newMetrics()
+		invoker.SetMetrics(observability.NewPrometheusRecorder(metrics))
+
+		err := invoker.InvokePortfolioAccountingCLI(context.Background(), "transactions.csv", outputDir)
+
+		var cliErr *CLIError
+		require.ErrorAs(t, err, &cliErr)
+		assert.Equal(t, CLIExitCodeBadInput, cliErr.ExitCode)
+		assert.Contains(t, err.Error(), "step 1 of 2")
+		assert.NoFileExists(t, filepath.Join(outputDir, "loaded"))
+
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics.PortfolioCLIStepDuration))
+		var failed dto.Metric
+		require.NoError(t, metrics.PortfolioCLIStepDuration.WithLabelValues("1", "error").(prometheus.Histogram).Write(&failed))
+		assert.Equal(t, uint64(1), failed.GetHistogram().GetSampleCount())
+	})
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package service
+
+import (
+	"os/exec"
+	"time"
+)
+
+// killProcessGroupOnCancel leaves exec.CommandContext's default of killing only the
+// direct child on platforms without process groups; there is no grace period, so the
+// returned stop func has nothing to cancel
+func killProcessGroupOnCancel(cmd *exec.Cmd, grace time.Duration) (stop func()) {
+	cmd.WaitDelay = cliWaitDelay
+	return func() {}
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package service
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessGroupOnCancel starts cmd in its own process group and stops the whole
+// group when its context is done, so children of a shell wrapper such as sh -c are
+// stopped along with it. With a grace period the group gets SIGTERM first, giving the
+// CLI a chance to flush its output, and SIGKILL once the grace period is over. The
+// returned stop func cancels a pending SIGKILL; call it once cmd has been waited on, so
+// the timer neither outlives the command nor signals a process group ID since reused.
+func killProcessGroupOnCancel(cmd *exec.Cmd, grace time.Duration) (stop func()) {
+	var timer *time.Timer
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if grace <= 0 {
+			return syscall.Kill(pgid, syscall.SIGKILL)
+		}
+		timer = time.AfterFunc(grace, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = grace + cliWaitDelay
+
+	// Wait returns only after Cancel has, so timer is set by the time stop runs
+	return func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
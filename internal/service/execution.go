@@ -2,17 +2,54 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
 	"github.com/kasbench/globeco-allocation-service/internal/config"
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 	"github.com/kasbench/globeco-allocation-service/internal/repository"
 )
 
+// Policies for executions whose portfolio ID lookup fails
+const (
+	PortfolioLookupFailurePolicyError = "error"
+	PortfolioLookupFailurePolicySkip  = "skip"
+)
+
+// Policies for a closed execution whose status reports a fill but whose quantity
+// filled is zero, which almost always means bad upstream data
+const (
+	ZeroQuantityFilledPolicyReject = "reject" // fail the execution as an error
+	ZeroQuantityFilledPolicySkip   = "skip"   // skip it without creating it
+	ZeroQuantityFilledPolicyPass   = "pass"   // create it anyway
+)
+
+// Policies for a Send window whose executions span longer than MaxSendWindowDuration
+const (
+	SendWindowOverflowPolicySplit  = "split"  // send the oldest MaxSendWindowDuration, leaving the rest
+	SendWindowOverflowPolicyRefuse = "refuse" // fail the Send unless it is forced
+)
+
+// fillStatuses are the execution statuses that report some quantity as traded
+var fillStatuses = map[string]bool{
+	"PART":             true,
+	"PARTIAL":          true,
+	"PARTIALLY_FILLED": true,
+	"FULL":             true,
+	"FILLED":           true,
+}
+
 // ExecutionService handles business logic for executions
 type ExecutionService struct {
 	executionRepo    *repository.ExecutionRepository
@@ -23,6 +60,18 @@ type ExecutionService struct {
 	logger           *zap.Logger
 	validator        *validator.Validate
 	config           *config.Config
+	tradeDateLoc     *time.Location
+	now              func() time.Time
+	auditRepo        *repository.AuditLogRepository
+	skippedRepo      *repository.SkippedExecutionRepository
+	rejectedRepo     *repository.RejectedExecutionRepository
+	webhook          *WebhookNotifier
+	metrics          observability.MetricsRecorder
+	events           *ExecutionBroker
+
+	// sendQueue, when Send queueing is enabled, holds a token while a Send or batch
+	// retry runs in this process so later ones wait their turn
+	sendQueue chan struct{}
 }
 
 // NewExecutionService creates a new execution service
@@ -32,9 +81,69 @@ func NewExecutionService(
 	tradeClient *TradeServiceClient,
 	logger *zap.Logger,
 	cfg *config.Config,
-) *ExecutionService {
+) (*ExecutionService, error) {
+	tradeDateLoc, err := time.LoadLocation(cfg.TradeDateTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trade date timezone %q: %w", cfg.TradeDateTimezone, err)
+	}
+
 	fileGenerator := NewFileGeneratorService(cfg.OutputDir, logger)
+	if err := fileGenerator.SetOutputFormat(cfg.OutputFormat); err != nil {
+		return nil, err
+	}
+	if err := fileGenerator.SetCSVLineEnding(cfg.CSVLineEnding); err != nil {
+		return nil, err
+	}
+	fileGenerator.SetCSVWriteBOM(cfg.CSVWriteBOM)
+	fileGenerator.SetWriteChecksumSidecar(cfg.WriteChecksumSidecar)
+	fileGenerator.SetValidateGeneratedFile(cfg.ValidateGeneratedFile)
+	fileMode, dirMode, err := cfg.OutputModes()
+	if err != nil {
+		return nil, err
+	}
+	fileGenerator.SetOutputModes(fileMode, dirMode)
+	fileGenerator.SetSplitByPortfolio(cfg.SplitOutputByPortfolio)
+	fileGenerator.SetTradeTypeMapping(cfg.TradeTypeMapping)
+	if cfg.ReconciliationEnabled {
+		if _, err := newTotalAmountReconciler(cfg.ReconciliationTolerance, cfg.ReconciliationPolicy); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateTotalAmountPolicy(cfg.TotalAmountPolicy); err != nil {
+		return nil, err
+	}
+	switch cfg.PortfolioLookupFailurePolicy {
+	case "", PortfolioLookupFailurePolicyError, PortfolioLookupFailurePolicySkip:
+	default:
+		return nil, fmt.Errorf("unsupported portfolio lookup failure policy %q, expected %q or %q",
+			cfg.PortfolioLookupFailurePolicy, PortfolioLookupFailurePolicyError, PortfolioLookupFailurePolicySkip)
+	}
+	switch cfg.ZeroQuantityFilledPolicy {
+	case "", ZeroQuantityFilledPolicyReject, ZeroQuantityFilledPolicySkip, ZeroQuantityFilledPolicyPass:
+	default:
+		return nil, fmt.Errorf("unsupported zero quantity filled policy %q, expected %q, %q or %q",
+			cfg.ZeroQuantityFilledPolicy, ZeroQuantityFilledPolicyReject, ZeroQuantityFilledPolicySkip, ZeroQuantityFilledPolicyPass)
+	}
+	switch cfg.SendWindowOverflowPolicy {
+	case "", SendWindowOverflowPolicySplit, SendWindowOverflowPolicyRefuse:
+	default:
+		return nil, fmt.Errorf("unsupported send window overflow policy %q, expected %q or %q",
+			cfg.SendWindowOverflowPolicy, SendWindowOverflowPolicySplit, SendWindowOverflowPolicyRefuse)
+	}
 	cliInvoker := NewCLIInvokerService(cfg.CLICommand, logger)
+	cliInvoker.SetWorkingDir(cfg.CLIWorkingDir)
+	cliInvoker.SetEnv(cfg.CLIEnv)
+	cliInvoker.SetTimeoutGrace(cfg.CLITimeoutGrace)
+	cliInvoker.SetHealthCheck(cfg.CLIHealthCheckCommand, cfg.CLIHealthCheckTimeout)
+
+	var sendQueue chan struct{}
+	if cfg.SendQueueEnabled {
+		sendQueue = make(chan struct{}, 1)
+	}
+
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(domain.DecimalValidationValue, domain.Decimal{})
+	validate.RegisterStructValidation(executionStatusValidation(cfg.ExecutionStatuses()), domain.ExecutionPostDTO{})
 
 	return &ExecutionService{
 		executionRepo:    executionRepo,
@@ -43,9 +152,101 @@ func NewExecutionService(
 		fileGenerator:    fileGenerator,
 		cliInvoker:       cliInvoker,
 		logger:           logger,
-		validator:        validator.New(),
+		validator:        validate,
 		config:           cfg,
+		tradeDateLoc:     tradeDateLoc,
+		now:              time.Now,
+		sendQueue:        sendQueue,
+	}, nil
+}
+
+// SetAuditRepository enables audit logging of Send and batch retry invocations
+func (s *ExecutionService) SetAuditRepository(auditRepo *repository.AuditLogRepository) {
+	s.auditRepo = auditRepo
+}
+
+// SetSkippedExecutionRepository enables storing reprocessable skips, which Reprocess requires
+func (s *ExecutionService) SetSkippedExecutionRepository(skippedRepo *repository.SkippedExecutionRepository) {
+	s.skippedRepo = skippedRepo
+}
+
+// SetRejectedExecutionRepository enables listing rejected executions. They are only
+// recorded when rejected_executions_enabled is set.
+func (s *ExecutionService) SetRejectedExecutionRepository(rejectedRepo *repository.RejectedExecutionRepository) {
+	s.rejectedRepo = rejectedRepo
+}
+
+// SetCompletionWebhook enables a notification after each Send or batch retry that ran a batch
+func (s *ExecutionService) SetCompletionWebhook(webhook *WebhookNotifier) {
+	s.webhook = webhook
+}
+
+// SetMetrics enables metrics for execution creates, Send batches, generated files and
+// CLI pipeline steps
+func (s *ExecutionService) SetMetrics(metrics observability.MetricsRecorder) {
+	s.metrics = metrics
+	s.cliInvoker.SetMetrics(metrics)
+}
+
+// SetExecutionBroker enables publishing each created execution to the execution stream
+func (s *ExecutionService) SetExecutionBroker(events *ExecutionBroker) {
+	s.events = events
+}
+
+// SubscribeExecutions subscribes to executions as they are created; see ExecutionBroker.Subscribe
+func (s *ExecutionService) SubscribeExecutions() (<-chan domain.ExecutionDTO, func(), error) {
+	if s.events == nil {
+		return nil, nil, fmt.Errorf("execution stream is not configured")
+	}
+	return s.events.Subscribe()
+}
+
+// SeedLastSuccessfulSend initializes the time-since-last-successful-Send metric from
+// the newest completed batch, so the gauge is meaningful right after a restart
+func (s *ExecutionService) SeedLastSuccessfulSend(ctx context.Context) error {
+	if s.metrics == nil {
+		return nil
 	}
+
+	startTime, err := s.batchHistoryRepo.GetLatestCompletedStartTime(ctx)
+	if err != nil {
+		return err
+	}
+	if startTime != nil {
+		s.metrics.RecordSuccessfulSend(ctx, *startTime)
+	}
+	return nil
+}
+
+// maxLoggedTradeDateMismatches caps the execution IDs logged by CheckTradeDates
+const maxLoggedTradeDateMismatches = 20
+
+// CheckTradeDates samples the sampleSize most recent executions and logs a warning if
+// any trade_date differs from the date of sent_timestamp in the trade date timezone,
+// which would point to a timezone or truncation bug. It returns the number found.
+func (s *ExecutionService) CheckTradeDates(ctx context.Context, sampleSize int) (int, error) {
+	ids, err := s.executionRepo.TradeDateMismatches(ctx, s.tradeDateLoc.String(), sampleSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) > 0 {
+		logged := ids
+		if len(logged) > maxLoggedTradeDateMismatches {
+			logged = logged[:maxLoggedTradeDateMismatches]
+		}
+		s.logger.Warn("Executions have a trade date that does not match their sent timestamp",
+			zap.Int("mismatches", len(ids)),
+			zap.Int("sample_size", sampleSize),
+			zap.String("timezone", s.tradeDateLoc.String()),
+			zap.Ints("execution_ids", logged))
+	}
+	return len(ids), nil
+}
+
+// CheckCLI runs the configured CLI health check command, for readiness probes
+func (s *ExecutionService) CheckCLI(ctx context.Context) error {
+	return s.cliInvoker.CheckInvokable(ctx)
 }
 
 // CreateBatch processes a batch of execution requests
@@ -61,22 +262,9 @@ func (s *ExecutionService) CreateBatch(ctx context.Context, executions []domain.
 	s.logger.Info("Processing execution batch", zap.Int("batch_size", len(executions)))
 
 	response := &domain.BatchCreateResponse{
-		Results: make([]domain.ExecutionResult, 0, len(executions)),
-	}
-
-	for _, executionDTO := range executions {
-		result := s.processExecution(ctx, executionDTO)
-		response.Results = append(response.Results, result)
-
-		switch result.Status {
-		case "created":
-			response.ProcessedCount++
-		case "skipped":
-			response.SkippedCount++
-		case "error":
-			response.ErrorCount++
-		}
+		Results: s.processExecutions(ctx, executions),
 	}
+	response.CalculateTotals()
 
 	s.logger.Info("Batch processing completed",
 		zap.Int("processed", response.ProcessedCount),
@@ -86,6 +274,61 @@ func (s *ExecutionService) CreateBatch(ctx context.Context, executions []domain.
 	return response, nil
 }
 
+// processExecutions processes the executions using up to BatchConcurrency workers.
+// Results are returned in the same order as the input. Only the first occurrence of
+// an executionServiceId is processed; later occurrences in the same batch are skipped
+// up front, so concurrent workers never race on the existence check for the same ID.
+func (s *ExecutionService) processExecutions(ctx context.Context, executions []domain.ExecutionPostDTO) []domain.ExecutionResult {
+	results := make([]domain.ExecutionResult, len(executions))
+
+	pending := make([]int, 0, len(executions))
+	for i, duplicate := range duplicatesInBatch(executions) {
+		executionDTO := executions[i]
+		if duplicate {
+			results[i] = domain.ExecutionResult{
+				ExecutionServiceID: executionDTO.ExecutionServiceID,
+				Status:             "skipped",
+				Reason:             domain.SkipReasonDuplicateInBatch,
+				Error:              "execution service ID appears more than once in batch",
+			}
+			s.logger.Debug("Skipping duplicate execution in batch", zap.Int("execution_service_id", executionDTO.ExecutionServiceID))
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	workers := s.config.BatchConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Each index is written by exactly one worker, so no locking is needed
+				results[i] = s.processExecution(ctx, executions[i])
+				s.storeSkip(ctx, executions[i], results[i])
+				s.recordRejection(ctx, executions[i], results[i])
+			}
+		}()
+	}
+
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
 // processExecution processes a single execution DTO
 func (s *ExecutionService) processExecution(ctx context.Context, executionDTO domain.ExecutionPostDTO) domain.ExecutionResult {
 	result := domain.ExecutionResult{
@@ -93,7 +336,7 @@ func (s *ExecutionService) processExecution(ctx context.Context, executionDTO do
 	}
 
 	// Validate input
-	if err := s.validator.Struct(executionDTO); err != nil {
+	if err := s.validateExecution(executionDTO); err != nil {
 		result.Status = "error"
 		result.Error = fmt.Sprintf("validation failed: %v", err)
 		return result
@@ -102,31 +345,63 @@ func (s *ExecutionService) processExecution(ctx context.Context, executionDTO do
 	// Skip open executions
 	if executionDTO.IsOpen {
 		result.Status = "skipped"
+		result.Reason = domain.SkipReasonOpen
 		result.Error = "execution is still open"
 		s.logger.Debug("Skipping open execution", zap.Int("execution_service_id", executionDTO.ExecutionServiceID))
 		return result
 	}
 
-	// Check if execution already exists
-	existing, err := s.executionRepo.GetByExecutionServiceID(ctx, executionDTO.ExecutionServiceID)
+	// Check if execution already exists. Soft-deleted executions still occupy their
+	// executionServiceId (it is unique), so they count as existing and are never re-created.
+	existing, err := s.executionRepo.GetByExecutionServiceID(ctx, executionDTO.ExecutionServiceID, true)
+	if err != nil && !errors.Is(err, apperrors.ErrExecutionNotFound) {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("failed to check for existing execution: %v", err)
+		return result
+	}
+	if err == nil && existing != nil && existing.DeletedAt != nil {
+		result.Status = "skipped"
+		result.Reason = domain.SkipReasonDeleted
+		result.Error = "execution was previously deleted"
+		result.ExecutionID = &existing.ID
+		s.logger.Debug("Execution was previously deleted", zap.Int("execution_service_id", executionDTO.ExecutionServiceID))
+		return result
+	}
 	if err == nil && existing != nil {
 		result.Status = "skipped"
+		result.Reason = domain.SkipReasonAlreadyExists
 		result.Error = "execution already exists"
 		result.ExecutionID = &existing.ID
+		result.PortfolioID = existing.PortfolioID
 		s.logger.Debug("Execution already exists", zap.Int("execution_service_id", executionDTO.ExecutionServiceID))
 		return result
 	}
 
-	// Get portfolio ID from Trade Service
-	portfolioID, err := s.getPortfolioIDFromTradeService(ctx, executionDTO.ExecutionServiceID)
+	// Policies apply only to executions about to be created, so a re-post of an execution
+	// that is stored is still reported as already existing
+	if skipReason, fieldErr := s.checkPolicies(executionDTO); fieldErr != nil {
+		return s.turnedAwayByPolicy(ctx, result, skipReason, fieldErr)
+	}
+
+	// Use the caller's portfolio ID when supplied; otherwise get it from Trade Service
+	var portfolioID string
+	if executionDTO.PortfolioID != nil && *executionDTO.PortfolioID != "" {
+		portfolioID = *executionDTO.PortfolioID
+	} else {
+		portfolioID, err = s.getPortfolioIDFromTradeService(ctx, executionDTO.ExecutionServiceID)
+		if err != nil {
+			return s.portfolioLookupFailed(ctx, result, err)
+		}
+	}
+
+	// Convert DTO to domain model
+	execution, err := s.dtoToExecution(executionDTO, portfolioID)
 	if err != nil {
 		result.Status = "error"
-		result.Error = fmt.Sprintf("failed to get portfolio ID: %v", err)
+		result.Error = err.Error()
 		return result
 	}
-
-	// Convert DTO to domain model
-	execution := s.dtoToExecution(executionDTO, portfolioID)
+	recordCreateSpan(ctx, execution)
 
 	// Save execution
 	if err := s.executionRepo.Create(ctx, execution); err != nil {
@@ -136,44 +411,329 @@ func (s *ExecutionService) processExecution(ctx context.Context, executionDTO do
 	}
 
 	result.Status = "created"
+	if s.metrics != nil {
+		s.metrics.RecordExecutionCreated(ctx, execution.TradeType, execution.Destination)
+	}
 	result.ExecutionID = &execution.ID
+	result.PortfolioID = execution.PortfolioID
 	s.logger.Info("Execution created successfully",
 		zap.Int("id", execution.ID),
 		zap.Int("execution_service_id", execution.ExecutionServiceID))
+	if s.events != nil {
+		s.events.Publish(execution.ToDTO())
+	}
 
 	return result
 }
 
-// getPortfolioIDFromTradeService retrieves portfolio ID from Trade Service
-func (s *ExecutionService) getPortfolioIDFromTradeService(ctx context.Context, executionServiceID int) (string, error) {
-	response, err := s.tradeClient.GetExecutionByServiceID(ctx, executionServiceID)
+// portfolioLookupFailed completes result for an execution whose portfolio ID could not
+// be resolved, as an error or, under the skip policy, as a skip to retry in a later batch
+func (s *ExecutionService) portfolioLookupFailed(ctx context.Context, result domain.ExecutionResult, err error) domain.ExecutionResult {
+	result.Error = fmt.Sprintf("failed to get portfolio ID: %v", err)
+
+	if s.config.PortfolioLookupFailurePolicy == PortfolioLookupFailurePolicySkip {
+		result.Status = "skipped"
+		result.Reason = domain.SkipReasonPortfolioLookupFailed
+		if s.metrics != nil {
+			s.metrics.RecordExecutionSkipped(ctx, domain.SkipReasonPortfolioLookupFailed)
+		}
+		s.logger.Warn("Skipping execution after portfolio lookup failure",
+			zap.Int("execution_service_id", result.ExecutionServiceID),
+			zap.Error(err))
+		return result
+	}
+
+	result.Status = "error"
+	if s.metrics != nil {
+		s.metrics.RecordExecutionError(ctx, domain.SkipReasonPortfolioLookupFailed)
+	}
+	return result
+}
+
+// turnedAwayByPolicy completes result for an execution a configured policy turned away,
+// as a skip when checkPolicies gave a skip reason and as an error otherwise
+func (s *ExecutionService) turnedAwayByPolicy(ctx context.Context, result domain.ExecutionResult, skipReason string, fieldErr *domain.FieldError) domain.ExecutionResult {
+	result.Error = fieldErr.Message
+
+	if skipReason != "" {
+		result.Status = "skipped"
+		result.Reason = skipReason
+		if s.metrics != nil {
+			s.metrics.RecordExecutionSkipped(ctx, skipReason)
+		}
+		s.logger.Warn("Skipping execution turned away by policy",
+			zap.Int("execution_service_id", result.ExecutionServiceID),
+			zap.String("reason", skipReason),
+			zap.String("detail", fieldErr.Message))
+		return result
+	}
+
+	result.Status = "error"
+	if s.metrics != nil {
+		s.metrics.RecordExecutionError(ctx, fieldErr.Rule)
+	}
+	return result
+}
+
+// storeSkip keeps the payload of an execution skipped for a reason that can clear up
+// later, so it can be reprocessed. Failures are logged; the skip is still reported.
+func (s *ExecutionService) storeSkip(ctx context.Context, executionDTO domain.ExecutionPostDTO, result domain.ExecutionResult) {
+	if s.skippedRepo == nil || result.Status != "skipped" || !domain.IsReprocessableSkipReason(result.Reason) {
+		return
+	}
+
+	payload, err := json.Marshal(executionDTO)
+	if err == nil {
+		err = s.skippedRepo.Upsert(ctx, &domain.SkippedExecution{
+			ExecutionServiceID: executionDTO.ExecutionServiceID,
+			Reason:             result.Reason,
+			Payload:            payload,
+		})
+	}
 	if err != nil {
-		return "", fmt.Errorf("trade service call failed: %w", err)
+		s.logger.Warn("Failed to store skipped execution for reprocessing",
+			zap.Int("execution_service_id", executionDTO.ExecutionServiceID),
+			zap.Error(err))
 	}
+}
 
-	if len(response.Executions) == 0 {
-		return "", fmt.Errorf("no execution found in trade service for ID %d", executionServiceID)
+// storeReconciliationSkips records the executions a Send excluded for failing total
+// amount reconciliation as skipped, so they are not lost once the batch moves past
+// them. The execution is already stored, so the record is kept for review only and
+// Reprocess refuses it. Failures are logged; the batch outcome stands.
+func (s *ExecutionService) storeReconciliationSkips(ctx context.Context, excluded []domain.Execution) {
+	if s.skippedRepo == nil {
+		return
 	}
 
-	execution := response.Executions[0]
-	portfolioID := execution.TradeOrder.Portfolio.PortfolioID
+	for _, execution := range excluded {
+		payload, err := json.Marshal(execution.ToDTO())
+		if err == nil {
+			err = s.skippedRepo.Upsert(ctx, &domain.SkippedExecution{
+				ExecutionServiceID: execution.ExecutionServiceID,
+				Reason:             domain.SkipReasonTotalAmountMismatch,
+				Payload:            payload,
+			})
+		}
+		if err != nil {
+			s.logger.Warn("Failed to store execution excluded by reconciliation",
+				zap.Int("execution_service_id", execution.ExecutionServiceID),
+				zap.Error(err))
+			continue
+		}
+		if s.metrics != nil {
+			s.metrics.RecordExecutionSkipped(ctx, domain.SkipReasonTotalAmountMismatch)
+		}
+	}
+}
 
-	if portfolioID == "" {
-		return "", fmt.Errorf("portfolio ID is empty for execution service ID %d", executionServiceID)
+// recordRejection records an execution that was not created, when enabled. Failures
+// are logged; the result is still reported.
+func (s *ExecutionService) recordRejection(ctx context.Context, executionDTO domain.ExecutionPostDTO, result domain.ExecutionResult) {
+	if s.rejectedRepo == nil || !s.config.RejectedExecutionsEnabled || result.Status == "created" {
+		return
 	}
 
-	return portfolioID, nil
+	rejected := &domain.RejectedExecution{
+		ExecutionServiceID: executionDTO.ExecutionServiceID,
+		Status:             result.Status,
+	}
+	if result.Reason != "" {
+		rejected.Reason = &result.Reason
+	}
+	if result.Error != "" {
+		rejected.Error = &result.Error
+	}
+
+	payload, err := json.Marshal(executionDTO)
+	if err == nil {
+		rejected.Payload = payload
+		err = s.rejectedRepo.Create(ctx, rejected)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to record rejected execution",
+			zap.Int("execution_service_id", executionDTO.ExecutionServiceID),
+			zap.Error(err))
+	}
 }
 
-// dtoToExecution converts ExecutionPostDTO to Execution domain model
-func (s *ExecutionService) dtoToExecution(dto domain.ExecutionPostDTO, portfolioID string) *domain.Execution {
+// Reprocess re-runs creation, including the portfolio lookup, for stored skipped
+// executions selected by executionServiceId, by skip reason or by updated payloads.
+// Executions selected by ID or reason are reprocessed from the payload stored when they
+// were skipped, so one skipped while open is skipped again until it is reprocessed with
+// an updated payload. A stored skip is removed with the execution it creates, or once
+// its execution is found to already exist; requested IDs with no stored skip, or with a
+// record-only skip such as a total amount reconciliation exclusion, are reported as
+// errors and their skips kept.
+func (s *ExecutionService) Reprocess(ctx context.Context, request domain.ReprocessRequest) (*domain.BatchCreateResponse, error) {
+	if s.skippedRepo == nil {
+		return nil, fmt.Errorf("reprocessing is not enabled")
+	}
+
+	requestedIDs := request.ExecutionServiceIDs
+	if len(request.Executions) > 0 {
+		requestedIDs = make([]int, len(request.Executions))
+		for i, executionDTO := range request.Executions {
+			requestedIDs[i] = executionDTO.ExecutionServiceID
+		}
+	}
+	if len(requestedIDs) > domain.MaxReprocessBatchSize {
+		return nil, fmt.Errorf("reprocess size exceeds maximum of %d executions", domain.MaxReprocessBatchSize)
+	}
+
+	var skipped []domain.SkippedExecution
+	var err error
+	if len(requestedIDs) > 0 {
+		skipped, err = s.skippedRepo.ListByExecutionServiceIDs(ctx, requestedIDs)
+	} else {
+		skipped, err = s.skippedRepo.ListByReason(ctx, request.Reason, domain.MaxReprocessBatchSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[int]bool, len(skipped))
+	recordOnly := make(map[int]string) // skip reason of stored skips that cannot be reprocessed
+	reprocessable := skipped[:0]
+	for _, skip := range skipped {
+		if !domain.IsReprocessableSkipReason(skip.Reason) {
+			recordOnly[skip.ExecutionServiceID] = skip.Reason
+			continue
+		}
+		stored[skip.ExecutionServiceID] = true
+		reprocessable = append(reprocessable, skip)
+	}
+	skipped = reprocessable
+	var executions []domain.ExecutionPostDTO
+	if len(request.Executions) > 0 {
+		for _, executionDTO := range request.Executions {
+			if stored[executionDTO.ExecutionServiceID] {
+				executions = append(executions, executionDTO)
+			}
+		}
+	} else {
+		executions = make([]domain.ExecutionPostDTO, len(skipped))
+		for i, skip := range skipped {
+			if err := json.Unmarshal(skip.Payload, &executions[i]); err != nil {
+				return nil, fmt.Errorf("failed to decode skipped execution %d: %w", skip.ExecutionServiceID, err)
+			}
+		}
+	}
+
+	s.logger.Info("Reprocessing skipped executions", zap.Int("count", len(executions)))
+
+	results := s.processExecutions(ctx, executions)
+	for _, result := range results {
+		// Creating the execution already removed its stored skip
+		if result.Status == "skipped" && !domain.IsReprocessableSkipReason(result.Reason) {
+			if err := s.skippedRepo.Delete(ctx, result.ExecutionServiceID); err != nil {
+				s.logger.Warn("Failed to clear reprocessed skip",
+					zap.Int("execution_service_id", result.ExecutionServiceID),
+					zap.Error(err))
+			}
+		}
+	}
+	for _, executionServiceID := range requestedIDs {
+		if reason, ok := recordOnly[executionServiceID]; ok {
+			// Such as an execution a Send excluded for failing total amount reconciliation,
+			// which is already stored; its skip is kept for review
+			results = append(results, domain.ExecutionResult{
+				ExecutionServiceID: executionServiceID,
+				Status:             "error",
+				Reason:             reason,
+				Error:              fmt.Sprintf("execution is already stored and was skipped for %s, which cannot be reprocessed", reason),
+			})
+			delete(recordOnly, executionServiceID)
+			stored[executionServiceID] = true
+			continue
+		}
+		if !stored[executionServiceID] {
+			results = append(results, domain.ExecutionResult{
+				ExecutionServiceID: executionServiceID,
+				Status:             "error",
+				Error:              "no stored skip for execution service ID",
+			})
+			stored[executionServiceID] = true
+		}
+	}
+
+	response := &domain.BatchCreateResponse{Results: results}
+	response.CalculateTotals()
+
+	s.logger.Info("Reprocessing completed",
+		zap.Int("processed", response.ProcessedCount),
+		zap.Int("skipped", response.SkippedCount),
+		zap.Int("errors", response.ErrorCount))
+
+	return response, nil
+}
+
+// BulkLoad loads executions with PostgreSQL COPY, for backfills too large for
+// CreateBatch. Each execution must pass ValidateBatch without a skip, be closed and
+// carry its portfolio ID, since the load makes no Trade Service lookups; otherwise
+// nothing is loaded and the offending results are returned. The load itself is all or
+// nothing, so an executionServiceId already stored fails it. Returns
+// apperrors.ErrBulkLoadDisabled unless database.bulk_load_enabled is set.
+func (s *ExecutionService) BulkLoad(ctx context.Context, executionDTOs []domain.ExecutionPostDTO) (*domain.BulkLoadResponse, error) {
+	response := &domain.BulkLoadResponse{}
+	for _, result := range s.ValidateBatch(executionDTOs).Results {
+		executionDTO := executionDTOs[result.Index]
+		switch {
+		case !result.Valid || result.SkipReason != "":
+		case executionDTO.IsOpen:
+			result.SkipReason = domain.SkipReasonOpen
+		case executionDTO.PortfolioID == nil || *executionDTO.PortfolioID == "":
+			result.Valid = false
+			result.Errors = []domain.FieldError{{
+				Field:   "portfolioId",
+				Rule:    "required",
+				Message: "portfolioId is required for bulk load",
+			}}
+		default:
+			continue
+		}
+		response.Results = append(response.Results, result)
+	}
+	if len(response.Results) > 0 {
+		return response, nil
+	}
+
+	executions := make([]domain.Execution, len(executionDTOs))
+	for i, executionDTO := range executionDTOs {
+		execution, err := s.dtoToExecution(executionDTO, *executionDTO.PortfolioID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert execution %d: %w", executionDTO.ExecutionServiceID, err)
+		}
+		executions[i] = *execution
+	}
+
+	loaded, err := s.executionRepo.BulkLoad(ctx, executions)
+	if err != nil {
+		return nil, err
+	}
+	if s.metrics != nil {
+		for _, execution := range executions {
+			s.metrics.RecordExecutionCreated(ctx, execution.TradeType, execution.Destination)
+		}
+	}
+	response.LoadedCount = loaded
+	return response, nil
+}
+
+// getPortfolioIDFromTradeService retrieves portfolio ID from Trade Service
+func (s *ExecutionService) getPortfolioIDFromTradeService(ctx context.Context, executionServiceID int) (string, error) {
+	return s.tradeClient.ResolvePortfolioID(ctx, executionServiceID)
+}
+
+// dtoToExecution converts ExecutionPostDTO to Execution domain model, applying the
+// configured total amount policy
+func (s *ExecutionService) dtoToExecution(dto domain.ExecutionPostDTO, portfolioID string) (*domain.Execution, error) {
 	now := time.Now()
 
-	// Determine trade date based on US Eastern Time
-	easternLoc, _ := time.LoadLocation("America/New_York")
-	tradeDate := dto.SentTimestamp.In(easternLoc).Truncate(24 * time.Hour)
+	// Determine trade date in the configured trading timezone
+	tradeDate := domain.CalculateTradeDate(dto.SentTimestamp, s.tradeDateLoc)
 
-	return &domain.Execution{
+	execution := &domain.Execution{
 		ExecutionServiceID:   dto.ExecutionServiceID,
 		IsOpen:               false, // We only process closed executions
 		ExecutionStatus:      dto.ExecutionStatus,
@@ -194,6 +754,10 @@ func (s *ExecutionService) dtoToExecution(dto domain.ExecutionPostDTO, portfolio
 		ReadyToSendTimestamp: now.UTC(),
 		Version:              1,
 	}
+	if err := applyTotalAmountPolicy(execution, s.config.TotalAmountPolicy, s.config.ReconciliationTolerance); err != nil {
+		return nil, err
+	}
+	return execution, nil
 }
 
 // GetByID retrieves an execution by ID
@@ -207,85 +771,701 @@ func (s *ExecutionService) GetByID(ctx context.Context, id int) (*domain.Executi
 	return &dto, nil
 }
 
-// List retrieves executions with pagination
-func (s *ExecutionService) List(ctx context.Context, limit, offset int) (*domain.ExecutionListResponse, error) {
+// List retrieves executions matching the filter with pagination
+func (s *ExecutionService) List(ctx context.Context, filter domain.ExecutionFilter, sort domain.ExecutionSort, limit, offset int) (*domain.ExecutionListResponse, error) {
 	// Set default and maximum limits
+	defaultSize, maxSize := s.PageSizeLimits()
 	if limit <= 0 {
-		limit = 50
+		limit = defaultSize
 	}
-	if limit > 1000 {
-		limit = 1000
+	if limit > maxSize {
+		limit = maxSize
 	}
 
-	executions, totalCount, err := s.executionRepo.List(ctx, limit, offset)
+	executions, totalCount, err := s.executionRepo.List(ctx, filter, sort, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list executions: %w", err)
 	}
 
-	// Convert to DTOs
+	// Convert to DTOs in one pre-sized slice, indexing to avoid copying each execution
 	executionDTOs := make([]domain.ExecutionDTO, len(executions))
-	for i, execution := range executions {
-		executionDTOs[i] = execution.ToDTO()
+	for i := range executions {
+		executionDTOs[i] = executions[i].ToDTO()
 	}
 
-	// Calculate pagination info
-	totalPages := (totalCount + limit - 1) / limit
-	currentPage := offset / limit
-
 	response := &domain.ExecutionListResponse{
 		Executions: executionDTOs,
-		Pagination: domain.PaginationInfo{
-			TotalElements: totalCount,
-			TotalPages:    totalPages,
-			CurrentPage:   currentPage,
-			PageSize:      limit,
-			HasNext:       offset+limit < totalCount,
-			HasPrevious:   offset > 0,
-		},
+		Pagination: domain.NewPaginationInfo(totalCount, limit, offset),
 	}
 
 	return response, nil
 }
 
+// PageSizeLimits returns the configured default and maximum list page sizes
+func (s *ExecutionService) PageSizeLimits() (int, int) {
+	return s.config.PageSizeLimits()
+}
+
+// Count retrieves the number of executions matching the filter
+func (s *ExecutionService) Count(ctx context.Context, filter domain.ExecutionFilter) (*domain.ExecutionCountResponse, error) {
+	count, err := s.executionRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count executions: %w", err)
+	}
+
+	return &domain.ExecutionCountResponse{Count: count}, nil
+}
+
+// GetStats retrieves aggregate execution counts matching the filter
+func (s *ExecutionService) GetStats(ctx context.Context, filter domain.ExecutionFilter) (*domain.ExecutionStats, error) {
+	stats, err := s.executionRepo.GetStats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// DeleteByFilter soft-deletes all live executions matching filter, refusing with
+// apperrors.ErrExecutionsAlreadySent if any fall inside a batch window
+func (s *ExecutionService) DeleteByFilter(ctx context.Context, filter domain.ExecutionFilter) (*domain.BulkDeleteResponse, error) {
+	deleted, err := s.executionRepo.DeleteByFilter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete executions: %w", err)
+	}
+
+	return &domain.BulkDeleteResponse{DeletedCount: deleted}, nil
+}
+
+// SendOptions adjust a single Send
+type SendOptions struct {
+	// BatchKey makes the Send idempotent; see SendWithBatchKey
+	BatchKey string
+	// Force sends the whole window even when it spans longer than MaxSendWindowDuration
+	Force bool
+}
+
 // Send processes executions for Portfolio Accounting
 func (s *ExecutionService) Send(ctx context.Context) (*domain.SendResponse, error) {
-	s.logger.Info("Starting execution send process")
+	return s.SendWithOptions(ctx, SendOptions{})
+}
+
+// SendWithBatchKey is Send made idempotent by a client-supplied batch key: if the batch
+// created with batchKey already completed, its stored response is returned instead of
+// starting a new batch. An empty key sends exactly like Send.
+func (s *ExecutionService) SendWithBatchKey(ctx context.Context, batchKey string) (*domain.SendResponse, error) {
+	return s.SendWithOptions(ctx, SendOptions{BatchKey: batchKey})
+}
+
+// SendWithOptions is Send adjusted by opts
+func (s *ExecutionService) SendWithOptions(ctx context.Context, opts SendOptions) (response *domain.SendResponse, err error) {
+	batchKey := opts.BatchKey
+	s.logger.Info("Starting execution send process", zap.String("batch_key", batchKey), zap.Bool("force", opts.Force))
 
-	// Step 1: Get max start time from batch history
-	previousStartTime, err := s.batchHistoryRepo.GetMaxStartTime(ctx)
+	ctx, span := startSpan(ctx, "execution.send")
+	defer func() { endSpan(span, err) }()
+
+	// Every invocation is audited, whatever the outcome
+	var batchID *int
+	defer func() {
+		s.writeAudit(ctx, domain.AuditActionSend, batchID, response, err)
+		s.notifyCompletion(ctx, batchID, response, err)
+	}()
+
+	// Only one Send may run cluster-wide; the unique indexes on batch_history are the
+	// backstop if the lock is bypassed
+	release, err := s.acquireSendLock(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get max start time: %w", err)
+		return nil, err
 	}
+	defer release()
+
+	// Checked under the Send lock so a retry racing the original waits for its outcome
+	var key *string
+	if batchKey != "" {
+		key = &batchKey
+		span.SetAttributes(attribute.String("batch.key", batchKey))
+		replayed, err := s.replayBatch(ctx, batchKey)
+		if err != nil || replayed != nil {
+			return replayed, err
+		}
+	}
+
+	// Steps 1 & 2: Choose the batch window and record it in batch history. A window split
+	// by MaxSendWindowDuration is sent as consecutive batches, all under this lock, so the
+	// response covers the whole window; a batch key goes on the last batch, whose stored
+	// response is the one replayed.
+	var lastBatch *domain.BatchHistory
+	for {
+		previousStartTime, currentTime, split, moreRemain, ok, err := s.sendWindow(ctx, opts.Force)
+		if err != nil {
+			return response, err
+		}
+		if !ok && response != nil {
+			// Executions past an earlier split were deleted meanwhile, so the batch already
+			// sent is the last one and takes the batch key
+			s.storeLateBatchKey(ctx, lastBatch, key, response)
+			return response, nil
+		}
+		if !ok {
+			s.logger.Info("Send window holds no executions, no batch recorded",
+				zap.Time("previous_start_time", previousStartTime))
+			if s.metrics != nil {
+				// Nothing was waiting, so the Send succeeded without a batch
+				s.metrics.RecordSuccessfulSend(ctx, s.now().UTC())
+			}
+			return &domain.SendResponse{
+				ProcessedCount: 0,
+				FileName:       "",
+				Status:         "success",
+				Message:        "No executions to process",
+			}, nil
+		}
+		last := !split || moreRemain
+
+		batchHistory := &domain.BatchHistory{
+			StartTime:         currentTime,
+			PreviousStartTime: previousStartTime,
+			Status:            domain.BatchStatusInProgress,
+			Version:           1,
+		}
+		if last {
+			batchHistory.BatchKey = key
+		}
+
+		if err := s.createBatchHistory(ctx, batchHistory); err != nil {
+			// A uniqueness constraint violation surfaces as apperrors.ErrDuplicateBatch
+			return response, fmt.Errorf("failed to create batch history: %w", err)
+		}
+		batchID = &batchHistory.ID
+		lastBatch = batchHistory
+		span.SetAttributes(attribute.Int("batch.id", batchHistory.ID))
 
-	// Step 2: Create new batch history record
-	currentTime := time.Now().UTC()
-	batchHistory := &domain.BatchHistory{
-		StartTime:         currentTime,
-		PreviousStartTime: previousStartTime,
-		Version:           1,
+		s.logger.Info("Batch history created",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.Time("start_time", currentTime),
+			zap.Time("previous_start_time", previousStartTime),
+			zap.Bool("split", split),
+			zap.Bool("more_remain", moreRemain))
+
+		batchStart := time.Now()
+		batchResponse, err := s.processBatch(ctx, batchHistory)
+		s.recordBatchMetrics(ctx, "send", time.Since(batchStart), batchResponse)
+		if batchResponse != nil {
+			batchResponse.BatchID = batchHistory.ID
+		}
+		response = mergeSendResponses(response, batchResponse)
+		if err != nil {
+			return response, err
+		}
+		if !last {
+			continue
+		}
+
+		if moreRemain {
+			response.Message += "; more executions remain, send again to continue"
+		}
+		s.storeSendResponse(ctx, batchHistory, response)
+		return response, nil
 	}
+}
+
+// mergeSendResponses folds the response of the next batch of a split Send into the
+// response of the batches before it. The result reports the latest batch, with the
+// counts and files of all of them.
+func mergeSendResponses(sent, next *domain.SendResponse) *domain.SendResponse {
+	if sent == nil || next == nil {
+		if next == nil {
+			return sent
+		}
+		return next
+	}
+
+	merged := *next
+	merged.ProcessedCount += sent.ProcessedCount
+	merged.MismatchedExecutionIDs = append(append([]int(nil), sent.MismatchedExecutionIDs...), next.MismatchedExecutionIDs...)
+
+	var files []string
+	for _, response := range []*domain.SendResponse{sent, next} {
+		if len(response.FileNames) > 0 {
+			files = append(files, response.FileNames...)
+		} else if response.FileName != "" {
+			files = append(files, response.FileName)
+		}
+	}
+	if len(files) > 1 {
+		merged.FileNames = files
+	}
+	// FileName stays the first file, unless it must name the one the CLI failed on
+	if next.Status != "error" && len(files) > 0 {
+		merged.FileName = files[0]
+	}
+	return &merged
+}
+
+// replayBatch returns the stored response of the completed batch created with batchKey,
+// or nil when no batch has the key yet. A keyed batch that did not complete is reported
+// as apperrors.ErrBatchKeyInUse; it is finished with RetryBatch rather than a new Send.
+func (s *ExecutionService) replayBatch(ctx context.Context, batchKey string) (*domain.SendResponse, error) {
+	batchHistory, err := s.batchHistoryRepo.GetByBatchKey(ctx, batchKey)
+	if errors.Is(err, apperrors.ErrBatchNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up batch key: %w", err)
+	}
+
+	if batchHistory.Status != domain.BatchStatusCompleted {
+		return nil, fmt.Errorf("%w: batch %d is %s", apperrors.ErrBatchKeyInUse, batchHistory.ID, batchHistory.Status)
+	}
+	if batchHistory.SendResponse == nil {
+		return nil, fmt.Errorf("%w: batch %d completed without a stored response", apperrors.ErrBatchKeyInUse, batchHistory.ID)
+	}
+
+	var response domain.SendResponse
+	if err := json.Unmarshal(batchHistory.SendResponse, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode stored response of batch %d: %w", batchHistory.ID, err)
+	}
+
+	// Responses stored before batchId was reported lack it
+	response.BatchID = batchHistory.ID
+
+	s.logger.Info("Replaying completed batch for repeated batch key",
+		zap.Int("batch_id", batchHistory.ID),
+		zap.String("batch_key", batchKey))
+	return &response, nil
+}
+
+// storeSendResponse saves the response of a completed keyed batch for replay. Failures
+// are logged since the batch itself already completed.
+func (s *ExecutionService) storeSendResponse(ctx context.Context, batchHistory *domain.BatchHistory, response *domain.SendResponse) {
+	if batchHistory.BatchKey == nil || batchHistory.Status != domain.BatchStatusCompleted || response == nil {
+		return
+	}
+
+	sendResponse, err := json.Marshal(response)
+	if err == nil {
+		err = s.batchHistoryRepo.UpdateSendResponse(context.WithoutCancel(ctx), batchHistory.ID, sendResponse)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to store batch send response",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.Error(err))
+		return
+	}
+	batchHistory.SendResponse = sendResponse
+}
+
+// storeLateBatchKey gives a completed batch of a split Send the batch key and response
+// it was not created with, once it turns out to be the last batch. Like
+// storeSendResponse, failures are logged and the Send's outcome stands.
+func (s *ExecutionService) storeLateBatchKey(ctx context.Context, batchHistory *domain.BatchHistory, key *string, response *domain.SendResponse) {
+	if key == nil || batchHistory == nil || batchHistory.Status != domain.BatchStatusCompleted {
+		return
+	}
+
+	sendResponse, err := json.Marshal(response)
+	if err == nil {
+		err = s.batchHistoryRepo.UpdateBatchKey(context.WithoutCancel(ctx), batchHistory.ID, *key, sendResponse)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to store batch key of the last batch",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.String("batch_key", *key),
+			zap.Error(err))
+		return
+	}
+	batchHistory.BatchKey = key
+	batchHistory.SendResponse = sendResponse
+}
+
+// sendWindow picks the next batch window [previous start, start) in its own span.
+// Batches cover half-open windows, so consecutive batches share a boundary and every
+// ready_to_send_timestamp falls in exactly one batch. The window end lags the current
+// time so executions whose inserts are still in flight are left for the next batch
+// instead of being missed. ok is false when the window holds no executions yet, in
+// which case no batch is recorded: an empty batch would move the next window's start
+// past executions that are stamped inside it but commit later. split reports that
+// MaxSendWindowDuration cut the window short, and moreRemain that MaxSendBatchSize did;
+// force lifts the MaxSendWindowDuration limit.
+func (s *ExecutionService) sendWindow(ctx context.Context, force bool) (start, end time.Time, split, moreRemain, ok bool, err error) {
+	ctx, span := startSpan(ctx, "execution.send.window")
+	defer func() { endSpan(span, err) }()
+
+	start, err = s.batchHistoryRepo.GetMaxStartTime(ctx)
+	if err != nil {
+		return start, end, false, false, false, fmt.Errorf("failed to get max start time: %w", err)
+	}
+
+	end, ok = sendWindowEnd(start, s.now().UTC(), s.config.SendWindowLag)
+	if !ok {
+		return start, end, false, false, false, nil
+	}
+
+	first, err := s.executionRepo.ReadyTimestampAt(ctx, start, end, 0)
+	if err != nil {
+		return start, end, false, false, false, fmt.Errorf("failed to check send window: %w", err)
+	}
+	if first == nil {
+		return start, end, false, false, false, nil
+	}
+
+	end, split, err = s.limitSendWindowDuration(ctx, start, end, *first, force)
+	if err != nil {
+		return start, end, false, false, false, err
+	}
+
+	end, moreRemain, err = s.limitSendWindow(ctx, start, end, *first)
+	if err != nil {
+		return start, end, false, false, false, err
+	}
+
+	span.SetAttributes(
+		attribute.String("batch.window_start", start.Format(time.RFC3339Nano)),
+		attribute.String("batch.window_end", end.Format(time.RFC3339Nano)),
+		attribute.Bool("batch.split", split),
+		attribute.Bool("batch.more_remain", moreRemain),
+	)
+	return start, end, split, moreRemain, true, nil
+}
+
+// createBatchHistory inserts the batch history record in its own span
+func (s *ExecutionService) createBatchHistory(ctx context.Context, batchHistory *domain.BatchHistory) (err error) {
+	ctx, span := startSpan(ctx, "execution.send.create_batch")
+	defer func() { endSpan(span, err) }()
 
 	if err := s.batchHistoryRepo.Create(ctx, batchHistory); err != nil {
-		// Check if this is a uniqueness constraint violation (duplicate batch)
-		if err.Error() == "duplicate batch detected" {
-			return nil, fmt.Errorf("duplicate batch process already started")
+		return err
+	}
+	span.SetAttributes(attribute.Int("batch.id", batchHistory.ID))
+	return nil
+}
+
+// RetryBatch regenerates the file for a failed batch's stored window and re-invokes the CLI
+func (s *ExecutionService) RetryBatch(ctx context.Context, id int) (response *domain.SendResponse, err error) {
+	var retriedBatchID *int
+	defer func() {
+		s.writeAudit(ctx, domain.AuditActionBatchRetry, &id, response, err)
+		s.notifyCompletion(ctx, retriedBatchID, response, err)
+	}()
+
+	batchHistory, err := s.batchHistoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if batchHistory.Status != domain.BatchStatusFailed {
+		return nil, fmt.Errorf("%w: batch %d is %s", apperrors.ErrBatchNotFailed, id, batchHistory.Status)
+	}
+
+	release, err := s.acquireSendLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The status checked above may be stale by now; only the retry whose claim moves the
+	// batch out of failed sends it, so two retries of one batch never both run the CLI
+	claimed, err := s.batchHistoryRepo.ClaimForRetry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: batch %d was claimed by another retry", apperrors.ErrBatchNotFailed, id)
+	}
+	batchHistory.Status = domain.BatchStatusInProgress
+	batchHistory.Version++
+
+	retriedBatchID = &id
+
+	s.logger.Info("Retrying failed batch",
+		zap.Int("batch_id", batchHistory.ID),
+		zap.Time("start_time", batchHistory.StartTime),
+		zap.Time("previous_start_time", batchHistory.PreviousStartTime))
+
+	batchStart := time.Now()
+	response, err = s.processBatch(ctx, batchHistory)
+	s.recordBatchMetrics(ctx, "retry", time.Since(batchStart), response)
+	if response != nil {
+		response.BatchID = batchHistory.ID
+	}
+	s.storeSendResponse(ctx, batchHistory, response)
+	return response, err
+}
+
+// recordBatchMetrics records a finished Send or retry batch's duration, size and outcome
+func (s *ExecutionService) recordBatchMetrics(ctx context.Context, operation string, duration time.Duration, response *domain.SendResponse) {
+	if s.metrics == nil {
+		return
+	}
+
+	processedCount, status := 0, "error"
+	if response != nil {
+		processedCount, status = response.ProcessedCount, response.Status
+	}
+	s.metrics.RecordBatchProcessed(ctx, operation, status, duration, processedCount)
+}
+
+// writeAudit records a Send or retry invocation in the audit log. Audit failures are
+// logged rather than returned so they never change the outcome reported to the caller.
+func (s *ExecutionService) writeAudit(ctx context.Context, action string, batchID *int, response *domain.SendResponse, sendErr error) {
+	if s.auditRepo == nil {
+		return
+	}
+
+	entry := &domain.AuditLog{
+		Action:  action,
+		BatchID: batchID,
+		Outcome: domain.AuditOutcomeSuccess,
+	}
+	if correlationID := observability.GetCorrelationID(ctx); correlationID != "" {
+		entry.CorrelationID = &correlationID
+	}
+	if response != nil {
+		entry.ProcessedCount = response.ProcessedCount
+		if response.FileName != "" {
+			fileName := response.FileName
+			if len(response.FileNames) > 0 {
+				fileName = strings.Join(response.FileNames, ",")
+			}
+			entry.FileName = &fileName
+		}
+		message := response.Message
+		entry.Message = &message
+		if response.Status == "error" {
+			entry.Outcome = domain.AuditOutcomeError
+		}
+	}
+	if sendErr != nil {
+		entry.Outcome = domain.AuditOutcomeError
+		message := sendErr.Error()
+		entry.Message = &message
+	}
+
+	if err := s.auditRepo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		s.logger.Error("Failed to write audit log", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// notifyCompletion posts the outcome of a batch to the completion webhook in the
+// background. Nothing is sent when no batch ran, and delivery failures are logged
+// without affecting the Send.
+func (s *ExecutionService) notifyCompletion(ctx context.Context, batchID *int, response *domain.SendResponse, sendErr error) {
+	if s.webhook == nil || batchID == nil {
+		return
+	}
+
+	event := domain.SendCompletionEvent{
+		BatchID: *batchID,
+		Status:  "success",
+	}
+	if response != nil {
+		event.ProcessedCount = response.ProcessedCount
+		event.FileName = response.FileName
+		event.FileNames = response.FileNames
+		if response.Status == "error" {
+			event.Status = "error"
+			event.Error = response.Message
+		}
+	}
+	if sendErr != nil {
+		event.Status = "error"
+		event.Error = sendErr.Error()
+	}
+
+	s.webhook.NotifyAsync(ctx, event)
+}
+
+// ListAuditLogs retrieves audit log records, newest first
+func (s *ExecutionService) ListAuditLogs(ctx context.Context, limit, offset int) (*domain.AuditLogListResponse, error) {
+	if s.auditRepo == nil {
+		return nil, fmt.Errorf("audit log is not configured")
+	}
+
+	auditLogs, totalCount, err := s.auditRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	if auditLogs == nil {
+		auditLogs = []domain.AuditLog{}
+	}
+
+	return &domain.AuditLogListResponse{
+		AuditLogs:  auditLogs,
+		Pagination: domain.NewPaginationInfo(totalCount, limit, offset),
+	}, nil
+}
+
+// GetBatch retrieves a batch history record and, with includeExecutions, one page of the
+// executions in its [previous_start_time, start_time) window. Executions since deleted
+// or archived no longer appear. Unknown IDs return apperrors.ErrBatchNotFound.
+func (s *ExecutionService) GetBatch(ctx context.Context, id int, includeExecutions bool, limit, offset int) (*domain.BatchDetailResponse, error) {
+	batchHistory, err := s.batchHistoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := &domain.BatchDetailResponse{Batch: *batchHistory}
+	if !includeExecutions {
+		return response, nil
+	}
+
+	executions, totalCount, err := s.executionRepo.ListForBatch(ctx, batchHistory.PreviousStartTime, batchHistory.StartTime, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch executions: %w", err)
+	}
+	executionDTOs := make([]domain.ExecutionDTO, len(executions))
+	for i := range executions {
+		executionDTOs[i] = executions[i].ToDTO()
+	}
+	response.Executions = &domain.ExecutionListResponse{
+		Executions: executionDTOs,
+		Pagination: domain.NewPaginationInfo(totalCount, limit, offset),
+	}
+	return response, nil
+}
+
+// ListRejectedExecutions retrieves rejected executions, newest first
+func (s *ExecutionService) ListRejectedExecutions(ctx context.Context, limit, offset int) (*domain.RejectedExecutionListResponse, error) {
+	if s.rejectedRepo == nil {
+		return nil, fmt.Errorf("rejected executions are not configured")
+	}
+
+	rejected, totalCount, err := s.rejectedRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rejected executions: %w", err)
+	}
+	if rejected == nil {
+		rejected = []domain.RejectedExecution{}
+	}
+
+	return &domain.RejectedExecutionListResponse{
+		RejectedExecutions: rejected,
+		Pagination:         domain.NewPaginationInfo(totalCount, limit, offset),
+	}, nil
+}
+
+// acquireSendLock takes the cluster-wide Send lock and returns a func that releases it.
+// It returns apperrors.ErrDuplicateBatch when another instance holds the lock. With
+// Send queueing enabled, a Send already running in this process is waited for first,
+// for up to the configured max wait, before apperrors.ErrSendQueueTimeout is returned.
+func (s *ExecutionService) acquireSendLock(ctx context.Context) (func(), error) {
+	dequeue, err := s.waitForSendQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := s.batchHistoryRepo.TryAcquireSendLock(ctx)
+	if err != nil {
+		dequeue()
+		return nil, fmt.Errorf("failed to acquire send lock: %w", err)
+	}
+	if lock == nil {
+		dequeue()
+		return nil, fmt.Errorf("%w: another send is in progress", apperrors.ErrDuplicateBatch)
+	}
+
+	return func() {
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+			s.logger.Warn("Failed to release send lock", zap.Error(err))
 		}
-		return nil, fmt.Errorf("failed to create batch history: %w", err)
+		dequeue()
+	}, nil
+}
+
+// waitForSendQueue takes this process's Send queue token, waiting for the Send in
+// progress to finish, and returns a func that hands the token back. It is a no-op
+// when Send queueing is disabled.
+func (s *ExecutionService) waitForSendQueue(ctx context.Context) (func(), error) {
+	if s.sendQueue == nil {
+		return func() {}, nil
+	}
+
+	timer := time.NewTimer(s.SendQueueMaxWait())
+	defer timer.Stop()
+
+	select {
+	case s.sendQueue <- struct{}{}:
+		return func() { <-s.sendQueue }, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w after %s", apperrors.ErrSendQueueTimeout, s.SendQueueMaxWait())
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SendQueueMaxWait is how long a queued Send waits for the one in progress
+func (s *ExecutionService) SendQueueMaxWait() time.Duration {
+	return s.config.SendQueueMaxWait
+}
+
+// processBatch sends the executions in the batch's [previous start, start) window to
+// Portfolio Accounting and records the outcome as the batch status
+func (s *ExecutionService) processBatch(ctx context.Context, batchHistory *domain.BatchHistory) (*domain.SendResponse, error) {
+	// Step 3 & 4: Stream executions for this batch into the Portfolio Accounting file
+	streamForBatch := s.executionRepo.StreamForBatch
+	if s.config.SplitOutputByPortfolio {
+		// Files per portfolio are written one at a time, so each portfolio's rows must arrive together
+		streamForBatch = s.executionRepo.StreamForBatchByPortfolio
+	}
+	var stream ExecutionStream = func(fn func(domain.Execution) error) error {
+		return streamForBatch(ctx, batchHistory.PreviousStartTime, batchHistory.StartTime, fn)
+	}
+	if len(batchHistory.LoadedPortfolios) > 0 {
+		// A retry of a split batch: these portfolios' files were loaded before a later one failed
+		s.logger.Info("Leaving out portfolios already loaded by this batch",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.Strings("portfolio_ids", batchHistory.LoadedPortfolios))
+		stream = withoutPortfolios(stream, batchHistory.LoadedPortfolios)
+	}
+
+	if !s.config.ReconciliationEnabled {
+		return s.sendBatchFile(ctx, batchHistory, stream)
+	}
+
+	reconciler, err := newTotalAmountReconciler(s.config.ReconciliationTolerance, s.config.ReconciliationPolicy)
+	if err != nil {
+		s.setBatchStatus(ctx, batchHistory, domain.BatchStatusFailed)
+		return nil, err
 	}
 
-	s.logger.Info("Batch history created",
+	response, err := s.sendBatchFile(ctx, batchHistory, reconciler.filter(stream))
+	if len(reconciler.mismatched) == 0 {
+		return response, err
+	}
+
+	s.logger.Warn("Executions failed total amount reconciliation",
 		zap.Int("batch_id", batchHistory.ID),
-		zap.Time("start_time", currentTime),
-		zap.Time("previous_start_time", previousStartTime))
+		zap.Ints("execution_ids", reconciler.mismatched),
+		zap.Float64("tolerance", reconciler.tolerance),
+		zap.Bool("aborted", reconciler.abort))
+
+	if errors.Is(err, apperrors.ErrReconciliationFailed) {
+		return &domain.SendResponse{
+			Status:                 "error",
+			Message:                fmt.Sprintf("Batch aborted: %d executions failed total amount reconciliation", len(reconciler.mismatched)),
+			MismatchedExecutionIDs: reconciler.mismatched,
+		}, err
+	}
+	if response != nil {
+		response.MismatchedExecutionIDs = reconciler.mismatched
+		response.Message += fmt.Sprintf("; excluded %d executions that failed total amount reconciliation", len(reconciler.mismatched))
+	}
+	s.storeReconciliationSkips(ctx, reconciler.excluded)
+	return response, err
+}
 
-	// Step 3: Get executions for this batch
-	executions, err := s.executionRepo.GetForBatch(ctx, previousStartTime, currentTime)
+// sendBatchFile writes the batch's executions to a file, or one per portfolio, and hands
+// each to the Portfolio Accounting CLI, recording the outcome on the batch
+func (s *ExecutionService) sendBatchFile(ctx context.Context, batchHistory *domain.BatchHistory, stream ExecutionStream) (*domain.SendResponse, error) {
+	files, processedCount, err := s.generateBatchFiles(ctx, batchHistory, stream)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get executions for batch: %w", err)
+		s.setBatchStatus(ctx, batchHistory, domain.BatchStatusFailed)
+		return nil, fmt.Errorf("failed to generate file: %w", err)
 	}
 
-	if len(executions) == 0 {
+	if processedCount == 0 {
 		s.logger.Info("No executions to process")
+		s.setBatchStatus(ctx, batchHistory, domain.BatchStatusCompleted)
 		return &domain.SendResponse{
 			ProcessedCount: 0,
 			FileName:       "",
@@ -294,40 +1474,271 @@ func (s *ExecutionService) Send(ctx context.Context) (*domain.SendResponse, erro
 		}, nil
 	}
 
-	s.logger.Info("Retrieved executions for processing", zap.Int("count", len(executions)))
+	filenames := make([]string, len(files))
+	for i, file := range files {
+		filenames[i] = file.Filename
+	}
+	s.logger.Info("Generated file for executions", zap.Int("count", processedCount), zap.Strings("filenames", filenames))
 
-	// Step 4: Generate Portfolio Accounting file
-	filename, err := s.fileGenerator.GeneratePortfolioAccountingFile(ctx, executions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate file: %w", err)
+	// Only a split Send lists its files; otherwise FileName names the one file
+	var fileNames []string
+	if s.config.SplitOutputByPortfolio {
+		fileNames = filenames
 	}
 
-	// Step 5: Invoke Portfolio Accounting CLI
-	if err := s.cliInvoker.InvokePortfolioAccountingCLI(ctx, filename, s.config.OutputDir); err != nil {
-		s.logger.Error("CLI invocation failed", zap.Error(err))
-		return &domain.SendResponse{
-			ProcessedCount: len(executions),
-			FileName:       filename,
-			Status:         "error",
-			Message:        fmt.Sprintf("CLI invocation failed: %v", err),
-		}, fmt.Errorf("CLI invocation failed: %w", err)
+	// Step 5: Invoke Portfolio Accounting CLI once per file, stopping at the first failure
+	for i, file := range files {
+		filename := file.Filename
+		if err := s.invokeCLI(ctx, batchHistory, filename); err != nil {
+			s.logger.Error("CLI invocation failed", zap.String("filename", filename), zap.Error(err))
+			s.setBatchStatus(ctx, batchHistory, domain.BatchStatusFailed)
+			response := &domain.SendResponse{
+				ProcessedCount: processedCount,
+				FileName:       filename,
+				FileNames:      fileNames,
+				Status:         "error",
+				Message:        fmt.Sprintf("CLI invocation failed: %v", err),
+			}
+			var cliErr *CLIError
+			if errors.As(err, &cliErr) {
+				exitCode := cliErr.ExitCode
+				response.ExitCode = &exitCode
+			}
+			var abortedErr *CLIAbortedError
+			if errors.As(err, &abortedErr) {
+				response.CLIStdout = abortedErr.Stdout
+				response.CLIStderr = abortedErr.Stderr
+			}
+			return response, fmt.Errorf("CLI invocation failed: %w", err)
+		}
+		if s.config.SplitOutputByPortfolio && i < len(files)-1 {
+			// Should a later file fail, a retry must not load this portfolio again
+			s.recordLoadedPortfolio(ctx, batchHistory, file.PortfolioID)
+		}
 	}
 
-	// Step 6: Cleanup file if enabled
+	s.setBatchStatus(ctx, batchHistory, domain.BatchStatusCompleted)
+
+	// Step 6: Cleanup files if enabled
 	if s.config.FileCleanupEnabled {
-		if err := s.fileGenerator.CleanupFile(filename, true); err != nil {
-			s.logger.Warn("File cleanup failed", zap.Error(err))
+		for _, filename := range filenames {
+			if err := s.fileGenerator.CleanupFile(filename, true); err != nil {
+				s.logger.Warn("File cleanup failed", zap.String("filename", filename), zap.Error(err))
+			}
 		}
 	}
 
 	s.logger.Info("Execution send process completed successfully",
-		zap.Int("processed_count", len(executions)),
-		zap.String("filename", filename))
+		zap.Int("batch_id", batchHistory.ID),
+		zap.Int("processed_count", processedCount),
+		zap.Strings("filenames", filenames))
 
 	return &domain.SendResponse{
-		ProcessedCount: len(executions),
-		FileName:       filename,
+		ProcessedCount: processedCount,
+		FileName:       filenames[0],
+		FileNames:      fileNames,
 		Status:         "success",
 		Message:        "Portfolio Accounting CLI executed successfully",
 	}, nil
 }
+
+// generateBatchFiles streams the batch's executions into Portfolio Accounting files in its own span,
+// linked to the spans that created those executions
+func (s *ExecutionService) generateBatchFiles(ctx context.Context, batchHistory *domain.BatchHistory, stream ExecutionStream) (files []GeneratedFile, processedCount int, err error) {
+	ctx, span := startSpan(ctx, "execution.send.generate_file", attribute.Int("batch.id", batchHistory.ID))
+	defer func() { endSpan(span, err) }()
+
+	// Link back to the requests that created the executions in the file
+	var links createSpanLinks
+	files, processedCount, err = s.fileGenerator.StreamPortfolioAccountingFiles(ctx, links.collect(stream))
+	links.addTo(span)
+	if s.metrics != nil {
+		if err != nil {
+			s.metrics.RecordPortfolioFileGenerated(ctx, "error")
+		}
+		for range files {
+			s.metrics.RecordPortfolioFileGenerated(ctx, "success")
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("execution.count", processedCount),
+		attribute.Int("file.count", len(files)),
+	)
+	if len(files) > 0 {
+		span.SetAttributes(attribute.String("file.name", files[0].Filename))
+	}
+	return files, processedCount, err
+}
+
+// invokeCLI runs the Portfolio Accounting CLI on the batch file in its own span, with
+// events marking when the CLI starts and finishes
+func (s *ExecutionService) invokeCLI(ctx context.Context, batchHistory *domain.BatchHistory, filename string) (err error) {
+	ctx, span := startSpan(ctx, "execution.send.invoke_cli",
+		attribute.Int("batch.id", batchHistory.ID),
+		attribute.String("file.name", filename),
+	)
+	defer func() { endSpan(span, err) }()
+
+	span.AddEvent("cli.started", trace.WithAttributes(attribute.StringSlice("cli.command", s.config.CLICommand)))
+	err = s.cliInvoker.InvokePortfolioAccountingCLI(ctx, filename, s.config.OutputDir)
+
+	// Failures that are not a CLI exit, such as a missing binary, have no exit code
+	var cliErr *CLIError
+	switch {
+	case err == nil:
+		span.AddEvent("cli.finished", trace.WithAttributes(attribute.Int("cli.exit_code", 0)))
+	case errors.As(err, &cliErr):
+		span.AddEvent("cli.finished", trace.WithAttributes(attribute.Int("cli.exit_code", cliErr.ExitCode)))
+	default:
+		span.AddEvent("cli.finished")
+	}
+	return err
+}
+
+// recordLoadedPortfolio records that the CLI loaded portfolioID's file of a split batch.
+// A failure is logged: the file is loaded either way, and a retry would load it again.
+func (s *ExecutionService) recordLoadedPortfolio(ctx context.Context, batchHistory *domain.BatchHistory, portfolioID string) {
+	if err := s.batchHistoryRepo.AddLoadedPortfolio(context.WithoutCancel(ctx), batchHistory.ID, portfolioID); err != nil {
+		s.logger.Error("Failed to record loaded portfolio; retrying this batch would load it again",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.String("portfolio_id", portfolioID),
+			zap.Error(err))
+		return
+	}
+	batchHistory.LoadedPortfolios = append(batchHistory.LoadedPortfolios, portfolioID)
+}
+
+// withoutPortfolios drops the executions of the given portfolios from stream; an
+// execution without a portfolio ID matches ""
+func withoutPortfolios(stream ExecutionStream, portfolioIDs []string) ExecutionStream {
+	excluded := make(map[string]bool, len(portfolioIDs))
+	for _, portfolioID := range portfolioIDs {
+		excluded[portfolioID] = true
+	}
+	return func(fn func(domain.Execution) error) error {
+		return stream(func(execution domain.Execution) error {
+			portfolioID := ""
+			if execution.PortfolioID != nil {
+				portfolioID = *execution.PortfolioID
+			}
+			if excluded[portfolioID] {
+				return nil
+			}
+			return fn(execution)
+		})
+	}
+}
+
+// setBatchStatus records a batch outcome, even when ctx was cancelled mid-Send; failures
+// are logged since the send itself already finished
+func (s *ExecutionService) setBatchStatus(ctx context.Context, batchHistory *domain.BatchHistory, status string) {
+	if err := s.batchHistoryRepo.UpdateStatus(context.WithoutCancel(ctx), batchHistory.ID, status); err != nil {
+		s.logger.Warn("Failed to update batch status",
+			zap.Int("batch_id", batchHistory.ID),
+			zap.String("status", status),
+			zap.Error(err))
+		return
+	}
+	batchHistory.Status = status
+	batchHistory.Version++
+
+	if status == domain.BatchStatusCompleted && s.metrics != nil {
+		s.metrics.RecordSuccessfulSend(ctx, batchHistory.StartTime)
+	}
+}
+
+// limitSendWindow shrinks the window [start, end) to the oldest MaxSendBatchSize
+// executions and reports whether any were left for a later Send. The returned end
+// is the timestamp of the first execution left out, so the next batch begins exactly
+// after the last one included. Executions sharing a timestamp are never split across
+// batches, so a batch can exceed the limit when the cut falls inside such a group.
+// first is the timestamp of the window's first execution.
+func (s *ExecutionService) limitSendWindow(ctx context.Context, start, end, first time.Time) (time.Time, bool, error) {
+	limit := s.config.MaxSendBatchSize
+	if limit <= 0 {
+		return end, false, nil
+	}
+
+	cutoff, err := s.executionRepo.ReadyTimestampAt(ctx, start, end, limit)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to limit send window: %w", err)
+	}
+	if cutoff == nil {
+		return end, false, nil
+	}
+
+	if cutoff.After(first) {
+		s.logger.Info("Send window truncated to max batch size",
+			zap.Int("max_send_batch_size", limit),
+			zap.Time("window_end", end),
+			zap.Time("batch_end", *cutoff))
+		return *cutoff, true, nil
+	}
+
+	// Every execution up to the cut shares one timestamp; take the whole group
+	next, err := s.executionRepo.NextReadyTimestampAfter(ctx, *cutoff, end)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to limit send window: %w", err)
+	}
+	s.logger.Warn("Executions sharing a timestamp exceed max batch size, sending them together",
+		zap.Int("max_send_batch_size", limit),
+		zap.Time("ready_to_send_timestamp", *cutoff))
+	if next == nil {
+		return end, false, nil
+	}
+	return *next, true, nil
+}
+
+// limitSendWindowDuration enforces MaxSendWindowDuration on the window [start, end)
+// whose oldest execution is stamped first. The span is measured from first, so an idle
+// period before it doesn't count. Over the limit, the split policy ends the window
+// MaxSendWindowDuration after first and reports the split, unless nothing is stamped
+// past that cut; the refuse policy fails with apperrors.ErrSendWindowTooLarge unless
+// force is set.
+func (s *ExecutionService) limitSendWindowDuration(ctx context.Context, start, end, first time.Time, force bool) (time.Time, bool, error) {
+	limit := s.config.MaxSendWindowDuration
+	span := end.Sub(first)
+	if limit <= 0 || span <= limit {
+		return end, false, nil
+	}
+
+	fields := []zap.Field{
+		zap.Time("window_start", start),
+		zap.Time("window_end", end),
+		zap.Time("first_ready_to_send_timestamp", first),
+		zap.Duration("window_span", span),
+		zap.Duration("max_send_window_duration", limit),
+	}
+	switch {
+	case force:
+		s.logger.Warn("Send window exceeds max duration, sending it whole as forced", fields...)
+		return end, false, nil
+	case s.config.SendWindowOverflowPolicy == SendWindowOverflowPolicyRefuse:
+		s.logger.Warn("Send window exceeds max duration, refusing unforced send", fields...)
+		return time.Time{}, false, fmt.Errorf("%w: executions span %s, more than %s; force the send to process it",
+			apperrors.ErrSendWindowTooLarge, span, limit)
+	default:
+		cutoff := first.Add(limit)
+		next, err := s.executionRepo.ReadyTimestampAt(ctx, cutoff, end, 0)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to split send window: %w", err)
+		}
+		if next == nil {
+			// Nothing is stamped past the cut, so the window is sent whole
+			return end, false, nil
+		}
+		s.logger.Warn("Send window exceeds max duration, splitting",
+			append(fields, zap.Time("batch_end", cutoff))...)
+		return cutoff, true, nil
+	}
+}
+
+// sendWindowEnd returns the exclusive end of the next Send window, now minus lag.
+// It reports false when that would not advance past the previous window end.
+func sendWindowEnd(previousEnd, now time.Time, lag time.Duration) (time.Time, bool) {
+	end := now.Add(-lag)
+	if !end.After(previousEnd) {
+		return time.Time{}, false
+	}
+	return end, true
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// executionEventBuffer is how many created executions a subscriber may fall behind by
+// before it is disconnected
+const executionEventBuffer = 64
+
+// ExecutionBroker fans created executions out to stream subscribers. Publish never
+// blocks: a subscriber whose buffer is full is dropped rather than stalling ingestion,
+// and is expected to reconnect.
+type ExecutionBroker struct {
+	mu             sync.Mutex
+	subscribers    map[chan domain.ExecutionDTO]struct{}
+	maxSubscribers int
+	closed         bool
+	logger         *zap.Logger
+}
+
+// NewExecutionBroker creates a broker that accepts up to maxSubscribers at once
+func NewExecutionBroker(maxSubscribers int, logger *zap.Logger) *ExecutionBroker {
+	return &ExecutionBroker{
+		subscribers:    make(map[chan domain.ExecutionDTO]struct{}),
+		maxSubscribers: maxSubscribers,
+		logger:         logger,
+	}
+}
+
+// Subscribe registers a subscriber and returns its event channel, which is closed when
+// the subscriber is dropped or the broker closes, and a function that unsubscribes it.
+// apperrors.ErrTooManySubscribers is returned when the subscriber cap is reached.
+func (b *ExecutionBroker) Subscribe() (<-chan domain.ExecutionDTO, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, fmt.Errorf("execution stream is shutting down")
+	}
+	if len(b.subscribers) >= b.maxSubscribers {
+		return nil, nil, fmt.Errorf("%w: limit is %d", apperrors.ErrTooManySubscribers, b.maxSubscribers)
+	}
+
+	events := make(chan domain.ExecutionDTO, executionEventBuffer)
+	b.subscribers[events] = struct{}{}
+	return events, func() { b.remove(events) }, nil
+}
+
+// Publish sends execution to every subscriber without waiting on any of them
+func (b *ExecutionBroker) Publish(execution domain.ExecutionDTO) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subscribers {
+		select {
+		case events <- execution:
+		default:
+			delete(b.subscribers, events)
+			close(events)
+			b.logger.Warn("Dropped slow execution stream subscriber",
+				zap.Int("buffer", executionEventBuffer))
+		}
+	}
+}
+
+// SubscriberCount returns the number of connected subscribers
+func (b *ExecutionBroker) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close disconnects every subscriber and refuses new ones, so open streams don't hold
+// up a graceful shutdown
+func (b *ExecutionBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for events := range b.subscribers {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+// remove unsubscribes events if it is still subscribed
+func (b *ExecutionBroker) remove(events chan domain.ExecutionDTO) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[events]; ok {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
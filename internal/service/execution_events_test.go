@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestExecutionBroker_PublishFansOut(t *testing.T) {
+	broker := NewExecutionBroker(2, zap.NewNop())
+
+	first, unsubscribeFirst, err := broker.Subscribe()
+	require.NoError(t, err)
+	defer unsubscribeFirst()
+	second, unsubscribeSecond, err := broker.Subscribe()
+	require.NoError(t, err)
+	defer unsubscribeSecond()
+
+	broker.Publish(domain.ExecutionDTO{ID: 7})
+
+	assert.Equal(t, 7, (<-first).ID)
+	assert.Equal(t, 7, (<-second).ID)
+}
+
+func TestExecutionBroker_SubscriberCap(t *testing.T) {
+	broker := NewExecutionBroker(1, zap.NewNop())
+
+	_, unsubscribe, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	_, _, err = broker.Subscribe()
+	assert.ErrorIs(t, err, apperrors.ErrTooManySubscribers)
+
+	// Unsubscribing frees the slot, and unsubscribing twice is harmless
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 0, broker.SubscriberCount())
+	_, _, err = broker.Subscribe()
+	assert.NoError(t, err)
+}
+
+func TestExecutionBroker_SlowSubscriberIsDropped(t *testing.T) {
+	broker := NewExecutionBroker(2, zap.NewNop())
+
+	slow, _, err := broker.Subscribe()
+	require.NoError(t, err)
+	fast, unsubscribeFast, err := broker.Subscribe()
+	require.NoError(t, err)
+	defer unsubscribeFast()
+
+	// Publishing past the slow subscriber's buffer must not block
+	for i := 1; i <= executionEventBuffer+1; i++ {
+		broker.Publish(domain.ExecutionDTO{ID: i})
+		if i <= executionEventBuffer {
+			<-fast
+		}
+	}
+
+	assert.Equal(t, 1, broker.SubscriberCount())
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, executionEventBuffer, received, "the slow subscriber keeps what was buffered, then its channel closes")
+	assert.Equal(t, executionEventBuffer+1, (<-fast).ID)
+}
+
+func TestExecutionBroker_Close(t *testing.T) {
+	broker := NewExecutionBroker(1, zap.NewNop())
+
+	events, unsubscribe, err := broker.Subscribe()
+	require.NoError(t, err)
+
+	broker.Close()
+	_, ok := <-events
+	assert.False(t, ok)
+	unsubscribe()
+
+	_, _, err = broker.Subscribe()
+	assert.Error(t, err)
+}
+
+func TestExecutionService_CreateBatch_PublishesCreatedExecutions(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	svc, mock := newTestExecutionService(t, &config.Config{BatchConcurrency: 1})
+	svc.SetExecutionBroker(NewExecutionBroker(1, zap.NewNop()))
+	events, unsubscribe, err := svc.SubscribeExecutions()
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	open := validExecutionDTO(2)
+	open.IsOpen = true
+	expectExecutionLookup(mock, 1)
+	expectExecutionInsert(mock, 1, 11)
+
+	_, err = svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{validExecutionDTO(1), open})
+	require.NoError(t, err)
+
+	// Only the created execution is published, not the skipped one
+	require.Len(t, events, 1)
+	execution := <-events
+	assert.Equal(t, 11, execution.ID)
+	assert.Equal(t, 1, execution.ExecutionServiceID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_SubscribeExecutions_NotConfigured(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	_, _, err := svc.SubscribeExecutions()
+	assert.EqualError(t, err, "execution stream is not configured")
+}
@@ -0,0 +1,2101 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jarcoal/httpmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+	"github.com/kasbench/globeco-allocation-service/internal/repository"
+)
+
+const testTradeServiceURL = "http://globeco-trade-service:8082"
+
+// newTestExecutionService builds an ExecutionService backed by sqlmock and httpmock.
+// Callers must activate httpmock before calling it.
+func newTestExecutionService(t *testing.T, cfg *config.Config) (*ExecutionService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	svc, mock, _ := newTestExecutionServiceWithDB(t, cfg)
+	return svc, mock
+}
+
+// newTestExecutionServiceWithAudit is newTestExecutionService with audit logging enabled
+func newTestExecutionServiceWithAudit(t *testing.T, cfg *config.Config) (*ExecutionService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	svc, mock, dbWrapper := newTestExecutionServiceWithDB(t, cfg)
+	svc.SetAuditRepository(repository.NewAuditLogRepository(dbWrapper, zap.NewNop()))
+	return svc, mock
+}
+
+func newTestExecutionServiceWithDB(t *testing.T, cfg *config.Config) (*ExecutionService, sqlmock.Sqlmock, *repository.DB) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = t.TempDir()
+	}
+	if cfg.TradeDateTimezone == "" {
+		cfg.TradeDateTimezone = "America/New_York"
+	}
+
+	logger := zap.NewNop()
+	dbWrapper := &repository.DB{DB: sqlx.NewDb(db, "postgres")}
+	tradeClient := NewTradeServiceClient(testTradeServiceURL, logger)
+	tradeClient.SetRetryConfig(0, 0)
+
+	svc, err := NewExecutionService(
+		repository.NewExecutionRepository(dbWrapper, logger),
+		repository.NewBatchHistoryRepository(dbWrapper, logger),
+		tradeClient,
+		logger,
+		cfg,
+	)
+	require.NoError(t, err)
+	return svc, mock, dbWrapper
+}
+
+// registerPortfolioResponder makes the mocked Trade Service resolve every execution to portfolioID
+func registerPortfolioResponder(portfolioID string) {
+	httpmock.RegisterResponder("GET", testTradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			var serviceID int
+			fmt.Sscanf(req.URL.Query().Get("executionServiceId"), "%d", &serviceID) //nolint:errcheck
+			body, _ := json.Marshal(domain.TradeServiceExecutionResponse{
+				Executions: []domain.TradeServiceExecution{
+					{
+						ID:                 serviceID,
+						ExecutionServiceID: serviceID,
+						TradeOrder: domain.TradeServiceTradeOrder{
+							Portfolio: domain.TradeServicePortfolio{PortfolioID: portfolioID},
+						},
+					},
+				},
+			})
+			return httpmock.NewStringResponse(200, string(body)), nil
+		})
+}
+
+func validExecutionDTO(executionServiceID int) domain.ExecutionPostDTO {
+	now := time.Date(2024, 1, 15, 15, 30, 0, 0, time.UTC)
+	return domain.ExecutionPostDTO{
+		ExecutionServiceID: executionServiceID,
+		IsOpen:             false,
+		ExecutionStatus:    "FILLED",
+		TradeType:          "BUY",
+		Destination:        "NYSE",
+		SecurityID:         "12345678901234567890ABCD",
+		Ticker:             "AAPL",
+		Quantity:           domain.DecimalFromFloat(100),
+		ReceivedTimestamp:  now,
+		SentTimestamp:      now,
+		QuantityFilled:     domain.DecimalFromFloat(100),
+		TotalAmount:        domain.DecimalFromFloat(15000),
+		AveragePrice:       domain.DecimalFromFloat(150),
+	}
+}
+
+func expectExecutionLookup(mock sqlmock.Sqlmock, executionServiceID int) {
+	mock.ExpectQuery(`SELECT id, .* FROM execution WHERE execution_service_id = \$1 UNION ALL SELECT id, .* FROM execution_archive WHERE execution_service_id = \$1 LIMIT 1$`).
+		WithArgs(executionServiceID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func expectExecutionInsert(mock sqlmock.Sqlmock, executionServiceID, id int) {
+	args := []driver.Value{executionServiceID}
+	for i := 0; i < 20; i++ {
+		args = append(args, sqlmock.AnyArg())
+	}
+	mock.ExpectQuery(`INSERT INTO execution`).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+func TestExecutionService_CreateBatch_Concurrent(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	svc, mock := newTestExecutionService(t, &config.Config{BatchConcurrency: 4})
+	mock.MatchExpectationsInOrder(false)
+
+	var dtos []domain.ExecutionPostDTO
+	for i := 1; i <= 8; i++ {
+		dtos = append(dtos, validExecutionDTO(i))
+		expectExecutionLookup(mock, i)
+		expectExecutionInsert(mock, i, 100+i)
+	}
+
+	response, err := svc.CreateBatch(context.Background(), dtos)
+
+	require.NoError(t, err)
+	assert.Equal(t, 8, response.ProcessedCount)
+	require.Len(t, response.Results, 8)
+	for i, result := range response.Results {
+		// Result ordering must match input ordering regardless of completion order
+		assert.Equal(t, i+1, result.ExecutionServiceID)
+		assert.Equal(t, "created", result.Status)
+		require.NotNil(t, result.ExecutionID)
+		assert.Equal(t, 101+i, *result.ExecutionID)
+		require.NotNil(t, result.PortfolioID)
+		assert.Equal(t, "PORTFOLIO123456789012345", *result.PortfolioID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_DuplicateInBatch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	svc, mock := newTestExecutionService(t, &config.Config{BatchConcurrency: 2})
+
+	// Only one lookup and one insert are expected for the repeated ID
+	expectExecutionLookup(mock, 42)
+	expectExecutionInsert(mock, 42, 7)
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{
+		validExecutionDTO(42),
+		validExecutionDTO(42),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, "created", response.Results[0].Status)
+	assert.Equal(t, "skipped", response.Results[1].Status)
+	assert.Equal(t, domain.SkipReasonDuplicateInBatch, response.Results[1].Reason)
+	assert.Nil(t, response.Results[1].ExecutionID)
+	assert.Equal(t, 1, response.ProcessedCount)
+	assert.Equal(t, 1, response.SkippedCount)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectExecutionInsertWithPortfolio is expectExecutionInsert, also checking the stored portfolio ID
+func expectExecutionInsertWithPortfolio(mock sqlmock.Sqlmock, executionServiceID, id int, portfolioID string) {
+	args := []driver.Value{executionServiceID}
+	for i := 0; i < 7; i++ {
+		args = append(args, sqlmock.AnyArg())
+	}
+	args = append(args, portfolioID)
+	for i := 0; i < 12; i++ {
+		args = append(args, sqlmock.AnyArg())
+	}
+	mock.ExpectQuery(`INSERT INTO execution`).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+func TestExecutionService_CreateBatch_SuppliedPortfolioID(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("LOOKEDUP1234567890123456")
+
+	svc, mock := newTestExecutionService(t, &config.Config{BatchConcurrency: 1})
+
+	supplied := validExecutionDTO(1)
+	portfolioID := "SUPPLIED1234567890123456"
+	supplied.PortfolioID = &portfolioID
+	blank := validExecutionDTO(2)
+	empty := ""
+	blank.PortfolioID = &empty
+
+	expectExecutionLookup(mock, 1)
+	expectExecutionInsertWithPortfolio(mock, 1, 11, portfolioID)
+	expectExecutionLookup(mock, 2)
+	expectExecutionInsertWithPortfolio(mock, 2, 12, "LOOKEDUP1234567890123456")
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{supplied, blank})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.ProcessedCount)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount(), "only the execution without a portfolio ID is looked up")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_MalformedPortfolioID(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{BatchConcurrency: 1})
+
+	dto := validExecutionDTO(1)
+	portfolioID := "SHORT"
+	dto.PortfolioID = &portfolioID
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{dto})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "error", response.Results[0].Status)
+	assert.Contains(t, response.Results[0].Error, "PortfolioID")
+	assert.Zero(t, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_UnknownExecutionStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{})
+
+	dto := validExecutionDTO(1)
+	dto.ExecutionStatus = "DONE"
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{dto})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "error", response.Results[0].Status)
+	assert.Contains(t, response.Results[0].Error, "ExecutionStatus")
+	assert.Zero(t, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_PortfolioLookupFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy     string
+		wantStatus string
+		wantReason string
+		wantSkips  float64
+		wantErrors float64
+	}{
+		{PortfolioLookupFailurePolicyError, "error", "", 0, 1},
+		{PortfolioLookupFailurePolicySkip, "skipped", domain.SkipReasonPortfolioLookupFailed, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", testTradeServiceURL+"/api/v2/executions",
+				httpmock.NewStringResponder(500, "unavailable"))
+
+			svc, mock := newTestExecutionService(t, &config.Config{PortfolioLookupFailurePolicy: tt.policy})
+			metrics := &observability.BusinessMetrics{
+				ExecutionsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_executions_skipped_total"}, []string{"reason"}),
+				ExecutionsErrored: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_executions_errored_total"}, []string{"error_type"}),
+			}
+			svc.SetMetrics(observability.NewPrometheusRecorder(metrics))
+			expectExecutionLookup(mock, 1)
+
+			response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{validExecutionDTO(1)})
+
+			require.NoError(t, err)
+			require.Len(t, response.Results, 1)
+			result := response.Results[0]
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantReason, result.Reason)
+			assert.Contains(t, result.Error, "failed to get portfolio ID")
+			assert.Nil(t, result.PortfolioID)
+			assert.Equal(t, tt.wantSkips, testutil.ToFloat64(metrics.ExecutionsSkipped.WithLabelValues(domain.SkipReasonPortfolioLookupFailed)))
+			assert.Equal(t, tt.wantErrors, testutil.ToFloat64(metrics.ExecutionsErrored.WithLabelValues(domain.SkipReasonPortfolioLookupFailed)))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestNewExecutionService_InvalidPortfolioLookupFailurePolicy(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{
+		TradeDateTimezone:            "America/New_York",
+		PortfolioLookupFailurePolicy: "retry",
+	})
+
+	assert.ErrorContains(t, err, "unsupported portfolio lookup failure policy")
+}
+
+func TestExecutionService_CreateBatch_ZeroQuantityFilledPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		status     string
+		wantStatus string
+		wantReason string
+	}{
+		{"default rejects", "", "FILLED", "error", ""},
+		{"reject", ZeroQuantityFilledPolicyReject, "PARTIAL", "error", ""},
+		{"skip", ZeroQuantityFilledPolicySkip, "FILLED", "skipped", domain.SkipReasonZeroQuantityFilled},
+		{"pass", ZeroQuantityFilledPolicyPass, "FILLED", "created", ""},
+		{"not a fill", ZeroQuantityFilledPolicyReject, "NEW", "created", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			registerPortfolioResponder("PORTFOLIO123456789012345")
+
+			svc, mock := newTestExecutionService(t, &config.Config{ZeroQuantityFilledPolicy: tt.policy})
+			expectExecutionLookup(mock, 1)
+			if tt.wantStatus == "created" {
+				expectExecutionInsert(mock, 1, 7)
+			}
+			executionDTO := validExecutionDTO(1)
+			executionDTO.ExecutionStatus = tt.status
+			executionDTO.QuantityFilled = domain.DecimalFromFloat(0)
+
+			response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{executionDTO})
+
+			require.NoError(t, err)
+			require.Len(t, response.Results, 1)
+			result := response.Results[0]
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantReason, result.Reason)
+			if tt.wantStatus != "created" {
+				assert.Contains(t, result.Error, "quantityFilled is 0")
+				assert.Nil(t, result.ExecutionID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestNewExecutionService_InvalidZeroQuantityFilledPolicy(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{
+		TradeDateTimezone:        "America/New_York",
+		ZeroQuantityFilledPolicy: "warn",
+	})
+
+	assert.ErrorContains(t, err, "unsupported zero quantity filled policy")
+}
+
+func TestNewExecutionService_InvalidTimezone(t *testing.T) {
+	svc, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{TradeDateTimezone: "Mars/Olympus_Mons"})
+
+	assert.Error(t, err)
+	assert.Nil(t, svc)
+	assert.Contains(t, err.Error(), "Mars/Olympus_Mons")
+}
+
+func TestNewExecutionService_LoadsTradeDateLocation(t *testing.T) {
+	svc, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{TradeDateTimezone: "America/New_York"})
+
+	require.NoError(t, err)
+	require.NotNil(t, svc.tradeDateLoc)
+	assert.Equal(t, "America/New_York", svc.tradeDateLoc.String())
+}
+
+func TestExecutionService_DtoToExecution_ConfiguredTimezone(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{TradeDateTimezone: "America/Los_Angeles"})
+
+	dto := validExecutionDTO(1)
+	// 03:00 UTC on Jan 16 is still the evening of Jan 15 in Los Angeles
+	dto.SentTimestamp = time.Date(2024, 1, 16, 3, 0, 0, 0, time.UTC)
+
+	execution, err := svc.dtoToExecution(dto, "PORTFOLIO123456789012345")
+	require.NoError(t, err)
+
+	assert.Equal(t, "America/Los_Angeles", execution.TradeDate.Location().String())
+	assert.Equal(t, 15, execution.TradeDate.Day())
+}
+
+func TestExecutionService_DtoToExecution_TotalAmountPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		status        string
+		total         float64
+		expectedTotal float64
+		wantErr       bool
+	}{
+		{name: "trust keeps supplied total", policy: TotalAmountPolicyTrust, status: "FILLED", total: 14000, expectedTotal: 14000},
+		{name: "empty policy trusts", policy: "", status: "FILLED", total: 14000, expectedTotal: 14000},
+		{name: "compute overrides total", policy: TotalAmountPolicyCompute, status: "FILLED", total: 14000, expectedTotal: 15000},
+		{name: "compute leaves partial fill", policy: TotalAmountPolicyCompute, status: "PART", total: 7500, expectedTotal: 7500},
+		{name: "reconcile within tolerance", policy: TotalAmountPolicyReconcile, status: "FILLED", total: 15000.005, expectedTotal: 15000.005},
+		{name: "reconcile mismatch", policy: TotalAmountPolicyReconcile, status: "FILLED", total: 14000, wantErr: true},
+		{name: "reconcile leaves partial fill", policy: TotalAmountPolicyReconcile, status: "PART", total: 7500, expectedTotal: 7500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestExecutionService(t, &config.Config{TotalAmountPolicy: tt.policy, ReconciliationTolerance: 0.01})
+
+			dto := validExecutionDTO(1)
+			dto.ExecutionStatus = tt.status
+			dto.TotalAmount = domain.DecimalFromFloat(tt.total)
+
+			execution, err := svc.dtoToExecution(dto, "PORTFOLIO123456789012345")
+
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "does not match quantity * average price")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.DecimalFromFloat(tt.expectedTotal), execution.TotalAmount)
+		})
+	}
+}
+
+func TestExecutionService_CreateBatch_TotalAmountMismatch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{TotalAmountPolicy: TotalAmountPolicyReconcile, ReconciliationTolerance: 0.01})
+
+	dto := validExecutionDTO(42)
+	portfolioID := "PORTFOLIO123456789012345"
+	dto.PortfolioID = &portfolioID
+	dto.TotalAmount = domain.DecimalFromFloat(14000)
+	expectExecutionLookup(mock, 42)
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{dto})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "error", response.Results[0].Status)
+	assert.Contains(t, response.Results[0].Error, "total amount 14000")
+	assert.Equal(t, 1, response.ErrorCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewExecutionService_RejectsUnknownTotalAmountPolicy(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{TotalAmountPolicy: "guess"})
+
+	assert.ErrorContains(t, err, `unsupported total amount policy "guess"`)
+}
+
+func TestExecutionService_CreateBatch_SoftDeletedCountsAsExisting(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{})
+
+	mock.ExpectQuery(`SELECT id, .* FROM execution WHERE execution_service_id = \$1 UNION ALL SELECT id, .* FROM execution_archive WHERE execution_service_id = \$1 LIMIT 1$`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "execution_service_id", "deleted_at"}).AddRow(7, 42, time.Now()))
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{validExecutionDTO(42)})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "skipped", response.Results[0].Status)
+	assert.Equal(t, domain.SkipReasonDeleted, response.Results[0].Reason)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_AlreadyExistsReturnsPortfolioID(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{})
+
+	mock.ExpectQuery(`SELECT id, .* FROM execution WHERE execution_service_id = \$1 UNION ALL SELECT id, .* FROM execution_archive WHERE execution_service_id = \$1 LIMIT 1$`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "execution_service_id", "portfolio_id"}).AddRow(7, 42, "PORTFOLIO123456789012345"))
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{validExecutionDTO(42)})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	result := response.Results[0]
+	assert.Equal(t, domain.SkipReasonAlreadyExists, result.Reason)
+	require.NotNil(t, result.PortfolioID)
+	assert.Equal(t, "PORTFOLIO123456789012345", *result.PortfolioID)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_CreateBatch_AlreadyExistsBeforePolicies(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, mock := newTestExecutionService(t, &config.Config{TotalAmountPolicy: TotalAmountPolicyReconcile, ReconciliationTolerance: 0.01})
+
+	mock.ExpectQuery(`SELECT id, .* FROM execution WHERE execution_service_id = \$1 UNION ALL SELECT id, .* FROM execution_archive WHERE execution_service_id = \$1 LIMIT 1$`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "execution_service_id"}).AddRow(7, 42))
+
+	// A re-post of a stored execution is reported as such, even if it fails a policy now
+	dto := validExecutionDTO(42)
+	dto.TotalAmount = domain.DecimalFromFloat(14000)
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{dto})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "skipped", response.Results[0].Status)
+	assert.Equal(t, domain.SkipReasonAlreadyExists, response.Results[0].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSendWindowEnd(t *testing.T) {
+	previous := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	end, ok := sendWindowEnd(previous, previous.Add(time.Minute), time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, previous.Add(59*time.Second), end)
+
+	_, ok = sendWindowEnd(previous, previous.Add(time.Second), time.Second)
+	assert.False(t, ok, "window must advance past the previous end")
+
+	_, ok = sendWindowEnd(previous, previous.Add(500*time.Millisecond), time.Second)
+	assert.False(t, ok)
+}
+
+func expectSendLock(mock sqlmock.Sqlmock, acquired bool) {
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(acquired))
+}
+
+func expectSendUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectSendWindow mocks one Send whose batch window is [start, end) and returns rows for it
+func expectSendWindow(mock sqlmock.Sqlmock, batchID int, start, end time.Time, rows *sqlmock.Rows) {
+	maxStart := sqlmock.NewRows([]string{"max"})
+	if start.IsZero() {
+		maxStart.AddRow(nil)
+	} else {
+		maxStart.AddRow(start)
+	}
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).WillReturnRows(maxStart)
+	expectReadyTimestampAt(mock, start, end, 0, &end)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(end, start, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(batchID))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, end).
+		WillReturnRows(rows)
+	expectBatchStatusUpdate(mock, batchID, domain.BatchStatusCompleted)
+	expectSendUnlock(mock)
+}
+
+func expectBatchStatusUpdate(mock sqlmock.Sqlmock, batchID int, status string) {
+	mock.ExpectExec(`UPDATE batch_history SET status = \$1, version = version \+ 1 WHERE id = \$2`).
+		WithArgs(status, batchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectBatchLookup(mock sqlmock.Sqlmock, batchID int, start, end time.Time, status string) {
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE id = \$1`).
+		WithArgs(batchID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version"}).
+			AddRow(batchID, end, start, status, 2))
+}
+
+func TestExecutionService_Send_BackToBackWindowsAreContiguous(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+
+	firstNow := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	secondNow := firstNow.Add(time.Minute)
+	firstEnd := firstNow.Add(-time.Second)
+	secondEnd := secondNow.Add(-time.Second)
+
+	portfolioID := "PORTFOLIO123456789012"
+	executionRows := func(ids ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"})
+		for _, id := range ids {
+			rows.AddRow(id, portfolioID, "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, firstNow)
+		}
+		return rows
+	}
+
+	// Execution 2 is stamped exactly at the first window's end, execution 3 inside the
+	// first Send's lag; both must be picked up by the second Send rather than dropped.
+	expectSendWindow(mock, 1, time.Time{}, firstEnd, executionRows(1))
+	expectSendWindow(mock, 2, firstEnd, secondEnd, executionRows(2, 3))
+
+	svc.now = func() time.Time { return firstNow }
+	first, err := svc.Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.ProcessedCount)
+	assert.Equal(t, 1, first.BatchID)
+
+	svc.now = func() time.Time { return secondNow }
+	second, err := svc.Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.ProcessedCount)
+	assert.Equal(t, 2, second.BatchID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_SplitOutputByPortfolio(t *testing.T) {
+	outputDir := t.TempDir()
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:             []string{`sh -c "echo {filename} >> {output_dir}/invoked"`},
+		OutputDir:              outputDir,
+		SplitOutputByPortfolio: true,
+	})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIOA", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now).
+		AddRow(3, "PORTFOLIOA", "SECURITY123456789012ABCD", "SELL", 2.0, 1.5, now).
+		AddRow(2, "PORTFOLIOB", "SECURITY123456789012ABCD", "SELL", 5.0, 2.0, now)
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	expectReadyTimestampAt(mock, time.Time{}, now, 0, &now)
+	mock.ExpectQuery(`INSERT INTO batch_history`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE .* ORDER BY portfolio_id`).WillReturnRows(rows)
+	expectLoadedPortfolio(mock, 1, "PORTFOLIOA")
+	expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+	expectSendUnlock(mock)
+
+	svc.now = func() time.Time { return now }
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, response.ProcessedCount)
+	require.Len(t, response.FileNames, 2)
+	assert.Contains(t, response.FileNames[0], "transactions_PORTFOLIOA_")
+	assert.Contains(t, response.FileNames[1], "transactions_PORTFOLIOB_")
+	assert.Equal(t, response.FileNames[0], response.FileName)
+
+	invoked, err := os.ReadFile(filepath.Join(outputDir, "invoked"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join(response.FileNames, "\n")+"\n", string(invoked), "the CLI runs once per file")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectLoadedPortfolio(mock sqlmock.Sqlmock, batchID int, portfolioID string) {
+	mock.ExpectExec(`UPDATE batch_history SET loaded_portfolios = array_append\(loaded_portfolios, \$1\) WHERE id = \$2`).
+		WithArgs(portfolioID, batchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestExecutionService_SplitBatch_RetrySkipsLoadedPortfolios(t *testing.T) {
+	outputDir := t.TempDir()
+	// The CLI loads PORTFOLIOA's file and fails on any other
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:             []string{`sh -c "echo {filename} >> {output_dir}/invoked; case {filename} in *PORTFOLIOA*) ;; *) exit 1 ;; esac"`},
+		OutputDir:              outputDir,
+		SplitOutputByPortfolio: true,
+	})
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	columns := []string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(1, "PORTFOLIOA", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, start).
+			AddRow(2, "PORTFOLIOB", "SECURITY123456789012ABCD", "SELL", 5.0, 2.0, start)
+	}
+
+	// The first attempt loads PORTFOLIOA, records it, then fails on PORTFOLIOB
+	expectBatchLookup(mock, 7, start, end, domain.BatchStatusFailed)
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, true)
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE .* ORDER BY portfolio_id`).WillReturnRows(rows())
+	expectLoadedPortfolio(mock, 7, "PORTFOLIOA")
+	expectBatchStatusUpdate(mock, 7, domain.BatchStatusFailed)
+	expectSendUnlock(mock)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+	require.Error(t, err)
+	assert.Equal(t, "error", response.Status)
+
+	// The next retry reads PORTFOLIOA as loaded and hands the CLI only PORTFOLIOB's file
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version", "loaded_portfolios"}).
+			AddRow(7, end, start, domain.BatchStatusFailed, 4, "{PORTFOLIOA}"))
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, true)
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE .* ORDER BY portfolio_id`).WillReturnRows(rows())
+	expectBatchStatusUpdate(mock, 7, domain.BatchStatusFailed)
+	expectSendUnlock(mock)
+
+	response, err = svc.RetryBatch(context.Background(), 7)
+	require.Error(t, err)
+	assert.Equal(t, 1, response.ProcessedCount)
+
+	invoked, err := os.ReadFile(filepath.Join(outputDir, "invoked"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(invoked)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "PORTFOLIOA")
+	assert.Contains(t, lines[1], "PORTFOLIOB")
+	assert.Contains(t, lines[2], "PORTFOLIOB", "the retry does not load PORTFOLIOA again")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_WithinLagOfPreviousBatch(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+
+	previous := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(previous))
+	expectSendUnlock(mock)
+
+	svc.now = func() time.Time { return previous.Add(500 * time.Millisecond) }
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, response.ProcessedCount)
+	assert.Equal(t, "success", response.Status)
+	assert.Zero(t, response.BatchID, "no batch to report")
+	assert.NoError(t, mock.ExpectationsWereMet(), "no batch history row should be created")
+}
+
+func TestExecutionService_Send_EmptyWindowDoesNotOrphanLateExecutions(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+
+	previous := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	firstNow := previous.Add(time.Minute)
+	secondNow := firstNow.Add(time.Minute)
+	firstEnd := firstNow.Add(-time.Second)
+	secondEnd := secondNow.Add(-time.Second)
+
+	// The first Send finds its window empty and records no batch
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(previous))
+	expectReadyTimestampAt(mock, previous, firstEnd, 0, nil)
+	expectSendUnlock(mock)
+
+	svc.now = func() time.Time { return firstNow }
+	first, err := svc.Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, first.ProcessedCount)
+	assert.Zero(t, first.BatchID, "no batch is recorded for an empty window")
+
+	// An execution stamped inside the first window commits only afterwards. The next
+	// window still starts at the previous batch, so it is sent rather than skipped.
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, previous)
+	expectSendWindow(mock, 1, previous, secondEnd, rows)
+
+	svc.now = func() time.Time { return secondNow }
+	second, err := svc.Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.ProcessedCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_SendWithBatchKey_Replay(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	end := now.Add(-time.Second)
+	svc.now = func() time.Time { return now }
+
+	// The first Send runs the batch under the key and stores its response
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	expectReadyTimestampAt(mock, time.Time{}, end, 0, &end)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(end, time.Time{}, domain.BatchStatusInProgress, 1, "nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now))
+	expectBatchStatusUpdate(mock, 5, domain.BatchStatusCompleted)
+	mock.ExpectExec(`UPDATE batch_history SET send_response = \$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSendUnlock(mock)
+
+	first, err := svc.SendWithBatchKey(context.Background(), "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.ProcessedCount)
+	assert.Equal(t, 5, first.BatchID)
+	stored, err := json.Marshal(first)
+	require.NoError(t, err)
+
+	// The retried Send finds the completed batch and replays its response
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version", "batch_key", "send_response"}).
+			AddRow(5, end, time.Time{}, domain.BatchStatusCompleted, 2, "nightly", stored))
+	expectSendUnlock(mock)
+
+	second, err := svc.SendWithBatchKey(context.Background(), "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.NoError(t, mock.ExpectationsWereMet(), "the replay must not start a new batch")
+}
+
+func TestExecutionService_SendWithBatchKey_BatchNotCompleted(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	end := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time", "previous_start_time", "status", "version", "batch_key", "send_response"}).
+			AddRow(5, end, end.Add(-time.Hour), domain.BatchStatusFailed, 2, "nightly", nil))
+	expectSendUnlock(mock)
+
+	response, err := svc.SendWithBatchKey(context.Background(), "nightly")
+
+	assert.ErrorIs(t, err, apperrors.ErrBatchKeyInUse)
+	assert.Nil(t, response)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_RetryBatch(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	expectBatchLookup(mock, 7, start, end, domain.BatchStatusFailed)
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, true)
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, start))
+	expectBatchStatusUpdate(mock, 7, domain.BatchStatusCompleted)
+	expectSendUnlock(mock)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Status)
+	assert.Equal(t, 1, response.ProcessedCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_RetryBatch_CLIFailureKeepsFailed(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{`sh -c "exit 3"`}})
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	expectBatchLookup(mock, 7, start, end, domain.BatchStatusFailed)
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, true)
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, start))
+	expectBatchStatusUpdate(mock, 7, domain.BatchStatusFailed)
+	expectSendUnlock(mock)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+
+	assert.Error(t, err)
+	require.NotNil(t, response)
+	assert.Equal(t, "error", response.Status)
+	require.NotNil(t, response.ExitCode)
+	assert.Equal(t, CLIExitCodeDownstreamUnavailable, *response.ExitCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_RetryBatch_CLITimeoutReturnsPartialOutput(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{`sh -c "echo loading; echo bad row 3 >&2; sleep 30"`}})
+	svc.cliInvoker.SetTimeout(200 * time.Millisecond)
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	expectBatchLookup(mock, 7, start, end, domain.BatchStatusFailed)
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, true)
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, start))
+	expectBatchStatusUpdate(mock, 7, domain.BatchStatusFailed)
+	expectSendUnlock(mock)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, response)
+	assert.Equal(t, "error", response.Status)
+	assert.Nil(t, response.ExitCode)
+	assert.Equal(t, "loading\n", response.CLIStdout)
+	assert.Equal(t, "bad row 3\n", response.CLIStderr)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectRetryClaim(mock sqlmock.Sqlmock, batchID int, claimed bool) {
+	rows := int64(0)
+	if claimed {
+		rows = 1
+	}
+	mock.ExpectExec(`UPDATE batch_history SET status = \$1, version = version \+ 1 WHERE id = \$2 AND status = \$3`).
+		WithArgs(domain.BatchStatusInProgress, batchID, domain.BatchStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, rows))
+}
+
+func TestExecutionService_RetryBatch_ConcurrentRetryLosesClaim(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"false"}})
+
+	// Both retries read the batch as failed; the other one claimed it first, so this one
+	// must stop without reading executions or running the CLI
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	expectBatchLookup(mock, 7, start, start.Add(time.Hour), domain.BatchStatusFailed)
+	expectSendLock(mock, true)
+	expectRetryClaim(mock, 7, false)
+	expectSendUnlock(mock)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, apperrors.ErrBatchNotFailed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_RetryBatch_NotFailed(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	expectBatchLookup(mock, 7, start, start.Add(time.Hour), domain.BatchStatusCompleted)
+
+	response, err := svc.RetryBatch(context.Background(), 7)
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, apperrors.ErrBatchNotFailed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_GetPortfolioID_ConflictingPortfolios(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	httpmock.RegisterResponder("GET", testTradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := json.Marshal(domain.TradeServiceExecutionResponse{
+				Executions: []domain.TradeServiceExecution{
+					{ID: 1, TradeOrder: domain.TradeServiceTradeOrder{Portfolio: domain.TradeServicePortfolio{PortfolioID: "PORTFOLIO_A"}}},
+					{ID: 2, TradeOrder: domain.TradeServiceTradeOrder{Portfolio: domain.TradeServicePortfolio{PortfolioID: "PORTFOLIO_B"}}},
+				},
+			})
+			return httpmock.NewStringResponse(200, string(body)), nil
+		})
+
+	_, err := svc.getPortfolioIDFromTradeService(context.Background(), 42)
+
+	assert.ErrorContains(t, err, "maps to multiple portfolios")
+}
+
+func TestExecutionService_Send_DuplicateBatch(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	previous := now.Add(-time.Hour)
+	svc.now = func() time.Time { return now }
+
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(previous))
+	expectReadyTimestampAt(mock, previous, now.Add(-time.Second), 0, &previous)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WillReturnError(&pq.Error{Code: "23505"})
+	expectSendUnlock(mock)
+
+	response, err := svc.Send(context.Background())
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_LockHeldElsewhere(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	// Another instance is mid-Send and holds the advisory lock
+	expectSendLock(mock, false)
+
+	response, err := svc.Send(context.Background())
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet(), "no batch work should happen without the lock")
+}
+
+func TestExecutionService_Send_QueueTimeout(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendQueueEnabled: true, SendQueueMaxWait: 20 * time.Millisecond})
+
+	// A Send is already running in this process
+	svc.sendQueue <- struct{}{}
+
+	response, err := svc.Send(context.Background())
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, apperrors.ErrSendQueueTimeout)
+	assert.NoError(t, mock.ExpectationsWereMet(), "a Send still queued must not touch the lock")
+}
+
+func TestExecutionService_Send_QueueWaitsForRunningSend(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendQueueEnabled: true, SendQueueMaxWait: 5 * time.Second})
+
+	svc.sendQueue <- struct{}{}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-svc.sendQueue
+	}()
+
+	expectSendLock(mock, false)
+
+	start := time.Now()
+	_, err := svc.Send(context.Background())
+
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch, "cross-instance contention still conflicts")
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Empty(t, svc.sendQueue, "the queue token must be returned")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectAuditInsert mocks the audit row written at the end of a Send or retry
+func expectAuditInsert(mock sqlmock.Sqlmock, action string, batchID interface{}, outcome string) {
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WithArgs(action, batchID, sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), outcome, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+}
+
+func TestExecutionService_Send_WritesAuditOnSuccess(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithAudit(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: 0})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	start := now.Add(-time.Hour)
+
+	expectSendWindow(mock, 11, start, now, sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now))
+	expectAuditInsert(mock, domain.AuditActionSend, 11, domain.AuditOutcomeSuccess)
+
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_WritesAuditOnFailure(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithAudit(t, &config.Config{CLICommand: []string{"true"}})
+
+	expectSendLock(mock, false)
+	expectAuditInsert(mock, domain.AuditActionSend, nil, domain.AuditOutcomeError)
+
+	_, err := svc.Send(context.Background())
+
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_RetryBatch_WritesAudit(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithAudit(t, &config.Config{CLICommand: []string{"true"}})
+
+	start := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+	expectBatchLookup(mock, 7, start, start.Add(time.Hour), domain.BatchStatusCompleted)
+	expectAuditInsert(mock, domain.AuditActionBatchRetry, 7, domain.AuditOutcomeError)
+
+	_, err := svc.RetryBatch(context.Background(), 7)
+
+	assert.ErrorIs(t, err, apperrors.ErrBatchNotFailed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_AuditFailureDoesNotFailSend(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithAudit(t, &config.Config{CLICommand: []string{"true"}})
+
+	expectSendLock(mock, false)
+	mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnError(errors.New("audit table unavailable"))
+
+	_, err := svc.Send(context.Background())
+
+	assert.ErrorIs(t, err, apperrors.ErrDuplicateBatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectReadyTimestampAt mocks the window position lookup used to cap a Send; a nil
+// timestamp means the window holds no execution at that offset
+func expectReadyTimestampAt(mock sqlmock.Sqlmock, start, end time.Time, offset int, timestamp *time.Time) {
+	rows := sqlmock.NewRows([]string{"ready_to_send_timestamp"})
+	if timestamp != nil {
+		rows.AddRow(*timestamp)
+	}
+	mock.ExpectQuery(`SELECT ready_to_send_timestamp FROM execution`).
+		WithArgs(start, end, offset).
+		WillReturnRows(rows)
+}
+
+func TestExecutionService_Send_MaxSendBatchSize(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	t1 := start.Add(time.Minute)
+	t2 := start.Add(2 * time.Minute)
+	t3 := start.Add(3 * time.Minute)
+	t4 := start.Add(4 * time.Minute)
+
+	portfolioID := "PORTFOLIO123456789012"
+	executionRows := func(ids ...int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"})
+		for _, id := range ids {
+			rows.AddRow(id, portfolioID, "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now)
+		}
+		return rows
+	}
+
+	tests := []struct {
+		name            string
+		expectBoundary  func(mock sqlmock.Sqlmock)
+		expectedEnd     time.Time
+		rows            *sqlmock.Rows
+		expectedCount   int
+		expectRemaining bool
+	}{
+		{
+			name: "window within limit is sent whole",
+			expectBoundary: func(mock sqlmock.Sqlmock) {
+				expectReadyTimestampAt(mock, start, now, 0, &t1)
+				expectReadyTimestampAt(mock, start, now, 2, nil)
+			},
+			expectedEnd:   now,
+			rows:          executionRows(1, 2),
+			expectedCount: 2,
+		},
+		{
+			name: "window over limit ends at the first execution left out",
+			expectBoundary: func(mock sqlmock.Sqlmock) {
+				expectReadyTimestampAt(mock, start, now, 0, &t1)
+				expectReadyTimestampAt(mock, start, now, 2, &t3)
+			},
+			expectedEnd:     t3,
+			rows:            executionRows(1, 2),
+			expectedCount:   2,
+			expectRemaining: true,
+		},
+		{
+			name: "executions sharing the cut timestamp are sent together",
+			expectBoundary: func(mock sqlmock.Sqlmock) {
+				expectReadyTimestampAt(mock, start, now, 0, &t2)
+				expectReadyTimestampAt(mock, start, now, 2, &t2)
+				mock.ExpectQuery(`SELECT MIN\(ready_to_send_timestamp\) FROM execution`).
+					WithArgs(t2, now).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(t4))
+			},
+			expectedEnd:     t4,
+			rows:            executionRows(1, 2, 3),
+			expectedCount:   3,
+			expectRemaining: true,
+		},
+		{
+			name: "tied group filling the window leaves nothing behind",
+			expectBoundary: func(mock sqlmock.Sqlmock) {
+				expectReadyTimestampAt(mock, start, now, 0, &t2)
+				expectReadyTimestampAt(mock, start, now, 2, &t2)
+				mock.ExpectQuery(`SELECT MIN\(ready_to_send_timestamp\) FROM execution`).
+					WithArgs(t2, now).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+			},
+			expectedEnd:   now,
+			rows:          executionRows(1, 2, 3),
+			expectedCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, MaxSendBatchSize: 2})
+			svc.now = func() time.Time { return now }
+
+			expectSendLock(mock, true)
+			mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+				WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+			tt.expectBoundary(mock)
+			mock.ExpectQuery(`INSERT INTO batch_history`).
+				WithArgs(tt.expectedEnd, start, domain.BatchStatusInProgress, 1, nil).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+				WithArgs(start, tt.expectedEnd).
+				WillReturnRows(tt.rows)
+			expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+			expectSendUnlock(mock)
+
+			response, err := svc.Send(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, response.ProcessedCount)
+			if tt.expectRemaining {
+				assert.Contains(t, response.Message, "more executions remain")
+			} else {
+				assert.NotContains(t, response.Message, "more executions remain")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExecutionService_Send_MaxSendWindowDuration(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-72 * time.Hour)
+	first := start.Add(time.Hour)
+	cutoff := first.Add(24 * time.Hour)
+	next := first.Add(30 * time.Hour)
+
+	tests := []struct {
+		name          string
+		policy        string
+		force         bool
+		expectSplit   bool
+		expectRefused bool
+	}{
+		{name: "split sends the window as consecutive batches", policy: SendWindowOverflowPolicySplit, expectSplit: true},
+		{name: "default policy splits", expectSplit: true},
+		{name: "refuse fails unforced send", policy: SendWindowOverflowPolicyRefuse, expectRefused: true},
+		{name: "force sends the whole window", policy: SendWindowOverflowPolicyRefuse, force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mock := newTestExecutionService(t, &config.Config{
+				CLICommand:               []string{"true"},
+				MaxSendWindowDuration:    24 * time.Hour,
+				SendWindowOverflowPolicy: tt.policy,
+			})
+			svc.now = func() time.Time { return now }
+
+			expectSendLock(mock, true)
+			mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+				WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+			expectReadyTimestampAt(mock, start, now, 0, &first)
+			batchStart := start
+			if tt.expectSplit {
+				// The oldest max duration goes first, then the rest of the window
+				expectReadyTimestampAt(mock, cutoff, now, 0, &next)
+				mock.ExpectQuery(`INSERT INTO batch_history`).
+					WithArgs(cutoff, start, domain.BatchStatusInProgress, 1, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+					WithArgs(start, cutoff).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+
+				mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(cutoff))
+				expectReadyTimestampAt(mock, cutoff, now, 0, &next)
+				expectReadyTimestampAt(mock, next.Add(24*time.Hour), now, 0, nil)
+				batchStart = cutoff
+			}
+			if !tt.expectRefused {
+				mock.ExpectQuery(`INSERT INTO batch_history`).
+					WithArgs(now, batchStart, domain.BatchStatusInProgress, 1, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+					WithArgs(batchStart, now).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				expectBatchStatusUpdate(mock, 2, domain.BatchStatusCompleted)
+			}
+			expectSendUnlock(mock)
+
+			response, err := svc.SendWithOptions(context.Background(), SendOptions{Force: tt.force})
+
+			if tt.expectRefused {
+				assert.ErrorIs(t, err, apperrors.ErrSendWindowTooLarge)
+				assert.Nil(t, response)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, 2, response.BatchID)
+				assert.NotContains(t, response.Message, "more executions remain")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExecutionService_Send_MaxSendWindowDurationNothingPastCut(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-72 * time.Hour)
+	first := start.Add(time.Hour)
+
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:            []string{"true"},
+		MaxSendWindowDuration: 24 * time.Hour,
+	})
+	svc.now = func() time.Time { return now }
+
+	// With nothing stamped past the cut there is no second batch to split off
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+	expectReadyTimestampAt(mock, start, now, 0, &first)
+	expectReadyTimestampAt(mock, first.Add(24*time.Hour), now, 0, nil)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(now, start, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+	expectSendUnlock(mock)
+
+	_, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_SendWithBatchKey_SplitWindow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-72 * time.Hour)
+	first := start.Add(time.Hour)
+	cutoff := first.Add(24 * time.Hour)
+	next := now.Add(-time.Hour)
+	executionRows := func(timestamp time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, timestamp)
+	}
+
+	outputDir := t.TempDir()
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:            []string{"true"},
+		MaxSendWindowDuration: 24 * time.Hour,
+		OutputDir:             outputDir,
+		WriteChecksumSidecar:  true,
+	})
+	svc.now = func() time.Time { return now }
+
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+	expectReadyTimestampAt(mock, start, now, 0, &first)
+	expectReadyTimestampAt(mock, cutoff, now, 0, &next)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(cutoff, start, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, cutoff).
+		WillReturnRows(executionRows(first))
+	expectBatchStatusUpdate(mock, 5, domain.BatchStatusCompleted)
+
+	// The key goes on the last batch, which stores the response of the whole window
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(cutoff))
+	expectReadyTimestampAt(mock, cutoff, now, 0, &next)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(now, cutoff, domain.BatchStatusInProgress, 1, "nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(cutoff, now).
+		WillReturnRows(executionRows(next))
+	expectBatchStatusUpdate(mock, 6, domain.BatchStatusCompleted)
+	mock.ExpectExec(`UPDATE batch_history SET send_response = \$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSendUnlock(mock)
+
+	response, err := svc.SendWithBatchKey(context.Background(), "nightly")
+
+	require.NoError(t, err)
+	assert.Equal(t, 6, response.BatchID)
+	assert.Equal(t, 2, response.ProcessedCount)
+	assert.Len(t, response.FileNames, 2)
+	assert.Equal(t, response.FileNames[0], response.FileName)
+	// The sub-windows run within the same second, and neither file may overwrite the other
+	assert.NotEqual(t, response.FileNames[0], response.FileNames[1])
+	for _, filename := range response.FileNames {
+		assert.FileExists(t, filepath.Join(outputDir, filename))
+		assert.FileExists(t, filepath.Join(outputDir, filename+checksumSuffix))
+	}
+	assert.NotContains(t, response.Message, "more executions remain")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_SendWithBatchKey_SplitWindowEmptiedMeanwhile(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-72 * time.Hour)
+	first := start.Add(time.Hour)
+	cutoff := first.Add(24 * time.Hour)
+	next := now.Add(-time.Hour)
+
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:            []string{"true"},
+		MaxSendWindowDuration: 24 * time.Hour,
+	})
+	svc.now = func() time.Time { return now }
+
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE batch_key = \$1`).
+		WithArgs("nightly").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+	expectReadyTimestampAt(mock, start, now, 0, &first)
+	expectReadyTimestampAt(mock, cutoff, now, 0, &next)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(cutoff, start, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+			AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, first))
+	expectBatchStatusUpdate(mock, 5, domain.BatchStatusCompleted)
+
+	// The rest of the window was deleted before its batch, so the batch already sent
+	// takes the key and stores the response a retry replays
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(cutoff))
+	expectReadyTimestampAt(mock, cutoff, now, 0, nil)
+	mock.ExpectExec(`UPDATE batch_history SET batch_key = \$1, send_response = \$2 WHERE id = \$3`).
+		WithArgs("nightly", sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSendUnlock(mock)
+
+	response, err := svc.SendWithBatchKey(context.Background(), "nightly")
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, response.BatchID)
+	assert.Equal(t, 1, response.ProcessedCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_MaxSendWindowDurationIgnoresIdleStart(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-72 * time.Hour)
+	first := now.Add(-time.Hour)
+
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:               []string{"true"},
+		MaxSendWindowDuration:    24 * time.Hour,
+		SendWindowOverflowPolicy: SendWindowOverflowPolicyRefuse,
+	})
+	svc.now = func() time.Time { return now }
+
+	// Days without executions before the first one don't count toward the window span
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+	expectReadyTimestampAt(mock, start, now, 0, &first)
+	mock.ExpectQuery(`INSERT INTO batch_history`).
+		WithArgs(now, start, domain.BatchStatusInProgress, 1, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+		WithArgs(start, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+	expectSendUnlock(mock)
+
+	_, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewExecutionService_InvalidSendWindowOverflowPolicy(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{
+		TradeDateTimezone:        "America/New_York",
+		SendWindowOverflowPolicy: "truncate",
+	})
+
+	assert.ErrorContains(t, err, `unsupported send window overflow policy "truncate"`)
+}
+
+func TestExecutionService_Send_JSONLOutputFormat(t *testing.T) {
+	// The CLI only succeeds if it is handed the name of the file actually written
+	svc, mock := newTestExecutionService(t, &config.Config{
+		CLICommand:   []string{"test -f {output_dir}/{filename}"},
+		OutputFormat: OutputFormatJSONL,
+	})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	start := now.Add(-time.Hour)
+
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now)
+	expectSendWindow(mock, 1, start, now, rows)
+
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Status)
+	assert.True(t, strings.HasSuffix(response.FileName, ".jsonl"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewExecutionService_InvalidOutputFormat(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{
+		TradeDateTimezone: "America/New_York",
+		OutputFormat:      "xml",
+	})
+
+	assert.ErrorContains(t, err, "unsupported output format")
+}
+
+func TestExecutionService_Send_Reconciliation(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+
+	executionRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "execution_service_id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "total_amount", "trade_date"}).
+			AddRow(1, 101, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, 15.0, now).
+			AddRow(2, 102, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, 16.0, now)
+	}
+
+	t.Run("exclude sends the rest", func(t *testing.T) {
+		svc, mock := newTestExecutionService(t, &config.Config{
+			CLICommand:              []string{"true"},
+			ReconciliationEnabled:   true,
+			ReconciliationTolerance: 0.01,
+			ReconciliationPolicy:    ReconciliationPolicyExclude,
+		})
+		svc.now = func() time.Time { return now }
+		expectSendWindow(mock, 1, start, now, executionRows())
+
+		response, err := svc.Send(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "success", response.Status)
+		assert.Equal(t, 1, response.ProcessedCount)
+		assert.Equal(t, []int{2}, response.MismatchedExecutionIDs)
+		assert.Contains(t, response.Message, "excluded 1 executions")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("exclude records the excluded executions as skipped", func(t *testing.T) {
+		svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{
+			CLICommand:              []string{"true"},
+			ReconciliationEnabled:   true,
+			ReconciliationTolerance: 0.01,
+			ReconciliationPolicy:    ReconciliationPolicyExclude,
+		})
+		svc.now = func() time.Time { return now }
+
+		expectSendLock(mock, true)
+		mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+		expectReadyTimestampAt(mock, start, now, 0, &start)
+		mock.ExpectQuery(`INSERT INTO batch_history`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+			WillReturnRows(executionRows())
+		expectBatchStatusUpdate(mock, 1, domain.BatchStatusCompleted)
+		mock.ExpectExec(`INSERT INTO skipped_execution`).
+			WithArgs(102, domain.SkipReasonTotalAmountMismatch, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSendUnlock(mock)
+
+		response, err := svc.Send(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{2}, response.MismatchedExecutionIDs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("abort fails the batch", func(t *testing.T) {
+		svc, mock := newTestExecutionService(t, &config.Config{
+			CLICommand:              []string{"false"},
+			OutputDir:               t.TempDir(),
+			ReconciliationEnabled:   true,
+			ReconciliationTolerance: 0.01,
+			ReconciliationPolicy:    ReconciliationPolicyAbort,
+		})
+		svc.now = func() time.Time { return now }
+
+		expectSendLock(mock, true)
+		mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+		expectReadyTimestampAt(mock, start, now, 0, &start)
+		mock.ExpectQuery(`INSERT INTO batch_history`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1 AND ready_to_send_timestamp < \$2`).
+			WillReturnRows(executionRows())
+		expectBatchStatusUpdate(mock, 1, domain.BatchStatusFailed)
+		expectSendUnlock(mock)
+
+		response, err := svc.Send(context.Background())
+
+		assert.ErrorIs(t, err, apperrors.ErrReconciliationFailed)
+		require.NotNil(t, response)
+		assert.Equal(t, "error", response.Status)
+		assert.Equal(t, []int{2}, response.MismatchedExecutionIDs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		entries, readErr := os.ReadDir(svc.config.OutputDir)
+		require.NoError(t, readErr)
+		assert.Empty(t, entries, "no file is left for an aborted batch")
+	})
+}
+
+func TestNewExecutionService_InvalidReconciliationPolicy(t *testing.T) {
+	_, err := NewExecutionService(nil, nil, nil, zap.NewNop(), &config.Config{
+		TradeDateTimezone:     "America/New_York",
+		ReconciliationEnabled: true,
+		ReconciliationPolicy:  "ignore",
+	})
+
+	assert.ErrorContains(t, err, "unsupported reconciliation policy")
+}
+
+func TestExecutionService_LastSuccessfulSendMetric(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+	recency := observability.NewSendRecency()
+	svc.SetMetrics(observability.NewPrometheusRecorder(&observability.BusinessMetrics{
+		LastSuccessfulSend:       recency,
+		PortfolioCLIStepDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_cli_step_duration_seconds"}, []string{"step", "status"}),
+		PortfolioFileGenerated:   prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_portfolio_files_generated_total"}, []string{"status"}),
+		ExecutionsBatchProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_executions_batch_processed_total"}, []string{"status"}),
+		BatchSize:                prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_batch_size"}, []string{"operation"}),
+		BatchProcessingTime:      prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_batch_processing_duration_seconds"}, []string{"operation"}),
+	}))
+
+	seeded := time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history WHERE status = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(seeded))
+	require.NoError(t, svc.SeedLastSuccessfulSend(context.Background()))
+	seededAge := recency.SecondsSince()
+	assert.False(t, math.IsNaN(seededAge))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	svc.now = func() time.Time { return now }
+	expectSendWindow(mock, 1, seeded, now, sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now))
+
+	_, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Less(t, recency.SecondsSince(), seededAge, "a successful Send resets the gauge")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_LastSuccessfulSendMetric_EmptyWindow(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}, SendWindowLag: time.Second})
+	recency := observability.NewSendRecency()
+	svc.SetMetrics(observability.NewPrometheusRecorder(&observability.BusinessMetrics{LastSuccessfulSend: recency}))
+
+	previous := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	recency.Record(previous)
+	staleAge := recency.SecondsSince()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	svc.now = func() time.Time { return now }
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(previous))
+	expectReadyTimestampAt(mock, previous, now.Add(-time.Second), 0, nil)
+	expectSendUnlock(mock)
+
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, response.BatchID)
+	assert.Less(t, recency.SecondsSince(), staleAge, "a Send with nothing to send still resets the gauge")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newTestExecutionServiceWithSkips is newTestExecutionService with reprocessable skips stored
+func newTestExecutionServiceWithSkips(t *testing.T, cfg *config.Config) (*ExecutionService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	svc, mock, dbWrapper := newTestExecutionServiceWithDB(t, cfg)
+	svc.SetSkippedExecutionRepository(repository.NewSkippedExecutionRepository(dbWrapper, zap.NewNop()))
+	return svc, mock
+}
+
+func TestExecutionService_CreateBatch_StoresReprocessableSkips(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{BatchConcurrency: 1})
+
+	open := validExecutionDTO(1)
+	open.IsOpen = true
+	payload, err := json.Marshal(open)
+	require.NoError(t, err)
+
+	// Only the open execution is stored; the in-batch duplicate skip is final
+	mock.ExpectExec(`INSERT INTO skipped_execution`).
+		WithArgs(1, domain.SkipReasonOpen, payload).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{open, open})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.SkippedCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Reprocess(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{BatchConcurrency: 1})
+
+	payload, err := json.Marshal(validExecutionDTO(1))
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE execution_service_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]int{1, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"execution_service_id", "reason", "payload", "skipped_at"}).
+			AddRow(1, domain.SkipReasonPortfolioLookupFailed, payload, time.Now()))
+	expectExecutionLookup(mock, 1)
+	// The insert clears the stored skip itself
+	expectExecutionInsert(mock, 1, 11)
+
+	response, err := svc.Reprocess(context.Background(), domain.ReprocessRequest{ExecutionServiceIDs: []int{1, 2}})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, "created", response.Results[0].Status)
+	assert.Equal(t, 2, response.Results[1].ExecutionServiceID)
+	assert.Equal(t, "error", response.Results[1].Status)
+	assert.Equal(t, "no stored skip for execution service ID", response.Results[1].Error)
+	assert.Equal(t, 1, response.ProcessedCount)
+	assert.Equal(t, 1, response.ErrorCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Reprocess_RefusesReconciliationExclusion(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{BatchConcurrency: 1})
+
+	payload, err := json.Marshal(domain.ExecutionDTO{ID: 2, ExecutionServiceID: 102})
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE execution_service_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]int{102})).
+		WillReturnRows(sqlmock.NewRows([]string{"execution_service_id", "reason", "payload", "skipped_at"}).
+			AddRow(102, domain.SkipReasonTotalAmountMismatch, payload, time.Now()))
+
+	// Neither re-created nor cleared: the execution is stored and its skip kept for review
+	response, err := svc.Reprocess(context.Background(), domain.ReprocessRequest{ExecutionServiceIDs: []int{102}})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "error", response.Results[0].Status)
+	assert.Equal(t, domain.SkipReasonTotalAmountMismatch, response.Results[0].Reason)
+	assert.Contains(t, response.Results[0].Error, "cannot be reprocessed")
+	assert.Equal(t, 1, response.ErrorCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Reprocess_StillOpen(t *testing.T) {
+	svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{BatchConcurrency: 1})
+
+	open := validExecutionDTO(3)
+	open.IsOpen = true
+	payload, err := json.Marshal(open)
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE reason = \$1`).
+		WithArgs(domain.SkipReasonOpen, domain.MaxReprocessBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"execution_service_id", "reason", "payload", "skipped_at"}).
+			AddRow(3, domain.SkipReasonOpen, payload, time.Now()))
+	// The skip is stored again rather than cleared
+	mock.ExpectExec(`INSERT INTO skipped_execution`).
+		WithArgs(3, domain.SkipReasonOpen, payload).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	response, err := svc.Reprocess(context.Background(), domain.ReprocessRequest{Reason: domain.SkipReasonOpen})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "skipped", response.Results[0].Status)
+	assert.Equal(t, domain.SkipReasonOpen, response.Results[0].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Reprocess_UpdatedPayload(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	svc, mock := newTestExecutionServiceWithSkips(t, &config.Config{BatchConcurrency: 1})
+
+	open := validExecutionDTO(3)
+	open.IsOpen = true
+	payload, err := json.Marshal(open)
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT \* FROM skipped_execution WHERE execution_service_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]int{3, 4})).
+		WillReturnRows(sqlmock.NewRows([]string{"execution_service_id", "reason", "payload", "skipped_at"}).
+			AddRow(3, domain.SkipReasonOpen, payload, time.Now()))
+	// The closed payload is created in place of the stored open one
+	expectExecutionLookup(mock, 3)
+	expectExecutionInsert(mock, 3, 13)
+
+	closed := validExecutionDTO(3)
+	response, err := svc.Reprocess(context.Background(), domain.ReprocessRequest{
+		Executions: []domain.ExecutionPostDTO{closed, validExecutionDTO(4)},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, "created", response.Results[0].Status)
+	assert.Equal(t, 4, response.Results[1].ExecutionServiceID)
+	assert.Equal(t, "no stored skip for execution service ID", response.Results[1].Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Reprocess_NotEnabled(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	_, err := svc.Reprocess(context.Background(), domain.ReprocessRequest{Reason: domain.SkipReasonOpen})
+
+	assert.ErrorContains(t, err, "reprocessing is not enabled")
+}
+
+func TestExecutionService_CreateBatch_RecordsRejections(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			svc, mock, dbWrapper := newTestExecutionServiceWithDB(t, &config.Config{BatchConcurrency: 1, RejectedExecutionsEnabled: enabled})
+			svc.SetRejectedExecutionRepository(repository.NewRejectedExecutionRepository(dbWrapper, zap.NewNop()))
+
+			open := validExecutionDTO(1)
+			open.IsOpen = true
+			invalid := validExecutionDTO(2)
+			invalid.TradeType = "HOLD"
+
+			if enabled {
+				openPayload, err := json.Marshal(open)
+				require.NoError(t, err)
+				invalidPayload, err := json.Marshal(invalid)
+				require.NoError(t, err)
+				reason := domain.SkipReasonOpen
+				mock.ExpectQuery(`INSERT INTO rejected_execution`).
+					WithArgs(1, "skipped", &reason, sqlmock.AnyArg(), json.RawMessage(openPayload)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+				mock.ExpectQuery(`INSERT INTO rejected_execution`).
+					WithArgs(2, "error", nil, sqlmock.AnyArg(), json.RawMessage(invalidPayload)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+			}
+
+			response, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{open, invalid})
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, response.SkippedCount)
+			assert.Equal(t, 1, response.ErrorCount)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExecutionService_ListRejectedExecutions(t *testing.T) {
+	svc, mock, dbWrapper := newTestExecutionServiceWithDB(t, &config.Config{})
+	svc.SetRejectedExecutionRepository(repository.NewRejectedExecutionRepository(dbWrapper, zap.NewNop()))
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM rejected_execution`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM rejected_execution`).
+		WithArgs(50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	response, err := svc.ListRejectedExecutions(context.Background(), 50, 0)
+
+	require.NoError(t, err)
+	assert.NotNil(t, response.RejectedExecutions, "an empty page serializes as [] rather than null")
+	assert.Equal(t, 0, response.Pagination.TotalElements)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_List_PageSizeLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		expectedLimit int
+	}{
+		{name: "default", limit: 0, expectedLimit: 20},
+		{name: "within bounds", limit: 150, expectedLimit: 150},
+		{name: "clamped to max", limit: 201, expectedLimit: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mock := newTestExecutionService(t, &config.Config{DefaultPageSize: 20, MaxPageSize: 200})
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM execution WHERE deleted_at IS NULL`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(500))
+			mock.ExpectQuery(`SELECT \* FROM execution WHERE deleted_at IS NULL ORDER BY id DESC LIMIT \$1 OFFSET \$2`).
+				WithArgs(tt.expectedLimit, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			response, err := svc.List(context.Background(), domain.ExecutionFilter{}, domain.ExecutionSort{}, tt.limit, 0)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLimit, response.Pagination.PageSize)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	// Limits left unset fall back to the built-in defaults
+	svc, _ := newTestExecutionService(t, &config.Config{})
+	defaultSize, maxSize := svc.PageSizeLimits()
+	assert.Equal(t, 50, defaultSize)
+	assert.Equal(t, 1000, maxSize)
+}
+
+func TestExecutionService_CheckTradeDates(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{TradeDateTimezone: "America/New_York"})
+	core, logs := observer.New(zapcore.WarnLevel)
+	svc.logger = zap.New(core)
+
+	mock.ExpectQuery(`WHERE trade_date <> \(sent_timestamp AT TIME ZONE \$1\)::date`).
+		WithArgs("America/New_York", 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`WHERE trade_date <> \(sent_timestamp AT TIME ZONE \$1\)::date`).
+		WithArgs("America/New_York", 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(4))
+
+	// Consistent data logs nothing
+	mismatches, err := svc.CheckTradeDates(context.Background(), 500)
+	require.NoError(t, err)
+	assert.Zero(t, mismatches)
+	assert.Zero(t, logs.Len())
+
+	mismatches, err = svc.CheckTradeDates(context.Background(), 500)
+	require.NoError(t, err)
+	assert.Equal(t, 2, mismatches)
+	entries := logs.FilterMessage("Executions have a trade date that does not match their sent timestamp").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(2), entries[0].ContextMap()["mismatches"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// recordOTELMetrics installs a global meter provider backed by a manual reader and returns
+// a metrics manager that records through it
+func recordOTELMetrics(t *testing.T) (*observability.OTELMetricsManager, *sdkmetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	manager, err := observability.NewOTELMetricsManager(zap.NewNop())
+	require.NoError(t, err)
+	return manager, reader
+}
+
+// collectedMetricNames returns the names of every metric the reader has data for
+func collectedMetricNames(t *testing.T, reader *sdkmetric.ManualReader) map[string]bool {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	names := make(map[string]bool)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			names[m.Name] = true
+		}
+	}
+	return names
+}
+
+func TestExecutionService_OTELMetrics(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerPortfolioResponder("PORTFOLIO123456789012345")
+
+	manager, reader := recordOTELMetrics(t)
+	svc, mock := newTestExecutionService(t, &config.Config{})
+	svc.SetMetrics(observability.NewOTELRecorder(manager))
+
+	expectExecutionLookup(mock, 42)
+	expectExecutionInsert(mock, 42, 7)
+
+	_, err := svc.CreateBatch(context.Background(), []domain.ExecutionPostDTO{validExecutionDTO(42)})
+	require.NoError(t, err)
+
+	svc.recordBatchMetrics(context.Background(), "send", time.Second,
+		&domain.SendResponse{ProcessedCount: 3, Status: "success"})
+
+	names := collectedMetricNames(t, reader)
+	assert.True(t, names["executions_created_total"])
+	assert.True(t, names["executions_processed_total"])
+	assert.True(t, names["batch_processing_duration_seconds"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_GetBatch(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{})
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	expectBatchLookup(mock, 5, start, end, domain.BatchStatusCompleted)
+	response, err := svc.GetBatch(context.Background(), 5, false, 50, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, response.Batch.ID)
+	assert.Nil(t, response.Executions, "executions are only included on request")
+
+	expectBatchLookup(mock, 5, start, end, domain.BatchStatusCompleted)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM execution WHERE ready_to_send_timestamp >= \$1`).
+		WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1`).
+		WithArgs(start, end, 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "execution_service_id"}).AddRow(12, 42))
+	response, err = svc.GetBatch(context.Background(), 5, true, 2, 2)
+	require.NoError(t, err)
+	require.NotNil(t, response.Executions)
+	require.Len(t, response.Executions.Executions, 1)
+	assert.Equal(t, 12, response.Executions.Executions[0].ID)
+	assert.Equal(t, 3, response.Executions.Pagination.TotalElements)
+	assert.Equal(t, 1, response.Executions.Pagination.CurrentPage)
+
+	mock.ExpectQuery(`SELECT \* FROM batch_history WHERE id = \$1`).
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = svc.GetBatch(context.Background(), 99, true, 50, 0)
+	assert.ErrorIs(t, err, apperrors.ErrBatchNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_BulkLoad_HeldBackByInvalidExecutions(t *testing.T) {
+	svc, mock := newTestExecutionService(t, &config.Config{})
+
+	portfolioID := "PORTFOLIO123456789012ABC"
+	ready := validExecutionDTO(1)
+	ready.PortfolioID = &portfolioID
+	open := validExecutionDTO(2)
+	open.PortfolioID = &portfolioID
+	open.IsOpen = true
+	unresolved := validExecutionDTO(3)
+	invalid := validExecutionDTO(4)
+	invalid.PortfolioID = &portfolioID
+	invalid.Quantity = domain.DecimalFromFloat(0)
+
+	response, err := svc.BulkLoad(context.Background(), []domain.ExecutionPostDTO{ready, open, unresolved, invalid})
+
+	require.NoError(t, err)
+	assert.Zero(t, response.LoadedCount)
+	require.Len(t, response.Results, 3)
+	assert.Equal(t, domain.SkipReasonOpen, response.Results[0].SkipReason)
+	assert.Equal(t, "portfolioId", response.Results[1].Errors[0].Field)
+	assert.Equal(t, 3, response.Results[2].Index)
+	assert.False(t, response.Results[2].Valid)
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is loaded")
+}
+
+func TestExecutionService_BulkLoad_Disabled(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	portfolioID := "PORTFOLIO123456789012ABC"
+	executionDTO := validExecutionDTO(1)
+	executionDTO.PortfolioID = &portfolioID
+
+	response, err := svc.BulkLoad(context.Background(), []domain.ExecutionPostDTO{executionDTO})
+
+	assert.ErrorIs(t, err, apperrors.ErrBulkLoadDisabled)
+	assert.Nil(t, response)
+}
@@ -1,10 +1,20 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,109 +23,644 @@ import (
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
 )
 
+// ctxCheckInterval is how many records are written between context cancellation checks
+const ctxCheckInterval = 100
+
+// Output file formats accepted by the Portfolio Accounting CLI
+const (
+	OutputFormatCSV   = "csv"
+	OutputFormatJSONL = "jsonl"
+)
+
+// csvHeader names the Portfolio Accounting fields; JSON Lines records use the same names as keys
+var csvHeader = portfolioAccountingFieldNames()
+
+// CSV line endings
+const (
+	CSVLineEndingLF   = "lf"
+	CSVLineEndingCRLF = "crlf"
+)
+
+// utf8BOM is the byte order mark some Windows importers need at the start of a CSV
+const utf8BOM = "\ufeff"
+
+// partialFillStatuses are the execution statuses for which only quantity_filled was
+// actually traded, so the file carries it instead of the ordered quantity
+var partialFillStatuses = map[string]bool{
+	"PART":             true,
+	"PARTIAL":          true,
+	"PARTIALLY_FILLED": true,
+}
+
+// checksumSuffix is appended to a generated file's name to name its SHA-256 sidecar
+const checksumSuffix = ".sha256"
+
 // FileGeneratorService handles file generation for Portfolio Accounting CLI
 type FileGeneratorService struct {
-	outputDir string
-	logger    *zap.Logger
+	outputDir        string
+	format           string
+	lineEnding       string
+	writeBOM         bool
+	writeChecksum    bool
+	validate         bool
+	fileMode         os.FileMode
+	dirMode          os.FileMode
+	splitByPortfolio bool
+	tradeTypeMapping map[string]string
+	logger           *zap.Logger
 }
 
-// NewFileGeneratorService creates a new file generator service
+// NewFileGeneratorService creates a new file generator service that writes CSV
 func NewFileGeneratorService(outputDir string, logger *zap.Logger) *FileGeneratorService {
 	return &FileGeneratorService{
-		outputDir: outputDir,
-		logger:    logger,
+		outputDir:  outputDir,
+		format:     OutputFormatCSV,
+		lineEnding: "\n",
+		logger:     logger,
 	}
 }
 
-// GeneratePortfolioAccountingFile creates a CSV file in the Portfolio Accounting CLI format
+// SetOutputFormat selects the file format, "csv" or "jsonl"; empty keeps CSV
+func (s *FileGeneratorService) SetOutputFormat(format string) error {
+	switch format {
+	case "":
+		s.format = OutputFormatCSV
+	case OutputFormatCSV, OutputFormatJSONL:
+		s.format = format
+	default:
+		return fmt.Errorf("unsupported output format %q, expected %q or %q", format, OutputFormatCSV, OutputFormatJSONL)
+	}
+	return nil
+}
+
+// SetCSVLineEnding selects the CSV line ending, "lf" or "crlf"; empty keeps LF
+func (s *FileGeneratorService) SetCSVLineEnding(lineEnding string) error {
+	switch lineEnding {
+	case "", CSVLineEndingLF:
+		s.lineEnding = "\n"
+	case CSVLineEndingCRLF:
+		s.lineEnding = "\r\n"
+	default:
+		return fmt.Errorf("unsupported CSV line ending %q, expected %q or %q", lineEnding, CSVLineEndingLF, CSVLineEndingCRLF)
+	}
+	return nil
+}
+
+// SetCSVWriteBOM sets whether CSV files start with a UTF-8 byte order mark
+func (s *FileGeneratorService) SetCSVWriteBOM(writeBOM bool) {
+	s.writeBOM = writeBOM
+}
+
+// SetWriteChecksumSidecar sets whether each generated file gets a <filename>.sha256
+// sidecar in sha256sum format, so the CLI can verify it
+func (s *FileGeneratorService) SetWriteChecksumSidecar(writeChecksum bool) {
+	s.writeChecksum = writeChecksum
+}
+
+// SetValidateGeneratedFile sets whether a generated CSV file is read back and parsed
+// before it is handed to the CLI, so a malformed file fails the Send early
+func (s *FileGeneratorService) SetValidateGeneratedFile(validate bool) {
+	s.validate = validate
+}
+
+// SetOutputModes sets the exact permissions of generated files and of the output
+// directory when it has to be created; a zero mode keeps 0666 or 0755 less the umask
+func (s *FileGeneratorService) SetOutputModes(fileMode, dirMode os.FileMode) {
+	s.fileMode = fileMode
+	s.dirMode = dirMode
+}
+
+// SetSplitByPortfolio sets whether StreamPortfolioAccountingFiles writes a file per portfolio
+func (s *FileGeneratorService) SetSplitByPortfolio(split bool) {
+	s.splitByPortfolio = split
+}
+
+// SetTradeTypeMapping sets the output transaction_type code for each trade type,
+// e.g. BUY=B. An empty mapping writes trade types unchanged.
+func (s *FileGeneratorService) SetTradeTypeMapping(mapping map[string]string) {
+	s.tradeTypeMapping = mapping
+}
+
+// portfolioAccountingField is one Portfolio Accounting output field. value appends the
+// field's unquoted value for an execution, given its mapped transaction type.
+type portfolioAccountingField struct {
+	name    string
+	numeric bool // a JSON number in JSON Lines records rather than a string
+	value   func(buf []byte, execution domain.Execution, transactionType string) []byte
+}
+
+// portfolioAccountingFields lists the output fields in column order. CSV lines, JSON
+// Lines records and csvHeader are all built from it, so the formats cannot drift apart.
+var portfolioAccountingFields = []portfolioAccountingField{
+	{name: "portfolio_id", value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		// portfolio_id should not be null at this point
+		if execution.PortfolioID == nil {
+			return buf
+		}
+		return append(buf, *execution.PortfolioID...)
+	}},
+	{name: "security_id", value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		return append(buf, execution.SecurityID...)
+	}},
+	{name: "source_id", value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		return strconv.AppendInt(append(buf, "AC"...), int64(execution.ID), 10)
+	}},
+	{name: "transaction_type", value: func(buf []byte, _ domain.Execution, transactionType string) []byte {
+		return append(buf, transactionType...)
+	}},
+	{name: "quantity", numeric: true, value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		return transactionQuantity(execution).AppendFixed(buf, 8)
+	}},
+	{name: "price", numeric: true, value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		return execution.AveragePrice.AppendFixed(buf, 8)
+	}},
+	{name: "transaction_date", value: func(buf []byte, execution domain.Execution, _ string) []byte {
+		return execution.TradeDate.AppendFormat(buf, "20060102")
+	}},
+}
+
+// portfolioAccountingFieldNames returns the field names as a CSV header line
+func portfolioAccountingFieldNames() string {
+	names := make([]string, len(portfolioAccountingFields))
+	for i, field := range portfolioAccountingFields {
+		names[i] = field.name
+	}
+	return strings.Join(names, ",")
+}
+
+// ExecutionStream yields executions one at a time to fn, stopping at the first error fn returns
+type ExecutionStream func(fn func(domain.Execution) error) error
+
+// SliceExecutionStream adapts an in-memory slice of executions to an ExecutionStream
+func SliceExecutionStream(executions []domain.Execution) ExecutionStream {
+	return func(fn func(domain.Execution) error) error {
+		for _, execution := range executions {
+			if err := fn(execution); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// GeneratePortfolioAccountingFile creates a file in the Portfolio Accounting CLI format
 func (s *FileGeneratorService) GeneratePortfolioAccountingFile(ctx context.Context, executions []domain.Execution) (string, error) {
 	if len(executions) == 0 {
 		return "", fmt.Errorf("no executions to process")
 	}
 
-	// Generate filename with timestamp
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("transactions_%s.csv", timestamp)
-	filepath := filepath.Join(s.outputDir, filename)
+	filename, _, err := s.StreamPortfolioAccountingFile(ctx, SliceExecutionStream(executions))
+	return filename, err
+}
+
+// StreamPortfolioAccountingFile writes executions from the stream to a new Portfolio
+// Accounting file as they arrive, so the full set never has to be held in memory.
+// It returns the filename and the number of records written; when the stream yields
+// no executions no file is left behind and an empty filename is returned.
+func (s *FileGeneratorService) StreamPortfolioAccountingFile(ctx context.Context, stream ExecutionStream) (string, int, error) {
+	files, count, err := s.streamFiles(ctx, stream, false)
+	if err != nil || len(files) == 0 {
+		return "", 0, err
+	}
+	return files[0].Filename, count, nil
+}
+
+// GeneratedFile is a Portfolio Accounting file written by StreamPortfolioAccountingFiles
+type GeneratedFile struct {
+	Filename string
+	// PortfolioID is the portfolio whose executions the file holds when output is split
+	// by portfolio, and empty otherwise
+	PortfolioID string
+}
 
-	s.logger.Info("Generating Portfolio Accounting file",
-		zap.String("filename", filename),
-		zap.String("filepath", filepath),
-		zap.Int("execution_count", len(executions)))
+// StreamPortfolioAccountingFiles is StreamPortfolioAccountingFile, except that when
+// output is split by portfolio each portfolio's executions go to a file of their own.
+// The stream must then yield each portfolio's executions together; only one file is
+// open at a time. It returns the files in the order they were written.
+func (s *FileGeneratorService) StreamPortfolioAccountingFiles(ctx context.Context, stream ExecutionStream) ([]GeneratedFile, int, error) {
+	return s.streamFiles(ctx, stream, s.splitByPortfolio)
+}
+
+// streamFiles writes the stream to one file, or one per portfolio when split is set.
+// A portfolio's file is finished when the next portfolio's first execution arrives,
+// and every file written is removed if any fails.
+func (s *FileGeneratorService) streamFiles(ctx context.Context, stream ExecutionStream, split bool) ([]GeneratedFile, int, error) {
+	// Microseconds keep apart the files of sub-windows a split Send writes within the
+	// same second
+	timestamp := strings.Replace(time.Now().Format("20060102_150405.000000"), ".", "_", 1)
 
 	// Ensure output directory exists
-	if err := os.MkdirAll(s.outputDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory: %w", err)
+	if err := s.ensureOutputDir(); err != nil {
+		return nil, 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var ordered []*outputFile
+	var current *outputFile
+	currentPortfolio := ""
+	finished := make(map[string]bool)     // portfolios whose file is complete
+	portfolios := make(map[string]string) // filename part -> portfolio ID
+	fileFor := func(execution domain.Execution) (*outputFile, error) {
+		portfolioID := ""
+		if execution.PortfolioID != nil {
+			portfolioID = *execution.PortfolioID
+		}
+		if current != nil && (!split || portfolioID == currentPortfolio) {
+			return current, nil
+		}
+		if current != nil {
+			if err := s.finishOutputFile(current); err != nil {
+				return nil, err
+			}
+			finished[currentPortfolio] = true
+			current = nil
+		}
+		if finished[portfolioID] {
+			return nil, fmt.Errorf("executions of portfolio %q are not grouped together", portfolioID)
+		}
+
+		if !split {
+			portfolioID = ""
+		}
+		filename := fmt.Sprintf("transactions_%s.%s", timestamp, s.format)
+		if split {
+			part := portfolioFilenamePart(portfolioID)
+			if other, ok := portfolios[part]; ok {
+				return nil, fmt.Errorf("portfolios %q and %q map to the same filename", other, portfolioID)
+			}
+			portfolios[part] = portfolioID
+			filename = fmt.Sprintf("transactions_%s_%s.%s", part, timestamp, s.format)
+		}
+		s.logger.Info("Generating Portfolio Accounting file",
+			zap.String("filename", filename),
+			zap.String("filepath", filepath.Join(s.outputDir, filename)))
+		out, err := s.openOutputFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		out.portfolioID = portfolioID
+		ordered = append(ordered, out)
+		current, currentPortfolio = out, portfolioID
+		return out, nil
+	}
+
+	count, err := s.writeExecutions(ctx, stream, fileFor)
+	if err == nil && current != nil {
+		err = s.finishOutputFile(current)
+	}
+	if err != nil {
+		for _, out := range ordered {
+			s.discardOutputFile(out)
+		}
+		return nil, 0, err
+	}
+	if count == 0 {
+		s.logger.Info("No executions to write, no file generated")
+		return nil, 0, nil
+	}
+
+	files := make([]GeneratedFile, len(ordered))
+	for i, out := range ordered {
+		files[i] = GeneratedFile{Filename: out.filename, PortfolioID: out.portfolioID}
+		s.logger.Info("Portfolio Accounting file generated successfully",
+			zap.String("filename", out.filename),
+			zap.Int("records_written", out.count))
+	}
+	return files, count, nil
+}
+
+// portfolioFilenamePart returns a portfolio ID made safe for a filename. An ID that had
+// to be changed gets a hash of the original appended, so IDs such as "A/B" and "A_B"
+// still get different files.
+func portfolioFilenamePart(portfolioID string) string {
+	if portfolioID == "" {
+		return "NONE"
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, portfolioID)
+	if safe == portfolioID {
+		return safe
+	}
+	sum := sha256.Sum256([]byte(portfolioID))
+	return safe + "_" + hex.EncodeToString(sum[:4])
+}
+
+// outputFile is a Portfolio Accounting file being written
+type outputFile struct {
+	filename    string
+	portfolioID string
+	path        string
+	file        *os.File
+	w           *bufio.Writer
+	hasher      hash.Hash
+	count       int
+}
+
+// openOutputFile creates a file in the output directory and writes the optional BOM
+// and the header for CSV
+func (s *FileGeneratorService) openOutputFile(filename string) (*outputFile, error) {
+	path := filepath.Join(s.outputDir, filename)
+	file, err := s.createFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	out := &outputFile{filename: filename, path: path, file: file}
+
+	// Hash the file as it is written rather than reading it back
+	var w io.Writer = file
+	if s.writeChecksum {
+		out.hasher = sha256.New()
+		w = io.MultiWriter(file, out.hasher)
 	}
+	out.w = bufio.NewWriter(w)
 
-	// Create file
-	file, err := os.Create(filepath)
+	if s.format == OutputFormatCSV {
+		if s.writeBOM {
+			if _, err := out.w.WriteString(utf8BOM); err != nil {
+				s.discardOutputFile(out)
+				return nil, fmt.Errorf("failed to write byte order mark: %w", err)
+			}
+		}
+		if _, err := out.w.WriteString(csvHeader + s.lineEnding); err != nil {
+			s.discardOutputFile(out)
+			return nil, fmt.Errorf("failed to write header: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// finishOutputFile flushes and closes a file, then validates it and writes its
+// checksum sidecar as configured
+func (s *FileGeneratorService) finishOutputFile(out *outputFile) error {
+	if err := out.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := out.file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	if s.validate && s.format == OutputFormatCSV {
+		if err := validateCSVFile(out.path, out.count); err != nil {
+			return err
+		}
+	}
+	if out.hasher != nil {
+		return s.writeChecksumSidecar(out.filename, out.hasher.Sum(nil))
+	}
+	return nil
+}
+
+// discardOutputFile closes and removes an incomplete file and any sidecar written for it
+func (s *FileGeneratorService) discardOutputFile(out *outputFile) {
+	out.file.Close() //nolint:errcheck // already closed when finishing it failed
+	if err := os.Remove(out.path); err != nil {
+		s.logger.Error("failed to remove incomplete file", zap.String("filepath", out.path), zap.Error(err))
+	}
+	if err := os.Remove(out.path + checksumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Error("failed to remove incomplete checksum file", zap.String("filepath", out.path+checksumSuffix), zap.Error(err))
+	}
+}
+
+// validateCSVFile parses a generated CSV file and checks it has the expected header,
+// the same number of fields on every row and one row per written execution
+func validateCSVFile(path string, expectedRecords int) error {
+	file, err := os.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return fmt.Errorf("failed to open generated file for validation: %w", err)
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			s.logger.Error("failed to close file", zap.Error(err))
+	defer file.Close() //nolint:errcheck
+
+	br := bufio.NewReader(file)
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		br.Discard(len(utf8BOM)) //nolint:errcheck
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = len(strings.Split(csvHeader, ","))
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("generated file is malformed: %w", err)
+	}
+	if strings.Join(header, ",") != csvHeader {
+		return fmt.Errorf("generated file is malformed: unexpected header %q", strings.Join(header, ","))
+	}
+
+	records := 0
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("generated file is malformed: %w", err)
 		}
-	}()
+		records++
+	}
+	if records != expectedRecords {
+		return fmt.Errorf("generated file is malformed: %d records, expected %d", records, expectedRecords)
+	}
+	return nil
+}
 
-	// Write CSV header
-	header := "portfolio_id,security_id,source_id,transaction_type,quantity,price,transaction_date\n"
-	if _, err := file.WriteString(header); err != nil {
-		return "", fmt.Errorf("failed to write header: %w", err)
+// writeChecksumSidecar writes the digest of filename to its sidecar
+func (s *FileGeneratorService) writeChecksumSidecar(filename string, digest []byte) error {
+	file, err := s.createFile(filepath.Join(s.outputDir, filename+checksumSuffix))
+	if err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	_, err = fmt.Fprintf(file, "%s  %s\n", hex.EncodeToString(digest), filename)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
 	}
+	return nil
+}
 
-	// Convert executions to CSV format
-	for _, execution := range executions {
-		line := s.executionToCSVLine(execution)
-		if _, err := file.WriteString(line); err != nil {
-			return "", fmt.Errorf("failed to write execution line: %w", err)
+// ensureOutputDir creates the output directory if needed, with the configured mode
+func (s *FileGeneratorService) ensureOutputDir() error {
+	if s.dirMode == 0 {
+		return os.MkdirAll(s.outputDir, 0755)
+	}
+	_, statErr := os.Stat(s.outputDir)
+	if err := os.MkdirAll(s.outputDir, s.dirMode); err != nil {
+		return err
+	}
+	// An existing directory, such as a mounted volume, keeps its permissions
+	if errors.Is(statErr, os.ErrNotExist) {
+		return os.Chmod(s.outputDir, s.dirMode)
+	}
+	return nil
+}
+
+// createFile creates a new file with the configured mode, failing rather than
+// overwriting a file that already exists, which the CLI may not have loaded yet. The
+// mode is set again after creation so the umask cannot change it.
+func (s *FileGeneratorService) createFile(path string) (*os.File, error) {
+	if s.fileMode == 0 {
+		return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, s.fileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(s.fileMode); err != nil {
+		file.Close() //nolint:errcheck
+		return nil, err
+	}
+	return file, nil
+}
+
+// writeExecutions writes one line per streamed execution to the file fileFor picks
+// for it, aborting with the context's error once ctx is cancelled
+func (s *FileGeneratorService) writeExecutions(ctx context.Context, stream ExecutionStream, fileFor func(domain.Execution) (*outputFile, error)) (int, error) {
+	count := 0
+	var line []byte // reused across rows
+	err := stream(func(execution domain.Execution) error {
+		if count%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("file generation cancelled: %w", err)
+			}
+		}
+		var err error
+		line, err = s.appendExecutionLine(line[:0], execution)
+		if err != nil {
+			return err
 		}
+		out, err := fileFor(execution)
+		if err != nil {
+			return err
+		}
+		if _, err := out.w.Write(line); err != nil {
+			return fmt.Errorf("failed to write execution line: %w", err)
+		}
+		out.count++
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return count, fmt.Errorf("file generation cancelled: %w", err)
 	}
 
-	s.logger.Info("Portfolio Accounting file generated successfully",
-		zap.String("filename", filename),
-		zap.Int("records_written", len(executions)))
+	return count, nil
+}
+
+// appendExecutionLine appends an execution as one line of the configured output format to buf
+func (s *FileGeneratorService) appendExecutionLine(buf []byte, execution domain.Execution) ([]byte, error) {
+	if s.format == OutputFormatCSV {
+		return s.appendCSVLine(buf, execution)
+	}
+	return s.appendJSONLine(buf, execution)
+}
 
-	return filename, nil
+// transactionType maps a trade type to its output code. An empty mapping passes
+// trade types through unchanged; otherwise unmapped trade types are an error.
+func (s *FileGeneratorService) transactionType(execution domain.Execution) (string, error) {
+	if len(s.tradeTypeMapping) == 0 {
+		return execution.TradeType, nil
+	}
+	code, ok := s.tradeTypeMapping[execution.TradeType]
+	if !ok {
+		return "", fmt.Errorf("execution %d has trade type %q with no configured transaction type mapping", execution.ID, execution.TradeType)
+	}
+	return code, nil
 }
 
-// executionToCSVLine converts an execution to a CSV line according to the Portfolio Accounting format
-func (s *FileGeneratorService) executionToCSVLine(execution domain.Execution) string {
-	// Extract portfolio_id (should not be null at this point)
-	portfolioID := ""
-	if execution.PortfolioID != nil {
-		portfolioID = *execution.PortfolioID
+// transactionQuantity is the quantity booked for an execution: the filled quantity for
+// a partial fill, otherwise the ordered quantity
+func transactionQuantity(execution domain.Execution) domain.Decimal {
+	if partialFillStatuses[execution.ExecutionStatus] {
+		return execution.QuantityFilled
 	}
+	return execution.Quantity
+}
 
-	// Generate source_id as "AC" + execution.id
-	sourceID := fmt.Sprintf("AC%d", execution.ID)
+// appendJSONLine appends an execution to buf as a JSON Lines record, with the same
+// fields and values as a CSV line
+func (s *FileGeneratorService) appendJSONLine(buf []byte, execution domain.Execution) ([]byte, error) {
+	transactionType, err := s.transactionType(execution)
+	if err != nil {
+		return buf, err
+	}
 
-	// Format trade date as YYYYMMDD
-	tradeDate := execution.TradeDate.Format("20060102")
+	var value []byte
+	buf = append(buf, '{')
+	for i, field := range portfolioAccountingFields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '"')
+		buf = append(buf, field.name...)
+		buf = append(buf, '"', ':')
+		value = field.value(value[:0], execution, transactionType)
+		if field.numeric {
+			buf = append(buf, value...)
+			continue
+		}
+		quoted, err := json.Marshal(string(value))
+		if err != nil {
+			return buf, fmt.Errorf("failed to encode execution %d: %w", execution.ID, err)
+		}
+		buf = append(buf, quoted...)
+	}
+	return append(buf, '}', '\n'), nil
+}
 
-	// Build CSV line
-	fields := []string{
-		portfolioID,
-		execution.SecurityID,
-		sourceID,
-		execution.TradeType,
-		fmt.Sprintf("%.8f", execution.Quantity),
-		fmt.Sprintf("%.8f", execution.AveragePrice),
-		tradeDate,
+// appendCSVLine appends an execution to buf as a CSV line according to the Portfolio
+// Accounting format. Fields are formatted straight into buf: for a multi-hundred-thousand-row
+// Send, building each field as a string and joining them dominated file generation.
+func (s *FileGeneratorService) appendCSVLine(buf []byte, execution domain.Execution) ([]byte, error) {
+	transactionType, err := s.transactionType(execution)
+	if err != nil {
+		return buf, err
 	}
 
-	// Escape fields that might contain commas or quotes
-	for i, field := range fields {
-		if strings.Contains(field, ",") || strings.Contains(field, "\"") || strings.Contains(field, "\n") {
-			fields[i] = "\"" + strings.ReplaceAll(field, "\"", "\"\"") + "\""
+	for i, field := range portfolioAccountingFields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		start := len(buf)
+		buf = field.value(buf, execution, transactionType)
+		if csvFieldNeedsQuotes(buf[start:]) {
+			value := string(buf[start:])
+			buf = appendCSVField(buf[:start], value)
 		}
 	}
+	return append(buf, s.lineEnding...), nil
+}
+
+// csvSpecialChars are the characters that make a CSV field need quoting
+const csvSpecialChars = ",\"\r\n"
+
+// csvFieldNeedsQuotes reports whether a CSV field contains a comma, quote or line break
+func csvFieldNeedsQuotes(field []byte) bool {
+	return bytes.ContainsAny(field, csvSpecialChars)
+}
 
-	return strings.Join(fields, ",") + "\n"
+// appendCSVField appends a field to buf, quoted with embedded quotes doubled when it
+// contains a comma, quote or line break
+func appendCSVField(buf []byte, field string) []byte {
+	if !strings.ContainsAny(field, csvSpecialChars) {
+		return append(buf, field...)
+	}
+	buf = append(buf, '"')
+	for i := 0; i < len(field); i++ {
+		if field[i] == '"' {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, field[i])
+	}
+	return append(buf, '"')
 }
 
-// CleanupFile removes a file if cleanup is enabled
+// CleanupFile removes a file and its checksum sidecar, if any, when cleanup is enabled
 func (s *FileGeneratorService) CleanupFile(filename string, cleanupEnabled bool) error {
 	if !cleanupEnabled {
 		s.logger.Info("File cleanup disabled, keeping file", zap.String("filename", filename))
@@ -127,6 +672,10 @@ func (s *FileGeneratorService) CleanupFile(filename string, cleanupEnabled bool)
 		s.logger.Error("Failed to cleanup file", zap.String("filepath", filepath), zap.Error(err))
 		return fmt.Errorf("failed to cleanup file: %w", err)
 	}
+	if err := os.Remove(filepath + checksumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Error("Failed to cleanup checksum file", zap.String("filepath", filepath+checksumSuffix), zap.Error(err))
+		return fmt.Errorf("failed to cleanup checksum file: %w", err)
+	}
 
 	s.logger.Info("File cleaned up successfully", zap.String("filepath", filepath))
 	return nil
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Portfolio cache lookup results, used as the metric label
+const (
+	cacheResultHit         = "hit"
+	cacheResultNegativeHit = "negative_hit"
+	cacheResultMiss        = "miss"
+)
+
+// portfolioCacheEntry is a resolved portfolio ID, or a known-missing execution when found is false
+type portfolioCacheEntry struct {
+	portfolioID string
+	found       bool
+	expiresAt   time.Time
+}
+
+// portfolioCache is a size-bounded TTL cache of portfolio IDs keyed by executionServiceId
+type portfolioCache struct {
+	mu          sync.Mutex
+	entries     map[int]portfolioCacheEntry
+	maxSize     int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+// newPortfolioCache creates a cache holding at most maxSize entries
+func newPortfolioCache(maxSize int, ttl, negativeTTL time.Duration) *portfolioCache {
+	return &portfolioCache{
+		entries:     make(map[int]portfolioCacheEntry),
+		maxSize:     maxSize,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
+
+// get returns the live entry for executionServiceID, if any
+func (c *portfolioCache) get(executionServiceID int) (portfolioCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[executionServiceID]
+	if !ok {
+		return portfolioCacheEntry{}, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, executionServiceID)
+		return portfolioCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// set stores a resolved portfolio ID, or a not-found result when found is false
+func (c *portfolioCache) set(executionServiceID int, portfolioID string, found bool) {
+	ttl := c.ttl
+	if !found {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[executionServiceID]; !exists && len(c.entries) >= c.maxSize {
+		c.evict(now)
+	}
+	c.entries[executionServiceID] = portfolioCacheEntry{
+		portfolioID: portfolioID,
+		found:       found,
+		expiresAt:   now.Add(ttl),
+	}
+}
+
+// evict drops expired entries, or the entry closest to expiry if none have expired.
+// Callers must hold c.mu.
+func (c *portfolioCache) evict(now time.Time) {
+	oldestID := 0
+	var oldest time.Time
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+			continue
+		}
+		if oldest.IsZero() || entry.expiresAt.Before(oldest) {
+			oldestID, oldest = id, entry.expiresAt
+		}
+	}
+	if len(c.entries) >= c.maxSize {
+		delete(c.entries, oldestID)
+	}
+}
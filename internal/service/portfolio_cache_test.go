@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortfolioCache_TTLAndNegativeTTL(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	cache := newPortfolioCache(10, time.Minute, 10*time.Second)
+	cache.now = func() time.Time { return now }
+
+	cache.set(1, "PORTFOLIO_A", true)
+	cache.set(2, "", false)
+
+	entry, ok := cache.get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "PORTFOLIO_A", entry.portfolioID)
+
+	entry, ok = cache.get(2)
+	assert.True(t, ok)
+	assert.False(t, entry.found)
+
+	// Negative entries expire first
+	now = now.Add(10 * time.Second)
+	_, ok = cache.get(2)
+	assert.False(t, ok)
+	_, ok = cache.get(1)
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.get(1)
+	assert.False(t, ok)
+}
+
+func TestPortfolioCache_EvictsWhenFull(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	cache := newPortfolioCache(2, time.Minute, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set(1, "PORTFOLIO_A", true)
+	now = now.Add(time.Second)
+	cache.set(2, "PORTFOLIO_B", true)
+	now = now.Add(time.Second)
+	cache.set(3, "PORTFOLIO_C", true)
+
+	assert.Len(t, cache.entries, 2)
+	_, ok := cache.get(1)
+	assert.False(t, ok, "entry closest to expiry should be evicted")
+	_, ok = cache.get(3)
+	assert.True(t, ok)
+}
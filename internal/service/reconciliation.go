@@ -0,0 +1,106 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// Policies for executions that fail total amount reconciliation
+const (
+	ReconciliationPolicyExclude = "exclude"
+	ReconciliationPolicyAbort   = "abort"
+)
+
+// Policies for the total amount of a created full fill
+const (
+	TotalAmountPolicyTrust     = "trust"     // keep the supplied total amount
+	TotalAmountPolicyCompute   = "compute"   // replace it with quantity * average price
+	TotalAmountPolicyReconcile = "reconcile" // reject the execution if they differ beyond tolerance
+)
+
+// validateTotalAmountPolicy rejects unknown total amount policies; empty means trust
+func validateTotalAmountPolicy(policy string) error {
+	switch policy {
+	case "", TotalAmountPolicyTrust, TotalAmountPolicyCompute, TotalAmountPolicyReconcile:
+		return nil
+	default:
+		return fmt.Errorf("unsupported total amount policy %q, expected %q, %q or %q",
+			policy, TotalAmountPolicyTrust, TotalAmountPolicyCompute, TotalAmountPolicyReconcile)
+	}
+}
+
+// applyTotalAmountPolicy computes or reconciles a new execution's total amount. Partial
+// fills keep the supplied amount, since quantity * average price would overstate it.
+func applyTotalAmountPolicy(execution *domain.Execution, policy string, tolerance float64) error {
+	if partialFillStatuses[execution.ExecutionStatus] {
+		return nil
+	}
+
+	computed := execution.ComputeTotalAmount()
+	switch policy {
+	case TotalAmountPolicyCompute:
+		execution.TotalAmount = computed
+	case TotalAmountPolicyReconcile:
+		if math.Abs(computed.Sub(execution.TotalAmount).Float64()) > tolerance {
+			return fmt.Errorf("total amount %s does not match quantity * average price %s within tolerance %g",
+				execution.TotalAmount, computed, tolerance)
+		}
+	}
+	return nil
+}
+
+// totalAmountReconciler flags executions whose quantity * average price differs from
+// their total amount by more than the tolerance
+type totalAmountReconciler struct {
+	tolerance  float64
+	abort      bool
+	mismatched []int
+	excluded   []domain.Execution // the mismatched executions, under the exclude policy
+}
+
+// newTotalAmountReconciler creates a reconciler for the given policy; an empty policy excludes
+func newTotalAmountReconciler(tolerance float64, policy string) (*totalAmountReconciler, error) {
+	switch policy {
+	case "", ReconciliationPolicyExclude:
+		return &totalAmountReconciler{tolerance: tolerance}, nil
+	case ReconciliationPolicyAbort:
+		return &totalAmountReconciler{tolerance: tolerance, abort: true}, nil
+	default:
+		return nil, fmt.Errorf("unsupported reconciliation policy %q, expected %q or %q", policy, ReconciliationPolicyExclude, ReconciliationPolicyAbort)
+	}
+}
+
+// reconciles reports whether an execution's total amount is within tolerance of the
+// quantity it books times its average price; a partial fill books only quantity_filled
+func (r *totalAmountReconciler) reconciles(execution domain.Execution) bool {
+	return math.Abs(transactionQuantity(execution).Mul(execution.AveragePrice).Sub(execution.TotalAmount).Float64()) <= r.tolerance
+}
+
+// filter wraps stream so mismatched executions are recorded and never reach the file.
+// Under the abort policy the wrapped stream still reads the whole window, so every
+// offending ID is reported, then fails with apperrors.ErrReconciliationFailed.
+func (r *totalAmountReconciler) filter(stream ExecutionStream) ExecutionStream {
+	return func(fn func(domain.Execution) error) error {
+		r.mismatched, r.excluded = nil, nil
+		err := stream(func(execution domain.Execution) error {
+			if !r.reconciles(execution) {
+				r.mismatched = append(r.mismatched, execution.ID)
+				if !r.abort {
+					r.excluded = append(r.excluded, execution)
+				}
+				return nil
+			}
+			return fn(execution)
+		})
+		if err != nil {
+			return err
+		}
+		if r.abort && len(r.mismatched) > 0 {
+			return fmt.Errorf("%w: executions %v exceed tolerance %g", apperrors.ErrReconciliationFailed, r.mismatched, r.tolerance)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestTotalAmountReconciler_Reconciles(t *testing.T) {
+	reconciler, err := newTotalAmountReconciler(0.01, ReconciliationPolicyExclude)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		total    float64
+		expected bool
+	}{
+		{name: "exact", total: 1005.0, expected: true},
+		{name: "within tolerance", total: 1005.009, expected: true},
+		{name: "over tolerance", total: 1005.02, expected: false},
+		{name: "under tolerance", total: 1004.98, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execution := domain.Execution{Quantity: domain.DecimalFromFloat(100), AveragePrice: domain.DecimalFromFloat(10.05), TotalAmount: domain.DecimalFromFloat(tt.total)}
+			assert.Equal(t, tt.expected, reconciler.reconciles(execution))
+		})
+	}
+}
+
+func TestTotalAmountReconciler_ReconcilesPartialFill(t *testing.T) {
+	reconciler, err := newTotalAmountReconciler(0.01, ReconciliationPolicyExclude)
+	require.NoError(t, err)
+
+	// A partial fill's total covers the filled quantity, not the ordered one
+	partial := domain.Execution{ExecutionStatus: "PART", Quantity: domain.DecimalFromFloat(100), QuantityFilled: domain.DecimalFromFloat(40), AveragePrice: domain.DecimalFromFloat(10.05), TotalAmount: domain.DecimalFromFloat(402.0)}
+	assert.True(t, reconciler.reconciles(partial))
+
+	partial.TotalAmount = domain.DecimalFromFloat(1005.0)
+	assert.False(t, reconciler.reconciles(partial))
+
+	full := domain.Execution{ExecutionStatus: "FULL", Quantity: domain.DecimalFromFloat(100), QuantityFilled: domain.DecimalFromFloat(40), AveragePrice: domain.DecimalFromFloat(10.05), TotalAmount: domain.DecimalFromFloat(1005.0)}
+	assert.True(t, reconciler.reconciles(full))
+}
+
+func TestTotalAmountReconciler_Filter(t *testing.T) {
+	executions := []domain.Execution{
+		{ID: 1, Quantity: domain.DecimalFromFloat(10), AveragePrice: domain.DecimalFromFloat(2), TotalAmount: domain.DecimalFromFloat(20)},
+		{ID: 2, Quantity: domain.DecimalFromFloat(10), AveragePrice: domain.DecimalFromFloat(2), TotalAmount: domain.DecimalFromFloat(25)},
+		{ID: 3, Quantity: domain.DecimalFromFloat(10), AveragePrice: domain.DecimalFromFloat(2), TotalAmount: domain.DecimalFromFloat(20)},
+		{ID: 4, Quantity: domain.DecimalFromFloat(10), AveragePrice: domain.DecimalFromFloat(2), TotalAmount: domain.DecimalFromFloat(2)},
+	}
+
+	collect := func(stream ExecutionStream) ([]int, error) {
+		var ids []int
+		err := stream(func(execution domain.Execution) error {
+			ids = append(ids, execution.ID)
+			return nil
+		})
+		return ids, err
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		reconciler, err := newTotalAmountReconciler(0.01, ReconciliationPolicyExclude)
+		require.NoError(t, err)
+
+		ids, err := collect(reconciler.filter(SliceExecutionStream(executions)))
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, ids)
+		assert.Equal(t, []int{2, 4}, reconciler.mismatched)
+	})
+
+	t.Run("abort reports every mismatch", func(t *testing.T) {
+		reconciler, err := newTotalAmountReconciler(0.01, ReconciliationPolicyAbort)
+		require.NoError(t, err)
+
+		_, err = collect(reconciler.filter(SliceExecutionStream(executions)))
+
+		assert.ErrorIs(t, err, apperrors.ErrReconciliationFailed)
+		assert.ErrorContains(t, err, "[2 4]")
+		assert.Equal(t, []int{2, 4}, reconciler.mismatched)
+	})
+
+	t.Run("abort with all within tolerance", func(t *testing.T) {
+		reconciler, err := newTotalAmountReconciler(0.01, ReconciliationPolicyAbort)
+		require.NoError(t, err)
+
+		ids, err := collect(reconciler.filter(SliceExecutionStream([]domain.Execution{executions[0], executions[2]})))
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, ids)
+		assert.Empty(t, reconciler.mismatched)
+	})
+}
+
+func TestNewTotalAmountReconciler_InvalidPolicy(t *testing.T) {
+	_, err := newTotalAmountReconciler(0.01, "ignore")
+
+	assert.ErrorContains(t, err, "unsupported reconciliation policy")
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+// SendScheduler runs Send on a cron schedule for deployments without an external
+// scheduler. Runs go through the same advisory lock as the HTTP endpoint, so a
+// scheduled Send never overlaps a manual one anywhere in the cluster.
+type SendScheduler struct {
+	cron   *cron.Cron
+	send   func(ctx context.Context) (*domain.SendResponse, error)
+	logger *zap.Logger
+}
+
+// NewSendScheduler creates a scheduler for a standard five-field cron expression
+func NewSendScheduler(schedule string, executionService *ExecutionService, logger *zap.Logger) (*SendScheduler, error) {
+	return newSendScheduler(schedule, executionService.Send, logger)
+}
+
+func newSendScheduler(schedule string, send func(ctx context.Context) (*domain.SendResponse, error), logger *zap.Logger) (*SendScheduler, error) {
+	s := &SendScheduler{
+		// A run still in progress when the next is due is skipped rather than queued
+		cron:   cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+		send:   send,
+		logger: logger,
+	}
+
+	if _, err := s.cron.AddFunc(schedule, s.run); err != nil {
+		return nil, fmt.Errorf("invalid send schedule %q: %w", schedule, err)
+	}
+
+	return s, nil
+}
+
+// Start begins running Send on the schedule
+func (s *SendScheduler) Start() {
+	s.logger.Info("Starting scheduled Send")
+	s.cron.Start()
+}
+
+// Stop prevents further runs and waits for a running Send to finish or ctx to expire
+func (s *SendScheduler) Stop(ctx context.Context) error {
+	s.logger.Info("Stopping scheduled Send")
+	select {
+	case <-s.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduled send did not finish: %w", ctx.Err())
+	}
+}
+
+// run performs one scheduled Send and logs its outcome
+func (s *SendScheduler) run() {
+	correlationID := observability.GenerateCorrelationID()
+	ctx := observability.WithCorrelationID(context.Background(), correlationID)
+	logger := s.logger.With(zap.String("correlation_id", correlationID))
+
+	logger.Info("Running scheduled Send")
+	response, err := s.send(ctx)
+	switch {
+	case errors.Is(err, apperrors.ErrDuplicateBatch):
+		logger.Info("Scheduled Send skipped, another Send is in progress")
+	case err != nil:
+		logger.Error("Scheduled Send failed", zap.Error(err))
+	default:
+		logger.Info("Scheduled Send completed",
+			zap.String("status", response.Status),
+			zap.Int("processed_count", response.ProcessedCount),
+			zap.String("filename", response.FileName),
+			zap.String("message", response.Message))
+	}
+}
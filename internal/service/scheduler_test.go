@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kasbench/globeco-allocation-service/internal/apperrors"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
+)
+
+func TestNewSendScheduler_InvalidSchedule(t *testing.T) {
+	_, err := newSendScheduler("every tuesday", nil, zap.NewNop())
+
+	assert.ErrorContains(t, err, `invalid send schedule "every tuesday"`)
+}
+
+func TestSendScheduler_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    *domain.SendResponse
+		err         error
+		expectLevel zapcore.Level
+		expectMsg   string
+	}{
+		{
+			name:        "success",
+			response:    &domain.SendResponse{Status: "success", ProcessedCount: 3},
+			expectLevel: zapcore.InfoLevel,
+			expectMsg:   "Scheduled Send completed",
+		},
+		{
+			name:        "manual send holds the lock",
+			err:         fmt.Errorf("%w: another send is in progress", apperrors.ErrDuplicateBatch),
+			expectLevel: zapcore.InfoLevel,
+			expectMsg:   "Scheduled Send skipped, another Send is in progress",
+		},
+		{
+			name:        "failure",
+			err:         errors.New("failed to get max start time"),
+			expectLevel: zapcore.ErrorLevel,
+			expectMsg:   "Scheduled Send failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+			var correlationID string
+			scheduler, err := newSendScheduler("0 18 * * 1-5", func(ctx context.Context) (*domain.SendResponse, error) {
+				correlationID = observability.GetCorrelationID(ctx)
+				return tt.response, tt.err
+			}, zap.New(core))
+			require.NoError(t, err)
+
+			scheduler.run()
+
+			assert.NotEmpty(t, correlationID, "each scheduled run gets its own correlation ID")
+			entries := logs.FilterMessage(tt.expectMsg).All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.expectLevel, entries[0].Level)
+		})
+	}
+}
+
+func TestSendScheduler_StartStop(t *testing.T) {
+	scheduler, err := newSendScheduler("0 18 * * 1-5", func(ctx context.Context) (*domain.SendResponse, error) {
+		t.Fatal("Send should not run before its scheduled time")
+		return nil, nil
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	scheduler.Start()
+	require.Len(t, scheduler.cron.Entries(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, scheduler.Stop(ctx))
+}
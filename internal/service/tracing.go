@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// maxCreateSpanLinks caps the links from a Send span back to the spans that created its
+// executions; a batch built from many small create requests would otherwise carry an
+// unbounded link list
+const maxCreateSpanLinks = 128
+
+// startSpan starts a service span as a child of any span in ctx
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("globeco-allocation-service").Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// recordCreateSpan stores the span in ctx on execution, so the Send that later
+// processes it can link back to the request that created it
+func recordCreateSpan(ctx context.Context, execution *domain.Execution) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	traceID := spanContext.TraceID().String()
+	spanID := spanContext.SpanID().String()
+	execution.CreateTraceID = &traceID
+	execution.CreateSpanID = &spanID
+}
+
+// createSpanLinks collects links to the distinct create spans of streamed executions
+type createSpanLinks struct {
+	seen  map[string]struct{}
+	links []trace.Link
+}
+
+// collect wraps stream so every execution it yields is checked for a create span
+func (c *createSpanLinks) collect(stream ExecutionStream) ExecutionStream {
+	return func(fn func(domain.Execution) error) error {
+		c.seen = make(map[string]struct{})
+		c.links = nil
+		return stream(func(execution domain.Execution) error {
+			c.add(execution)
+			return fn(execution)
+		})
+	}
+}
+
+// add records the execution's create span, if it has a valid one not already seen.
+// Links beyond maxCreateSpanLinks are counted but not kept.
+func (c *createSpanLinks) add(execution domain.Execution) {
+	if execution.CreateTraceID == nil || execution.CreateSpanID == nil {
+		return
+	}
+	key := *execution.CreateTraceID + "-" + *execution.CreateSpanID
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+
+	traceID, err := trace.TraceIDFromHex(*execution.CreateTraceID)
+	if err != nil {
+		return
+	}
+	spanID, err := trace.SpanIDFromHex(*execution.CreateSpanID)
+	if err != nil {
+		return
+	}
+	c.seen[key] = struct{}{}
+	if len(c.links) >= maxCreateSpanLinks {
+		return
+	}
+	c.links = append(c.links, trace.Link{
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  spanID,
+			Remote:  true,
+		}),
+	})
+}
+
+// addTo adds the collected links to span along with the number of distinct create spans
+func (c *createSpanLinks) addTo(span trace.Span) {
+	for _, link := range c.links {
+		span.AddLink(link)
+	}
+	span.SetAttributes(attribute.Int("execution.create_span.count", len(c.seen)))
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// recordSpans installs a global tracer provider that records ended spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// spansByName indexes ended spans by name
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+// attributeValue returns the value of key among attrs
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestExecutionService_Send_Spans(t *testing.T) {
+	recorder := recordSpans(t)
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	start := now.Add(-time.Hour)
+
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now).
+		AddRow(2, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "SELL", 5.0, 1.5, now)
+	expectSendWindow(mock, 7, start, now, rows)
+
+	response, err := svc.Send(context.Background())
+	require.NoError(t, err)
+
+	spans := spansByName(recorder)
+	send := spans["execution.send"]
+	require.NotNil(t, send)
+	assert.Equal(t, int64(7), attributeValue(send.Attributes(), "batch.id").AsInt64())
+
+	for _, name := range []string{"execution.send.window", "execution.send.create_batch", "execution.send.generate_file", "execution.send.invoke_cli"} {
+		phase := spans[name]
+		require.NotNil(t, phase, name)
+		assert.Equal(t, send.SpanContext().SpanID(), phase.Parent().SpanID(), "%s should be a child of the Send span", name)
+	}
+
+	generate := spans["execution.send.generate_file"]
+	assert.Equal(t, int64(2), attributeValue(generate.Attributes(), "execution.count").AsInt64())
+	assert.Equal(t, response.FileName, attributeValue(generate.Attributes(), "file.name").AsString())
+
+	events := spans["execution.send.invoke_cli"].Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "cli.started", events[0].Name)
+	assert.Equal(t, "cli.finished", events[1].Name)
+	assert.Equal(t, int64(0), attributeValue(events[1].Attributes, "cli.exit_code").AsInt64())
+}
+
+func TestExecutionService_Send_SpansRecordCLIFailure(t *testing.T) {
+	recorder := recordSpans(t)
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"false"}})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	start := now.Add(-time.Hour)
+
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now)
+	expectSendLock(mock, true)
+	mock.ExpectQuery(`SELECT MAX\(start_time\) FROM batch_history`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(start))
+	expectReadyTimestampAt(mock, start, now, 0, &start)
+	mock.ExpectQuery(`INSERT INTO batch_history`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectQuery(`SELECT \* FROM execution WHERE ready_to_send_timestamp >= \$1`).WillReturnRows(rows)
+	expectBatchStatusUpdate(mock, 8, domain.BatchStatusFailed)
+	expectSendUnlock(mock)
+
+	_, err := svc.Send(context.Background())
+	require.Error(t, err)
+
+	spans := spansByName(recorder)
+	assert.Equal(t, codes.Error, spans["execution.send"].Status().Code)
+	cli := spans["execution.send.invoke_cli"]
+	require.NotNil(t, cli)
+	assert.Equal(t, codes.Error, cli.Status().Code)
+	var finished *sdktrace.Event
+	for i, event := range cli.Events() {
+		if event.Name == "cli.finished" {
+			finished = &cli.Events()[i]
+		}
+	}
+	require.NotNil(t, finished)
+	assert.Equal(t, int64(1), attributeValue(finished.Attributes, "cli.exit_code").AsInt64())
+}
+
+func TestExecutionService_Send_LinksCreateSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	start := now.Add(-time.Hour)
+
+	const traceID = "0102030405060708090a0b0c0d0e0f10"
+	const spanID = "0102030405060708"
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date", "create_trace_id", "create_span_id"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now, traceID, spanID).
+		AddRow(2, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "SELL", 5.0, 1.5, now, traceID, spanID).
+		AddRow(3, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 1.0, 1.5, now, nil, nil)
+	expectSendWindow(mock, 7, start, now, rows)
+
+	_, err := svc.Send(context.Background())
+	require.NoError(t, err)
+
+	generate := spansByName(recorder)["execution.send.generate_file"]
+	require.NotNil(t, generate)
+	require.Len(t, generate.Links(), 1, "executions from the same create span share one link")
+	link := generate.Links()[0].SpanContext
+	assert.Equal(t, traceID, link.TraceID().String())
+	assert.Equal(t, spanID, link.SpanID().String())
+	assert.Equal(t, int64(1), attributeValue(generate.Attributes(), "execution.create_span.count").AsInt64())
+}
+
+func TestRecordCreateSpan(t *testing.T) {
+	var untraced domain.Execution
+	recordCreateSpan(context.Background(), &untraced)
+	assert.Nil(t, untraced.CreateTraceID)
+	assert.Nil(t, untraced.CreateSpanID)
+
+	recordSpans(t)
+	ctx, span := startSpan(context.Background(), "create")
+	defer span.End()
+
+	var traced domain.Execution
+	recordCreateSpan(ctx, &traced)
+	require.NotNil(t, traced.CreateTraceID)
+	require.NotNil(t, traced.CreateSpanID)
+	assert.Equal(t, span.SpanContext().TraceID().String(), *traced.CreateTraceID)
+	assert.Equal(t, span.SpanContext().SpanID().String(), *traced.CreateSpanID)
+}
+
+func TestCreateSpanLinks_Cap(t *testing.T) {
+	executions := make([]domain.Execution, 0, maxCreateSpanLinks+10)
+	for i := 0; i < maxCreateSpanLinks+10; i++ {
+		traceID := fmt.Sprintf("%032x", i+1)
+		spanID := fmt.Sprintf("%016x", i+1)
+		executions = append(executions, domain.Execution{ID: i, CreateTraceID: &traceID, CreateSpanID: &spanID})
+	}
+	invalid := "not-hex"
+	executions = append(executions, domain.Execution{CreateTraceID: &invalid, CreateSpanID: &invalid})
+
+	var links createSpanLinks
+	count := 0
+	err := links.collect(SliceExecutionStream(executions))(func(domain.Execution) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, len(executions), count, "every execution is still streamed")
+	assert.Len(t, links.links, maxCreateSpanLinks)
+	assert.Len(t, links.seen, maxCreateSpanLinks+10)
+}
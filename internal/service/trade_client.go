@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -17,8 +18,18 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 )
 
+// maxTradeServicePages bounds how many pages are followed for a single lookup
+const maxTradeServicePages = 50
+
+// redactedHeaders are outbound headers whose values are never written to debug logs
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// maxRetryBackoffDoublings caps exponential retry backoff at baseDelay * 2^maxRetryBackoffDoublings
+const maxRetryBackoffDoublings = 10
+
 // TradeServiceClient handles communication with the Trade Service
 type TradeServiceClient struct {
 	baseURL    string
@@ -26,6 +37,18 @@ type TradeServiceClient struct {
 	logger     *zap.Logger
 	maxRetries int
 	baseDelay  time.Duration
+	// retryJitter switches retries from linear backoff to exponential backoff with full
+	// jitter; randInt64N picks the jittered delay and is replaceable in tests
+	retryJitter bool
+	randInt64N  func(n int64) int64
+	// debugLogging logs each request and raw response at debug level
+	debugLogging bool
+	// maxRetryDuration bounds the time spent across all attempts of a call; zero is unbounded
+	maxRetryDuration time.Duration
+	// correlationHeader carries the request's correlation ID to the Trade Service
+	correlationHeader string
+	portfolioCache    *portfolioCache
+	metrics           observability.MetricsRecorder
 }
 
 // NewTradeServiceClient creates a new Trade Service client with OpenTelemetry instrumentation
@@ -42,6 +65,9 @@ func NewTradeServiceClient(baseURL string, logger *zap.Logger) *TradeServiceClie
 		logger:     logger,
 		maxRetries: 3,
 		baseDelay:  1 * time.Second,
+		randInt64N: rand.Int64N,
+
+		correlationHeader: "X-Correlation-ID",
 	}
 }
 
@@ -51,7 +77,105 @@ func (c *TradeServiceClient) SetRetryConfig(maxRetries int, baseDelay time.Durat
 	c.baseDelay = baseDelay
 }
 
-// GetExecutionByServiceID retrieves execution details from Trade Service
+// SetRetryJitter switches retries to exponential backoff with full jitter, so clients
+// that failed together don't retry in lockstep
+func (c *TradeServiceClient) SetRetryJitter(enabled bool) {
+	c.retryJitter = enabled
+}
+
+// SetMaxRetryDuration bounds the total time a call may spend across all of its attempts
+// and the waits between them; zero leaves only the attempt count as a limit
+func (c *TradeServiceClient) SetMaxRetryDuration(maxRetryDuration time.Duration) {
+	c.maxRetryDuration = maxRetryDuration
+}
+
+// SetDebugLogging logs each request's method, URL and headers, with credentials
+// redacted, and each raw response at debug level
+func (c *TradeServiceClient) SetDebugLogging(enabled bool) {
+	c.debugLogging = enabled
+}
+
+// SetCorrelationHeader configures the header used to forward the correlation ID; empty disables it
+func (c *TradeServiceClient) SetCorrelationHeader(header string) {
+	c.correlationHeader = header
+}
+
+// SetPortfolioCache enables caching of resolved portfolio IDs. Not-found results are
+// cached for negativeTTL; a size of zero disables the cache.
+func (c *TradeServiceClient) SetPortfolioCache(size int, ttl, negativeTTL time.Duration) {
+	if size <= 0 {
+		c.portfolioCache = nil
+		return
+	}
+	c.portfolioCache = newPortfolioCache(size, ttl, negativeTTL)
+}
+
+// SetMetrics enables metrics for the client
+func (c *TradeServiceClient) SetMetrics(metrics observability.MetricsRecorder) {
+	c.metrics = metrics
+}
+
+// ResolvePortfolioID returns the portfolio ID for an executionServiceId, consulting the
+// portfolio cache first when one is configured
+func (c *TradeServiceClient) ResolvePortfolioID(ctx context.Context, executionServiceID int) (string, error) {
+	if c.portfolioCache != nil {
+		if entry, ok := c.portfolioCache.get(executionServiceID); ok {
+			if !entry.found {
+				c.recordCacheLookup(ctx, cacheResultNegativeHit)
+				return "", fmt.Errorf("no execution found in trade service for ID %d", executionServiceID)
+			}
+			c.recordCacheLookup(ctx, cacheResultHit)
+			return entry.portfolioID, nil
+		}
+		c.recordCacheLookup(ctx, cacheResultMiss)
+	}
+
+	response, err := c.GetExecutionByServiceID(ctx, executionServiceID)
+	if err != nil {
+		return "", fmt.Errorf("trade service call failed: %w", err)
+	}
+
+	if len(response.Executions) == 0 {
+		if c.portfolioCache != nil {
+			c.portfolioCache.set(executionServiceID, "", false)
+		}
+		return "", fmt.Errorf("no execution found in trade service for ID %d", executionServiceID)
+	}
+
+	// An executionServiceId is expected to map to a single execution. Duplicates that
+	// agree on the portfolio are tolerated; conflicting portfolios are an error.
+	portfolioID := ""
+	for _, execution := range response.Executions {
+		id := execution.TradeOrder.Portfolio.PortfolioID
+		if id == "" {
+			return "", fmt.Errorf("portfolio ID is empty for execution service ID %d", executionServiceID)
+		}
+		if portfolioID != "" && id != portfolioID {
+			return "", fmt.Errorf("execution service ID %d maps to multiple portfolios: %s, %s", executionServiceID, portfolioID, id)
+		}
+		portfolioID = id
+	}
+
+	if len(response.Executions) > 1 {
+		c.logger.Warn("Trade Service returned multiple executions for execution service ID",
+			zap.Int("execution_service_id", executionServiceID),
+			zap.Int("count", len(response.Executions)))
+	}
+
+	if c.portfolioCache != nil {
+		c.portfolioCache.set(executionServiceID, portfolioID, true)
+	}
+	return portfolioID, nil
+}
+
+func (c *TradeServiceClient) recordCacheLookup(ctx context.Context, result string) {
+	if c.metrics != nil {
+		c.metrics.RecordTradeServiceCacheLookup(ctx, result)
+	}
+}
+
+// GetExecutionByServiceID retrieves execution details from Trade Service, following
+// pagination so every execution for the service ID is returned in one response
 func (c *TradeServiceClient) GetExecutionByServiceID(ctx context.Context, executionServiceID int) (*domain.TradeServiceExecutionResponse, error) {
 	// Start OpenTelemetry span for this operation
 	tracer := otel.Tracer("globeco-allocation-service")
@@ -74,31 +198,75 @@ func (c *TradeServiceClient) GetExecutionByServiceID(ctx context.Context, execut
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
 
-	query := u.Query()
-	query.Set("executionServiceId", strconv.Itoa(executionServiceID))
-	u.RawQuery = query.Encode()
-
-	span.SetAttributes(attribute.String("http.url", u.String()))
-
 	c.logger.Info("Calling Trade Service with OpenTelemetry tracing",
 		zap.String("url", u.String()),
 		zap.Int("execution_service_id", executionServiceID),
 		zap.String("trace_id", span.SpanContext().TraceID().String()),
 		zap.String("span_id", span.SpanContext().SpanID().String()))
 
-	// Execute request with retry logic
-	response, err := c.executeWithRetry(ctx, "GET", u.String(), nil)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "trade service call failed")
-		return nil, fmt.Errorf("failed to call Trade Service: %w", err)
+	// Follow pages until the Trade Service reports no more, using the count
+	// fetched so far as the next offset
+	result := &domain.TradeServiceExecutionResponse{}
+	pages := 0
+	for {
+		query := url.Values{}
+		query.Set("executionServiceId", strconv.Itoa(executionServiceID))
+		if pages > 0 {
+			query.Set("offset", strconv.Itoa(len(result.Executions)))
+			if result.Pagination.PageSize > 0 {
+				query.Set("limit", strconv.Itoa(result.Pagination.PageSize))
+			}
+		}
+		u.RawQuery = query.Encode()
+		span.SetAttributes(attribute.String("http.url", u.String()))
+
+		// Execute request with retry logic
+		response, err := c.executeWithRetry(ctx, "GET", u.String(), nil)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "trade service call failed")
+			return nil, fmt.Errorf("failed to call Trade Service: %w", err)
+		}
+		pages++
+
+		result.Executions = append(result.Executions, response.Executions...)
+		result.Pagination = response.Pagination
+
+		if !response.Pagination.HasNext || len(response.Executions) == 0 {
+			break
+		}
+		if pages >= maxTradeServicePages {
+			c.recordPages(ctx, executionServiceID, pages)
+			err := fmt.Errorf("trade service returned more than %d pages for execution service ID %d", maxTradeServicePages, executionServiceID)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "too many pages")
+			return nil, err
+		}
 	}
 
+	c.recordPages(ctx, executionServiceID, pages)
+
 	// Add success attributes
-	span.SetAttributes(attribute.Int("response.executions_count", len(response.Executions)))
+	span.SetAttributes(
+		attribute.Int("response.executions_count", len(result.Executions)),
+		attribute.Int("response.pages", pages),
+	)
 	span.SetStatus(codes.Ok, "trade service call successful")
 
-	return response, nil
+	return result, nil
+}
+
+// recordPages records the number of pages a lookup fetched, warning when the Trade
+// Service paginated a response that normally fits on one page
+func (c *TradeServiceClient) recordPages(ctx context.Context, executionServiceID, pages int) {
+	if c.metrics != nil {
+		c.metrics.RecordTradeServicePages(ctx, pages)
+	}
+	if pages > 1 {
+		c.logger.Warn("Trade Service lookup spanned multiple pages",
+			zap.Int("execution_service_id", executionServiceID),
+			zap.Int("pages", pages))
+	}
 }
 
 // executeWithRetry performs HTTP request with exponential backoff retry
@@ -106,21 +274,35 @@ func (c *TradeServiceClient) executeWithRetry(ctx context.Context, method, url s
 	var lastErr error
 	startTime := time.Now()
 
+	// Attempts run under the retry budget, if any, so a slow attempt is cut short too
+	attemptCtx := ctx
+	if c.maxRetryDuration > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.maxRetryDuration)
+		defer cancel()
+	}
+
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
-			delay := time.Duration(attempt) * c.baseDelay
+			delay := c.retryDelay(attempt)
+			if c.maxRetryDuration > 0 && time.Since(startTime)+delay >= c.maxRetryDuration {
+				return nil, c.retryBudgetExhausted(method, attempt, startTime, lastErr)
+			}
 			c.logger.Info("Retrying Trade Service call with OpenTelemetry metrics",
 				zap.Int("attempt", attempt),
 				zap.Duration("delay", delay))
 
 			select {
 			case <-time.After(delay):
-			case <-ctx.Done():
-				return nil, ctx.Err()
+			case <-attemptCtx.Done():
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, c.retryBudgetExhausted(method, attempt, startTime, lastErr)
 			}
 		}
 
-		response, err := c.executeRequest(ctx, method, url, body)
+		response, err := c.executeRequest(attemptCtx, method, url, body)
 		if err == nil {
 			// Record successful call metrics
 			duration := time.Since(startTime)
@@ -131,6 +313,14 @@ func (c *TradeServiceClient) executeWithRetry(ctx context.Context, method, url s
 			return response, nil
 		}
 
+		if ctx.Err() == nil && attemptCtx.Err() != nil {
+			// The budget cut this attempt short; an earlier failure says more than the deadline
+			if lastErr == nil {
+				lastErr = err
+			}
+			return nil, c.retryBudgetExhausted(method, attempt+1, startTime, lastErr)
+		}
+
 		lastErr = err
 		c.logger.Warn("Trade Service call failed - retry metrics sent to OpenTelemetry collector",
 			zap.Int("attempt", attempt),
@@ -153,6 +343,34 @@ func (c *TradeServiceClient) executeWithRetry(ctx context.Context, method, url s
 	return nil, fmt.Errorf("all retry attempts failed: %w", lastErr)
 }
 
+// retryBudgetExhausted logs and returns the error for a call that ran out of its retry
+// budget after the given number of attempts
+func (c *TradeServiceClient) retryBudgetExhausted(method string, attempts int, startTime time.Time, lastErr error) error {
+	duration := time.Since(startTime)
+	c.logger.Error("Trade Service retry budget exhausted",
+		zap.String("method", method),
+		zap.Duration("total_duration", duration),
+		zap.Duration("max_retry_duration", c.maxRetryDuration),
+		zap.Int("total_attempts", attempts),
+		zap.Error(lastErr))
+
+	return fmt.Errorf("retry budget exhausted after %d attempts in %s: %w", attempts, duration.Round(time.Millisecond), lastErr)
+}
+
+// retryDelay returns how long to wait before the given retry attempt (1-based): attempt
+// times baseDelay, or with jitter a random delay up to baseDelay * 2^(attempt-1)
+func (c *TradeServiceClient) retryDelay(attempt int) time.Duration {
+	if !c.retryJitter {
+		return time.Duration(attempt) * c.baseDelay
+	}
+
+	backoff := c.baseDelay << min(attempt-1, maxRetryBackoffDoublings)
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(c.randInt64N(int64(backoff) + 1))
+}
+
 // executeRequest performs a single HTTP request
 func (c *TradeServiceClient) executeRequest(ctx context.Context, method, url string, body io.Reader) (*domain.TradeServiceExecutionResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
@@ -162,6 +380,15 @@ func (c *TradeServiceClient) executeRequest(ctx context.Context, method, url str
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if correlationID := observability.GetCorrelationID(ctx); correlationID != "" && c.correlationHeader != "" {
+		req.Header.Set(c.correlationHeader, correlationID)
+	}
+	if c.debugLogging {
+		c.logger.Debug("Trade Service request",
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.Redacted()),
+			zap.Any("headers", redactHeaders(req.Header)))
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -178,6 +405,14 @@ func (c *TradeServiceClient) executeRequest(ctx context.Context, method, url str
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if c.debugLogging {
+		c.logger.Debug("Trade Service response",
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.Redacted()),
+			zap.Int("status", resp.StatusCode),
+			zap.Any("headers", redactHeaders(resp.Header)),
+			zap.String("body", string(respBody)))
+	}
 
 	// Check for HTTP errors
 	if resp.StatusCode >= 400 {
@@ -191,7 +426,7 @@ func (c *TradeServiceClient) executeRequest(ctx context.Context, method, url str
 	var response domain.TradeServiceExecutionResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		c.logger.Error("Failed to parse Trade Service response",
-			zap.String("response_body", string(respBody)),
+			zap.Int("response_bytes", len(respBody)),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
@@ -202,6 +437,17 @@ func (c *TradeServiceClient) executeRequest(ctx context.Context, method, url str
 	return &response, nil
 }
 
+// redactHeaders returns a copy of header with credential values replaced
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "[REDACTED]")
+		}
+	}
+	return redacted
+}
+
 // HTTPError represents an HTTP error response
 type HTTPError struct {
 	StatusCode int
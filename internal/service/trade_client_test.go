@@ -3,15 +3,24 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/kasbench/globeco-allocation-service/internal/domain"
+	"github.com/kasbench/globeco-allocation-service/internal/observability"
 )
 
 func TestTradeServiceClient_GetExecutionByServiceID(t *testing.T) {
@@ -283,3 +292,264 @@ func TestTradeServiceClient_GetExecutionByServiceID_RetryExhausted(t *testing.T)
 	// Should have been called 4 times (initial + 3 retries)
 	assert.Equal(t, 4, httpmock.GetTotalCallCount())
 }
+
+func TestTradeServiceClient_GetExecutionByServiceID_RetryBudgetExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewTradeServiceClient(server.URL, zap.NewNop())
+	client.SetRetryConfig(100, 20*time.Millisecond)
+	client.SetMaxRetryDuration(300 * time.Millisecond)
+
+	start := time.Now()
+	response, err := client.GetExecutionByServiceID(context.Background(), 123)
+	elapsed := time.Since(start)
+
+	assert.Nil(t, response)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry budget exhausted")
+	assert.Contains(t, err.Error(), "500")
+	assert.Less(t, elapsed, 600*time.Millisecond, "the budget, not the 100 attempts, ends the call")
+}
+
+func TestTradeServiceClient_ForwardsCorrelationID(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tradeServiceURL := "http://globeco-trade-service:8082"
+	client := NewTradeServiceClient(tradeServiceURL, zap.NewNop())
+	client.SetCorrelationHeader("X-Request-Correlation")
+
+	var receivedHeader string
+	httpmock.RegisterResponder("GET", tradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			receivedHeader = req.Header.Get("X-Request-Correlation")
+			return httpmock.NewStringResponse(200, `{"executions":[]}`), nil
+		})
+
+	ctx := observability.WithCorrelationID(context.Background(), "corr-123")
+	_, err := client.GetExecutionByServiceID(ctx, 123)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "corr-123", receivedHeader)
+}
+
+func tradeServiceExecutionWithPortfolio(id int, portfolioID string) domain.TradeServiceExecution {
+	return domain.TradeServiceExecution{
+		ID: id,
+		TradeOrder: domain.TradeServiceTradeOrder{
+			Portfolio: domain.TradeServicePortfolio{PortfolioID: portfolioID},
+		},
+	}
+}
+
+func TestTradeServiceClient_GetExecutionByServiceID_SinglePage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tradeServiceURL := "http://globeco-trade-service:8082"
+	client := NewTradeServiceClient(tradeServiceURL, zap.NewNop())
+
+	httpmock.RegisterResponder("GET", tradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := json.Marshal(domain.TradeServiceExecutionResponse{
+				Executions: []domain.TradeServiceExecution{tradeServiceExecutionWithPortfolio(1, "PORTFOLIO123456789012")},
+				Pagination: domain.PaginationInfo{TotalElements: 1, TotalPages: 1, PageSize: 10},
+			})
+			return httpmock.NewStringResponse(200, string(body)), nil
+		})
+
+	response, err := client.GetExecutionByServiceID(context.Background(), 123)
+
+	assert.NoError(t, err)
+	assert.Len(t, response.Executions, 1)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestTradeServiceClient_GetExecutionByServiceID_MultiPage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tradeServiceURL := "http://globeco-trade-service:8082"
+	core, logs := observer.New(zapcore.WarnLevel)
+	client := NewTradeServiceClient(tradeServiceURL, zap.New(core))
+	metrics := &observability.BusinessMetrics{
+		TradeServicePages: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "test_trade_service_pages_per_lookup",
+			Buckets: []float64{1, 2, 3},
+		}),
+	}
+	client.SetMetrics(observability.NewPrometheusRecorder(metrics))
+
+	var offsets []string
+	httpmock.RegisterResponder("GET", tradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			offset := req.URL.Query().Get("offset")
+			offsets = append(offsets, offset)
+
+			page := domain.TradeServiceExecutionResponse{
+				Pagination: domain.PaginationInfo{TotalElements: 3, TotalPages: 2, PageSize: 2},
+			}
+			if offset == "" {
+				page.Executions = []domain.TradeServiceExecution{
+					tradeServiceExecutionWithPortfolio(1, "PORTFOLIO123456789012"),
+					tradeServiceExecutionWithPortfolio(2, "PORTFOLIO123456789012"),
+				}
+				page.Pagination.HasNext = true
+			} else {
+				page.Executions = []domain.TradeServiceExecution{tradeServiceExecutionWithPortfolio(3, "PORTFOLIO123456789012")}
+				page.Pagination.CurrentPage = 1
+				page.Pagination.HasPrevious = true
+			}
+			body, _ := json.Marshal(page)
+			return httpmock.NewStringResponse(200, string(body)), nil
+		})
+
+	response, err := client.GetExecutionByServiceID(context.Background(), 123)
+
+	assert.NoError(t, err)
+	assert.Len(t, response.Executions, 3)
+	assert.Equal(t, []string{"", "2"}, offsets)
+	assert.Equal(t, 3, response.Executions[2].ID)
+
+	var histogram dto.Metric
+	require.NoError(t, metrics.TradeServicePages.Write(&histogram))
+	assert.Equal(t, uint64(1), histogram.GetHistogram().GetSampleCount())
+	assert.Equal(t, 2.0, histogram.GetHistogram().GetSampleSum())
+	warnings := logs.FilterMessage("Trade Service lookup spanned multiple pages").All()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, int64(2), warnings[0].ContextMap()["pages"])
+}
+
+func TestTradeServiceClient_ResolvePortfolioID_Cached(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tradeServiceURL := "http://globeco-trade-service:8082"
+	client := NewTradeServiceClient(tradeServiceURL, zap.NewNop())
+	client.SetRetryConfig(0, 0)
+	client.SetPortfolioCache(100, time.Minute, time.Minute)
+
+	httpmock.RegisterResponder("GET", tradeServiceURL+"/api/v2/executions",
+		func(req *http.Request) (*http.Response, error) {
+			page := domain.TradeServiceExecutionResponse{}
+			if req.URL.Query().Get("executionServiceId") == "1" {
+				page.Executions = []domain.TradeServiceExecution{tradeServiceExecutionWithPortfolio(1, "PORTFOLIO123456789012")}
+			}
+			body, _ := json.Marshal(page)
+			return httpmock.NewStringResponse(200, string(body)), nil
+		})
+
+	for i := 0; i < 3; i++ {
+		portfolioID, err := client.ResolvePortfolioID(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "PORTFOLIO123456789012", portfolioID)
+
+		_, err = client.ResolvePortfolioID(context.Background(), 2)
+		assert.ErrorContains(t, err, "no execution found")
+	}
+
+	// One call per ID; repeats, including the known-missing ID, are served from the cache
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestTradeServiceClient_RetryDelay(t *testing.T) {
+	client := NewTradeServiceClient("http://globeco-trade-service:8082", zap.NewNop())
+	client.SetRetryConfig(5, 100*time.Millisecond)
+
+	t.Run("linear without jitter", func(t *testing.T) {
+		for attempt := 1; attempt <= 5; attempt++ {
+			assert.Equal(t, time.Duration(attempt)*100*time.Millisecond, client.retryDelay(attempt))
+		}
+	})
+
+	t.Run("exponential ceiling with jitter", func(t *testing.T) {
+		client.SetRetryJitter(true)
+		defer client.SetRetryJitter(false)
+		// Always pick the largest delay, exposing the backoff ceiling
+		client.randInt64N = func(n int64) int64 { return n - 1 }
+		defer func() { client.randInt64N = rand.Int64N }()
+
+		assert.Equal(t, 100*time.Millisecond, client.retryDelay(1))
+		assert.Equal(t, 200*time.Millisecond, client.retryDelay(2))
+		assert.Equal(t, 400*time.Millisecond, client.retryDelay(3))
+		assert.Equal(t, 800*time.Millisecond, client.retryDelay(4))
+		assert.Equal(t, 100*time.Millisecond<<maxRetryBackoffDoublings, client.retryDelay(50))
+	})
+
+	t.Run("full jitter spreads delays", func(t *testing.T) {
+		client.SetRetryJitter(true)
+		defer client.SetRetryJitter(false)
+		client.randInt64N = rand.New(rand.NewPCG(1, 2)).Int64N
+		defer func() { client.randInt64N = rand.Int64N }()
+
+		const samples = 1000
+		ceiling := 400 * time.Millisecond
+		distinct := make(map[time.Duration]struct{})
+		var total time.Duration
+		for i := 0; i < samples; i++ {
+			delay := client.retryDelay(3)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling)
+			distinct[delay] = struct{}{}
+			total += delay
+		}
+
+		// The deterministic backoff would give 300ms every time
+		assert.Greater(t, len(distinct), samples/2)
+		mean := total / samples
+		assert.InDelta(t, float64(ceiling/2), float64(mean), float64(ceiling/10))
+	})
+}
+
+func TestTradeServiceClient_DebugLogging(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", "http://globeco-trade-service:8082/api/v2/executions",
+				httpmock.NewStringResponder(200, `{"executions":[]}`))
+
+			core, logs := observer.New(zapcore.DebugLevel)
+			client := NewTradeServiceClient("http://globeco-trade-service:8082", zap.New(core))
+			client.SetDebugLogging(enabled)
+
+			ctx := observability.WithCorrelationID(context.Background(), "corr-1")
+			_, err := client.GetExecutionByServiceID(ctx, 123)
+			require.NoError(t, err)
+
+			requests := logs.FilterMessage("Trade Service request").All()
+			responses := logs.FilterMessage("Trade Service response").All()
+			if !enabled {
+				assert.Empty(t, requests)
+				assert.Empty(t, responses)
+				return
+			}
+			require.Len(t, requests, 1)
+			require.Len(t, responses, 1)
+			fields := requests[0].ContextMap()
+			assert.Equal(t, "GET", fields["method"])
+			assert.Contains(t, fields["url"], "executionServiceId=123")
+			assert.Equal(t, []string{"corr-1"}, fields["headers"].(http.Header).Values("X-Correlation-ID"))
+			assert.Equal(t, `{"executions":[]}`, responses[0].ContextMap()["body"])
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Accept", "application/json")
+
+	redacted := redactHeaders(header)
+
+	assert.Equal(t, "[REDACTED]", redacted.Get("Authorization"))
+	assert.Equal(t, "application/json", redacted.Get("Accept"))
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"), "the request's own headers are untouched")
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// validateExecution runs the checks every posted execution must pass before it is
+// created; ValidateBatch reports the same checks without creating anything
+func (s *ExecutionService) validateExecution(executionDTO domain.ExecutionPostDTO) error {
+	return s.validator.Struct(executionDTO)
+}
+
+// ruleTotalAmountMismatch reports an execution rejected by the reconcile total amount policy
+const ruleTotalAmountMismatch = "total_amount_mismatch"
+
+// checkPolicies applies the configured zero quantity filled and total amount policies to
+// an execution that passed validateExecution, so CreateBatch and ValidateBatch agree. A
+// policy that turns the execution away returns a field error, along with a skip reason
+// when the execution is skipped rather than rejected.
+func (s *ExecutionService) checkPolicies(executionDTO domain.ExecutionPostDTO) (skipReason string, fieldErr *domain.FieldError) {
+	if fillStatuses[executionDTO.ExecutionStatus] && executionDTO.QuantityFilled.IsZero() &&
+		s.config.ZeroQuantityFilledPolicy != ZeroQuantityFilledPolicyPass {
+		fieldErr = &domain.FieldError{
+			Field:   "quantityFilled",
+			Rule:    domain.SkipReasonZeroQuantityFilled,
+			Message: fmt.Sprintf("execution status %s reports a fill but quantityFilled is 0", executionDTO.ExecutionStatus),
+		}
+		if s.config.ZeroQuantityFilledPolicy == ZeroQuantityFilledPolicySkip {
+			return domain.SkipReasonZeroQuantityFilled, fieldErr
+		}
+		return "", fieldErr
+	}
+
+	if s.config.TotalAmountPolicy == TotalAmountPolicyReconcile {
+		execution := domain.Execution{
+			ExecutionStatus: executionDTO.ExecutionStatus,
+			Quantity:        executionDTO.Quantity,
+			QuantityFilled:  executionDTO.QuantityFilled,
+			TotalAmount:     executionDTO.TotalAmount,
+			AveragePrice:    executionDTO.AveragePrice,
+		}
+		if err := applyTotalAmountPolicy(&execution, TotalAmountPolicyReconcile, s.config.ReconciliationTolerance); err != nil {
+			return "", &domain.FieldError{Field: "totalAmount", Rule: ruleTotalAmountMismatch, Message: err.Error()}
+		}
+	}
+
+	return "", nil
+}
+
+// duplicatesInBatch reports, for each execution, whether an earlier one in the batch has
+// the same executionServiceId; only the first occurrence is processed
+func duplicatesInBatch(executions []domain.ExecutionPostDTO) []bool {
+	duplicates := make([]bool, len(executions))
+	seen := make(map[int]struct{}, len(executions))
+	for i, executionDTO := range executions {
+		if _, ok := seen[executionDTO.ExecutionServiceID]; ok {
+			duplicates[i] = true
+			continue
+		}
+		seen[executionDTO.ExecutionServiceID] = struct{}{}
+	}
+	return duplicates
+}
+
+// executionStatusValidation rejects an execution whose status is not one of allowed,
+// reporting it as a oneof failure so it reads like the other enumerated fields
+func executionStatusValidation(allowed []string) validator.StructLevelFunc {
+	statuses := make(map[string]struct{}, len(allowed))
+	for _, status := range allowed {
+		statuses[status] = struct{}{}
+	}
+	param := strings.Join(allowed, " ")
+
+	return func(sl validator.StructLevel) {
+		executionDTO := sl.Current().Interface().(domain.ExecutionPostDTO)
+		// An empty status already fails required
+		if executionDTO.ExecutionStatus == "" {
+			return
+		}
+		if _, ok := statuses[executionDTO.ExecutionStatus]; !ok {
+			sl.ReportError(executionDTO.ExecutionStatus, "ExecutionStatus", "ExecutionStatus", "oneof", param)
+		}
+	}
+}
+
+// ValidateBatch checks executions the way CreateBatch does, including duplicates in the
+// batch and the configured policies, without touching the database or the Trade Service
+func (s *ExecutionService) ValidateBatch(executions []domain.ExecutionPostDTO) *domain.ValidateExecutionsResponse {
+	response := &domain.ValidateExecutionsResponse{
+		Results: make([]domain.ExecutionValidationResult, len(executions)),
+	}
+
+	duplicates := duplicatesInBatch(executions)
+	for i, executionDTO := range executions {
+		result := domain.ExecutionValidationResult{
+			Index:              i,
+			ExecutionServiceID: executionDTO.ExecutionServiceID,
+			Valid:              true,
+		}
+		if duplicates[i] {
+			result.SkipReason = domain.SkipReasonDuplicateInBatch
+		} else if err := s.validateExecution(executionDTO); err != nil {
+			result.Valid = false
+			result.Errors = fieldErrors(err)
+		} else if skipReason, fieldErr := s.checkPolicies(executionDTO); skipReason != "" {
+			result.SkipReason = skipReason
+		} else if fieldErr != nil {
+			result.Valid = false
+			result.Errors = []domain.FieldError{*fieldErr}
+		}
+
+		if result.Valid {
+			response.ValidCount++
+		} else {
+			response.InvalidCount++
+		}
+		response.Results[i] = result
+	}
+
+	return response
+}
+
+// fieldErrors converts a validation error into machine-readable field errors named
+// by their JSON fields
+func fieldErrors(err error) []domain.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return []domain.FieldError{{Rule: "invalid", Message: err.Error()}}
+	}
+
+	result := make([]domain.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		field := jsonFieldName(fe.StructField())
+		result = append(result, domain.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldErrorMessage(field, fe),
+		})
+	}
+	return result
+}
+
+// jsonFieldName returns the JSON name of an ExecutionPostDTO field
+func jsonFieldName(structField string) string {
+	field, ok := reflect.TypeOf(domain.ExecutionPostDTO{}).FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return structField
+	}
+	return name
+}
+
+// fieldErrorMessage describes a failed rule in words
+func fieldErrorMessage(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(fe.Param()), ", "))
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	default:
+		return fmt.Sprintf("%s failed the %s validation", field, fe.Tag())
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+func TestExecutionService_ValidateBatch(t *testing.T) {
+	// No queries or Trade Service calls are expected
+	svc, mock := newTestExecutionService(t, &config.Config{})
+
+	invalid := validExecutionDTO(2)
+	invalid.TradeType = "HOLD"
+	invalid.Quantity = domain.DecimalFromFloat(0)
+	invalid.Ticker = ""
+
+	malformedPortfolio := validExecutionDTO(3)
+	portfolioID := "not-a-portfolio"
+	malformedPortfolio.PortfolioID = &portfolioID
+
+	response := svc.ValidateBatch([]domain.ExecutionPostDTO{validExecutionDTO(1), invalid, malformedPortfolio})
+
+	assert.Equal(t, 1, response.ValidCount)
+	assert.Equal(t, 2, response.InvalidCount)
+	require.Len(t, response.Results, 3)
+
+	assert.Equal(t, domain.ExecutionValidationResult{Index: 0, ExecutionServiceID: 1, Valid: true}, response.Results[0])
+
+	assert.Equal(t, 1, response.Results[1].Index)
+	assert.Equal(t, 2, response.Results[1].ExecutionServiceID)
+	assert.False(t, response.Results[1].Valid)
+	assert.ElementsMatch(t, []domain.FieldError{
+		{Field: "tradeType", Rule: "oneof", Param: "BUY SELL", Message: "tradeType must be one of: BUY, SELL"},
+		{Field: "ticker", Rule: "required", Message: "ticker is required"},
+		{Field: "quantity", Rule: "required", Message: "quantity is required"},
+	}, response.Results[1].Errors)
+
+	require.Len(t, response.Results[2].Errors, 1)
+	assert.Equal(t, "portfolioId", response.Results[2].Errors[0].Field)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_ValidateBatch_ExecutionStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowed   []string
+		status    string
+		wantValid bool
+	}{
+		{"built-in status", nil, "FILLED", true},
+		{"built-in partial", nil, "PARTIAL", true},
+		{"unknown status", nil, "DONE", false},
+		{"case matters", nil, "filled", false},
+		{"configured status", []string{"FILLED", "DONE"}, "DONE", true},
+		{"status outside configured set", []string{"FILLED", "DONE"}, "CANCELLED", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestExecutionService(t, &config.Config{AllowedExecutionStatuses: tt.allowed})
+
+			dto := validExecutionDTO(1)
+			dto.ExecutionStatus = tt.status
+			response := svc.ValidateBatch([]domain.ExecutionPostDTO{dto})
+
+			require.Len(t, response.Results, 1)
+			assert.Equal(t, tt.wantValid, response.Results[0].Valid)
+			if !tt.wantValid {
+				require.Len(t, response.Results[0].Errors, 1)
+				fieldErr := response.Results[0].Errors[0]
+				assert.Equal(t, "executionStatus", fieldErr.Field)
+				assert.Equal(t, "oneof", fieldErr.Rule)
+				assert.Contains(t, fieldErr.Message, "executionStatus must be one of: ")
+			}
+		})
+	}
+}
+
+func TestExecutionService_ValidateBatch_MissingExecutionStatus(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	dto := validExecutionDTO(1)
+	dto.ExecutionStatus = ""
+	response := svc.ValidateBatch([]domain.ExecutionPostDTO{dto})
+
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "executionStatus", Rule: "required", Message: "executionStatus is required"},
+	}, response.Results[0].Errors)
+}
+
+func TestExecutionService_ValidateBatch_MatchesCreate(t *testing.T) {
+	// The same duplicate, zero quantity filled and total amount checks as CreateBatch
+	svc, mock := newTestExecutionService(t, &config.Config{
+		ZeroQuantityFilledPolicy: ZeroQuantityFilledPolicySkip,
+		TotalAmountPolicy:        TotalAmountPolicyReconcile,
+		ReconciliationTolerance:  0.01,
+	})
+
+	zeroFill := validExecutionDTO(2)
+	zeroFill.QuantityFilled = domain.DecimalFromFloat(0)
+	mismatch := validExecutionDTO(3)
+	mismatch.TotalAmount = domain.DecimalFromFloat(14000)
+
+	response := svc.ValidateBatch([]domain.ExecutionPostDTO{validExecutionDTO(1), validExecutionDTO(1), zeroFill, mismatch})
+
+	assert.Equal(t, 3, response.ValidCount)
+	assert.Equal(t, 1, response.InvalidCount)
+	require.Len(t, response.Results, 4)
+	assert.Equal(t, domain.ExecutionValidationResult{Index: 0, ExecutionServiceID: 1, Valid: true}, response.Results[0])
+	assert.Equal(t, domain.ExecutionValidationResult{Index: 1, ExecutionServiceID: 1, Valid: true, SkipReason: domain.SkipReasonDuplicateInBatch}, response.Results[1])
+	assert.True(t, response.Results[2].Valid)
+	assert.Equal(t, domain.SkipReasonZeroQuantityFilled, response.Results[2].SkipReason)
+
+	assert.False(t, response.Results[3].Valid)
+	require.Len(t, response.Results[3].Errors, 1)
+	assert.Equal(t, "totalAmount", response.Results[3].Errors[0].Field)
+	assert.Equal(t, ruleTotalAmountMismatch, response.Results[3].Errors[0].Rule)
+	assert.Contains(t, response.Results[3].Errors[0].Message, "total amount 14000")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_ValidateBatch_ZeroQuantityFilledRejected(t *testing.T) {
+	svc, _ := newTestExecutionService(t, &config.Config{})
+
+	zeroFill := validExecutionDTO(1)
+	zeroFill.QuantityFilled = domain.DecimalFromFloat(0)
+	response := svc.ValidateBatch([]domain.ExecutionPostDTO{zeroFill})
+
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, []domain.FieldError{{
+		Field:   "quantityFilled",
+		Rule:    domain.SkipReasonZeroQuantityFilled,
+		Message: "execution status FILLED reports a fill but quantityFilled is 0",
+	}}, response.Results[0].Errors)
+}
+
+func TestFieldErrors_NonValidationError(t *testing.T) {
+	assert.Equal(t,
+		[]domain.FieldError{{Rule: "invalid", Message: "boom"}},
+		fieldErrors(errors.New("boom")))
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+// Webhook delivery is attempted this many times, waiting webhookRetryDelay * attempt between tries
+const (
+	webhookMaxAttempts = 3
+	webhookRetryDelay  = 500 * time.Millisecond
+)
+
+// WebhookNotifier posts Send completion events to an external callback URL
+type WebhookNotifier struct {
+	url         string
+	httpClient  *http.Client
+	logger      *zap.Logger
+	maxAttempts int
+	retryDelay  time.Duration
+	pending     sync.WaitGroup // deliveries started by NotifyAsync
+}
+
+// NewWebhookNotifier creates a notifier for url; timeout bounds each delivery attempt
+func NewWebhookNotifier(url string, timeout time.Duration, logger *zap.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger:      logger,
+		maxAttempts: webhookMaxAttempts,
+		retryDelay:  webhookRetryDelay,
+	}
+}
+
+// NotifyAsync delivers event in the background, so a slow callback never holds up the
+// Send. Delivery, retries included, is bounded by deliveryTimeout; failures are logged.
+func (n *WebhookNotifier) NotifyAsync(ctx context.Context, event domain.SendCompletionEvent) {
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), n.deliveryTimeout())
+		defer cancel()
+		if err := n.Notify(ctx, event); err != nil {
+			n.logger.Error("Failed to deliver send completion webhook", zap.Int("batch_id", event.BatchID), zap.Error(err))
+		}
+	}()
+}
+
+// Stop waits for background deliveries to finish or ctx to expire
+func (n *WebhookNotifier) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("send completion webhooks were not delivered: %w", ctx.Err())
+	}
+}
+
+// deliveryTimeout is the longest every attempt and the waits between them may take
+func (n *WebhookNotifier) deliveryTimeout() time.Duration {
+	timeout := time.Duration(n.maxAttempts) * n.httpClient.Timeout
+	for attempt := 1; attempt < n.maxAttempts; attempt++ {
+		timeout += time.Duration(attempt) * n.retryDelay
+	}
+	return timeout
+}
+
+// Notify delivers event, retrying failed attempts; 4xx responses are not retried
+func (n *WebhookNotifier) Notify(ctx context.Context, event domain.SendCompletionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < n.maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * n.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		lastErr = n.post(ctx, body)
+		if lastErr == nil {
+			n.logger.Info("Send completion webhook delivered",
+				zap.Int("batch_id", event.BatchID),
+				zap.Int("attempts", attempt+1))
+			return nil
+		}
+
+		n.logger.Warn("Send completion webhook attempt failed",
+			zap.Int("batch_id", event.BatchID),
+			zap.Int("attempt", attempt+1),
+			zap.Error(lastErr))
+
+		if httpErr, ok := lastErr.(*HTTPError); ok && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
+			break
+		}
+	}
+
+	return fmt.Errorf("webhook delivery failed: %w", lastErr)
+}
+
+// post performs a single delivery attempt
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			n.logger.Error("failed to close response body", zap.Error(err))
+		}
+	}()
+
+	if resp.StatusCode >= 400 {
+		return &HTTPError{StatusCode: resp.StatusCode, Message: resp.Status}
+	}
+	return nil
+}
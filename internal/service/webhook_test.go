@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/kasbench/globeco-allocation-service/internal/config"
+	"github.com/kasbench/globeco-allocation-service/internal/domain"
+)
+
+const testWebhookURL = "http://orchestrator:8080/hooks/send-complete"
+
+func newTestWebhookNotifier() *WebhookNotifier {
+	notifier := NewWebhookNotifier(testWebhookURL, time.Second, zap.NewNop())
+	notifier.retryDelay = 0
+	return notifier
+}
+
+func TestWebhookNotifier_Notify_Payload(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body string
+	httpmock.RegisterResponder("POST", testWebhookURL,
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+			raw, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			body = string(raw)
+			return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+		})
+
+	err := newTestWebhookNotifier().Notify(context.Background(), domain.SendCompletionEvent{
+		BatchID:        7,
+		Status:         "error",
+		ProcessedCount: 12,
+		FileName:       "transactions_20240115_120000.csv",
+		Error:          "CLI invocation failed: exit status 3",
+	})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"batchId": 7,
+		"status": "error",
+		"processedCount": 12,
+		"fileName": "transactions_20240115_120000.csv",
+		"error": "CLI invocation failed: exit status 3"
+	}`, body)
+}
+
+func TestWebhookNotifier_Notify_Retries(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", testWebhookURL,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+			httpmock.NewStringResponse(http.StatusOK, ""),
+		}))
+
+	err := newTestWebhookNotifier().Notify(context.Background(), domain.SendCompletionEvent{BatchID: 1, Status: "success"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestWebhookNotifier_Notify_ClientErrorNotRetried(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", testWebhookURL, httpmock.NewStringResponder(http.StatusBadRequest, ""))
+
+	err := newTestWebhookNotifier().Notify(context.Background(), domain.SendCompletionEvent{BatchID: 1, Status: "success"})
+
+	assert.ErrorContains(t, err, "webhook delivery failed")
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestExecutionService_Send_WebhookFailureDoesNotFailSend(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var event domain.SendCompletionEvent
+	httpmock.RegisterResponder("POST", testWebhookURL,
+		func(req *http.Request) (*http.Response, error) {
+			raw, _ := io.ReadAll(req.Body)
+			assert.NoError(t, json.Unmarshal(raw, &event))
+			return httpmock.NewStringResponse(http.StatusInternalServerError, ""), nil
+		})
+
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+	notifier := newTestWebhookNotifier()
+	svc.SetCompletionWebhook(notifier)
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now)
+	expectSendWindow(mock, 5, now.Add(-time.Hour), now, rows)
+
+	response, err := svc.Send(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Status)
+	require.NoError(t, notifier.Stop(context.Background()))
+	assert.Equal(t, webhookMaxAttempts, httpmock.GetTotalCallCount())
+	assert.Equal(t, 5, event.BatchID)
+	assert.Equal(t, "success", event.Status)
+	assert.Equal(t, 1, event.ProcessedCount)
+	assert.Equal(t, response.FileName, event.FileName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionService_Send_DoesNotWaitForWebhook(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	release := make(chan struct{})
+	httpmock.RegisterResponder("POST", testWebhookURL,
+		func(req *http.Request) (*http.Response, error) {
+			<-release
+			return httpmock.NewStringResponse(http.StatusOK, ""), nil
+		})
+
+	svc, mock := newTestExecutionService(t, &config.Config{CLICommand: []string{"true"}})
+	notifier := newTestWebhookNotifier()
+	svc.SetCompletionWebhook(notifier)
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	rows := sqlmock.NewRows([]string{"id", "portfolio_id", "security_id", "trade_type", "quantity", "average_price", "trade_date"}).
+		AddRow(1, "PORTFOLIO123456789012", "SECURITY123456789012ABCD", "BUY", 10.0, 1.5, now)
+	expectSendWindow(mock, 5, now.Add(-time.Hour), now, rows)
+
+	// The Send returns while the callback is still hanging
+	response, err := svc.Send(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Status)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, notifier.Stop(stopCtx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, notifier.Stop(context.Background()))
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookNotifier_DeliveryTimeout(t *testing.T) {
+	notifier := NewWebhookNotifier(testWebhookURL, time.Second, zap.NewNop())
+
+	// Three 1s attempts plus the 500ms and 1s waits between them
+	assert.Equal(t, 4500*time.Millisecond, notifier.deliveryTimeout())
+}
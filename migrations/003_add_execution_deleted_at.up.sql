@@ -0,0 +1,5 @@
+-- Soft-delete support: deleted rows keep their data for audit purposes
+ALTER TABLE execution ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Most queries only look at live rows
+CREATE INDEX IF NOT EXISTS execution_deleted_at_ndx ON execution(deleted_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- Support trade date range filters and the GROUP BY aggregations used by the stats endpoint
+CREATE INDEX IF NOT EXISTS execution_trade_date_ndx ON execution(trade_date);
+CREATE INDEX IF NOT EXISTS execution_trade_type_ndx ON execution(trade_type);
+CREATE INDEX IF NOT EXISTS execution_destination_ndx ON execution(destination);
@@ -0,0 +1,4 @@
+-- Track the outcome of each Send batch so failed batches can be retried
+ALTER TABLE batch_history ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed';
+
+CREATE INDEX IF NOT EXISTS batch_history_status_ndx ON batch_history(status);
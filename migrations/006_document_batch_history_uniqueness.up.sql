@@ -0,0 +1,7 @@
+-- Send relies on the unique indexes created in 001 for duplicate-batch detection:
+-- two concurrent Sends both read the same MAX(start_time) and insert it as
+-- previous_start_time, so the second insert fails with unique_violation (23505).
+COMMENT ON INDEX batch_history_start_time_ndx IS
+    'One batch per window end; guards against two batches claiming the same instant';
+COMMENT ON INDEX batch_history_previous_start_time_ndx IS
+    'One batch per window start; the second of two concurrent Sends fails here and is reported as a duplicate batch';
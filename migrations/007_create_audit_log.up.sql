@@ -0,0 +1,27 @@
+-- Append-only record of Send and batch retry invocations for compliance
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR(50) NOT NULL,
+    batch_id INTEGER,
+    correlation_id VARCHAR(100),
+    actor VARCHAR(100),
+    processed_count INTEGER NOT NULL DEFAULT 0,
+    file_name VARCHAR(255),
+    outcome VARCHAR(20) NOT NULL,
+    message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_ndx ON audit_log(created_at);
+
+-- Audit rows are immutable once written
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log rows cannot be modified or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_immutable_trg ON audit_log;
+CREATE TRIGGER audit_log_immutable_trg
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
@@ -0,0 +1,4 @@
+-- Trace context of the request that created each execution, so a Send trace can
+-- link back to the creates that produced its batch
+ALTER TABLE execution ADD COLUMN IF NOT EXISTS create_trace_id VARCHAR(32);
+ALTER TABLE execution ADD COLUMN IF NOT EXISTS create_span_id VARCHAR(16);
@@ -0,0 +1,10 @@
+-- Executions skipped for reasons that can clear up later (still open, portfolio lookup
+-- failed), with the submitted payload so they can be reprocessed without resubmitting
+CREATE TABLE IF NOT EXISTS skipped_execution (
+    execution_service_id INTEGER PRIMARY KEY,
+    reason VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    skipped_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS skipped_execution_reason_ndx ON skipped_execution(reason, skipped_at);
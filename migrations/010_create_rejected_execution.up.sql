@@ -0,0 +1,12 @@
+-- Every execution that was skipped or failed during batch create, for later triage
+CREATE TABLE IF NOT EXISTS rejected_execution (
+    id SERIAL PRIMARY KEY,
+    execution_service_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason VARCHAR(50),
+    error TEXT,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS rejected_execution_created_at_ndx ON rejected_execution(created_at);
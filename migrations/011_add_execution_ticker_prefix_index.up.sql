@@ -0,0 +1,4 @@
+-- Support case-insensitive ticker prefix search: lower(ticker) LIKE 'abc%' can use this
+-- index (text_pattern_ops makes LIKE prefixes indexable under any collation), while a
+-- leading wildcard such as '%abc' or a plain ILIKE cannot
+CREATE INDEX IF NOT EXISTS execution_ticker_prefix_ndx ON execution(lower(ticker) text_pattern_ops);
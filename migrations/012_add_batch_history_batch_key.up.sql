@@ -0,0 +1,7 @@
+-- Client-supplied key that makes Send idempotent: a Send repeating the key of a
+-- completed batch gets that batch's stored response instead of starting a new one.
+-- Unkeyed batches leave both columns NULL, which the unique index allows any number of.
+ALTER TABLE batch_history ADD COLUMN IF NOT EXISTS batch_key VARCHAR(255);
+ALTER TABLE batch_history ADD COLUMN IF NOT EXISTS send_response JSONB;
+
+CREATE UNIQUE INDEX IF NOT EXISTS batch_history_batch_key_ndx ON batch_history(batch_key);
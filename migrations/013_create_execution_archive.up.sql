@@ -0,0 +1,32 @@
+-- Sent executions past the retention period, moved out of the hot execution table by
+-- the archival job. Columns are listed rather than copied from execution, so the
+-- archive keeps its shape; a column later added to execution must be added here too,
+-- and to executionColumns in the repository.
+CREATE TABLE IF NOT EXISTS execution_archive (
+    id INTEGER PRIMARY KEY,
+    execution_service_id INTEGER NOT NULL UNIQUE,
+    is_open BOOLEAN NOT NULL,
+    execution_status VARCHAR(20) NOT NULL,
+    trade_type VARCHAR(10) NOT NULL,
+    destination VARCHAR(20) NOT NULL,
+    trade_date DATE NOT NULL,
+    security_id CHAR(24) NOT NULL,
+    ticker VARCHAR(20) NOT NULL,
+    portfolio_id CHAR(24),
+    quantity DECIMAL(18,8) NOT NULL,
+    limit_price DECIMAL(18,8),
+    received_timestamp TIMESTAMPTZ NOT NULL,
+    sent_timestamp TIMESTAMPTZ NOT NULL,
+    last_fill_timestamp TIMESTAMPTZ,
+    quantity_filled DECIMAL(18,8) NOT NULL,
+    total_amount DECIMAL(18,8),
+    average_price DECIMAL(18,8) NOT NULL,
+    ready_to_send_timestamp TIMESTAMPTZ,
+    version INTEGER NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    create_trace_id VARCHAR(32),
+    create_span_id VARCHAR(16),
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS execution_archive_ready_to_send_timestamp_ndx ON execution_archive(ready_to_send_timestamp);
@@ -0,0 +1,3 @@
+-- A Send split by portfolio records every file it wrote, comma-separated, which soon
+-- outgrows VARCHAR(255)
+ALTER TABLE audit_log ALTER COLUMN file_name TYPE TEXT;
@@ -0,0 +1,4 @@
+-- Portfolios whose file the CLI already loaded in a batch split by portfolio. A retry of
+-- the batch after a later file failed leaves these portfolios out, so they are not
+-- booked twice.
+ALTER TABLE batch_history ADD COLUMN IF NOT EXISTS loaded_portfolios TEXT[];